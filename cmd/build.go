@@ -43,8 +43,10 @@ var (
 	skipChangelog    bool
 	skipExternals    bool
 	forceExternals   bool
+	frozen           bool
 	skipZip          bool
 	skipUpload       bool
+	dryRunUpload     bool
 	nameTemplate     string
 	skipLocalization bool
 	onlyLocalization bool
@@ -57,7 +59,9 @@ var (
 var buildCmd = &cobra.Command{
 	Use:   "build",
 	Short: "Builds a World of Warcraft addon",
-	Long:  `This command packages the addon as specified via a pkgmeta file.`,
+	Long: `This command packages the addon as specified via a pkgmeta file.
+
+When the pkgmeta file lists packages, or the top directory has no TOC file but its folders do, each of those addons is built in the same run.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if cmd.Flags().Changed("topDir") && !cmd.Flags().Changed("releaseDir") {
 			releaseDir = filepath.Join(topDir, ".release")
@@ -76,8 +80,10 @@ var buildCmd = &cobra.Command{
 			SkipChangelog:    skipChangelog,
 			SkipExternals:    skipExternals,
 			ForceExternals:   forceExternals,
+			Frozen:           frozen,
 			SkipZip:          skipZip,
 			SkipUpload:       skipUpload,
+			DryRunUpload:     dryRunUpload,
 			NameTemplate:     nameTemplate,
 			SkipLocalization: skipLocalization,
 			OnlyLocalization: onlyLocalization,
@@ -109,8 +115,10 @@ func init() {
 	buildCmd.Flags().BoolVar(&skipChangelog, "skipChangelog", false, "Skip changelog generation.")
 	buildCmd.Flags().BoolVarP(&skipExternals, "skipExternals", "e", false, "Skip fetching externals.")
 	buildCmd.Flags().BoolVarP(&forceExternals, "forceExternals", "E", false, "Force fetching externals, bypassing the cache.")
+	buildCmd.Flags().BoolVar(&frozen, "frozen", false, "Fail if the externals don't match the revisions pinned in the .wbt.lock file.")
 	buildCmd.Flags().BoolVarP(&skipZip, "skipZip", "z", false, "Skip zipping the package (and uploading).")
 	buildCmd.Flags().BoolVarP(&skipUpload, "skipUpload", "d", false, "Skip uploading.")
+	buildCmd.Flags().BoolVar(&dryRunUpload, "dryRunUpload", false, "Write the upload requests to {releaseDir}/upload-plan.json instead of sending them.")
 	buildCmd.Flags().StringVarP(&nameTemplate, "nameTemplate", "n", "", "Set the name template to use for the release file. Use \"-n help\" for more info.")
 	buildCmd.Flags().BoolVarP(&skipLocalization, "skipLocalization", "l", false, "Skip @localization@ keyword replacement.")
 	buildCmd.Flags().BoolVarP(&onlyLocalization, "onlyLocalization", "L", false, "Only do @localization@ keyword replacement (skip upload to CurseForge).")
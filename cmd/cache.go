@@ -0,0 +1,98 @@
+/*
+Copyright © 2025 Rob "McTalian" Anderson
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/McTalian/wow-build-tools/internal/cmdimpl"
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneDays          int
+	removeCorruptCache bool
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the externals cache",
+	Long: dedent.Dedent(`
+		Externals are checked out into a cache shared by every project, in the .wow-build-tools/.cache/externals
+		directory of your home directory, and copied into the package from there.`),
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached externals",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdimpl.CacheList()
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove the cached externals no build used recently",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdimpl.CachePrune(pruneDays)
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached external",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdimpl.CacheClear()
+	},
+}
+
+var cacheInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show the size of the cache and verify the cached externals",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdimpl.CacheInfo()
+	},
+}
+
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify [name...]",
+	Short: "Verify the integrity of the cached externals",
+	Long: dedent.Dedent(`
+		Verify the integrity of the named cached externals, or of all of them, e.g. that no git objects
+		are corrupted. Builds only verify a cached external when it fails to check out, since verifying
+		reads all of it. The names are the ones "cache list" shows.`),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdimpl.CacheVerify(args, removeCorruptCache)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheInfoCmd)
+	cacheCmd.AddCommand(cacheVerifyCmd)
+
+	cachePruneCmd.Flags().IntVar(&pruneDays, "olderThan", 30, "Remove the externals no build used in this many days")
+	cacheVerifyCmd.Flags().BoolVar(&removeCorruptCache, "remove", false, "Remove the corrupted externals, so the next build fetches them again")
+}
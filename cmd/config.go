@@ -32,6 +32,7 @@ import (
 
 	"github.com/McTalian/wow-build-tools/internal/configdir"
 	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/toc"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -99,39 +100,33 @@ func createConfigFileIfNotExist(localPath string) error {
 	return nil
 }
 
+// Flavor is the name of a WoW installation, e.g. retail or classicEraPtr, from the flavor registry.
 type Flavor string
 
-const (
-	retail        Flavor = "retail"
-	classic       Flavor = "classic"
-	classicEra    Flavor = "classicEra"
-	ptr           Flavor = "ptr"
-	xptr          Flavor = "xptr"
-	classicPtr    Flavor = "classicPtr"
-	classicEraPtr Flavor = "classicEraPtr"
-)
+func knownFlavors() []Flavor {
+	var flavors []Flavor
+	for _, f := range toc.Flavors() {
+		for _, install := range f.Installs {
+			flavors = append(flavors, Flavor(install.Name))
+		}
+	}
+	return flavors
+}
 
-var knownFlavors = []Flavor{retail, classic, classicEra, ptr, xptr, classicPtr, classicEraPtr}
+func (f Flavor) install() (toc.FlavorInstall, bool) {
+	for _, gameFlavor := range toc.Flavors() {
+		for _, install := range gameFlavor.Installs {
+			if install.Name == string(f) {
+				return install, true
+			}
+		}
+	}
+	return toc.FlavorInstall{}, false
+}
 
 func (f Flavor) ToDir() string {
-	switch f {
-	case retail:
-		return "_retail_"
-	case classic:
-		return "_classic_"
-	case classicEra:
-		return "_classic_era_"
-	case ptr:
-		return "_ptr_"
-	case xptr:
-		return "_xptr_"
-	case classicPtr:
-		return "_classic_ptr_"
-	case classicEraPtr:
-		return "_classic_era_ptr_"
-	default:
-		return ""
-	}
+	install, _ := f.install()
+	return install.Dir
 }
 
 func capitalize(s string) string {
@@ -218,27 +213,14 @@ func setWoWPath(reader *bufio.Reader, value ...string) error {
 	}
 
 	for _, entry := range contents {
-		if entry.IsDir() && entry.Name() == "_retail_" {
-			logger.Success("Found Retail World of Warcraft installation at: %s", filepath.Join(wowPath, entry.Name()))
-			viper.Set("wowPath.retail", filepath.Join(wowPath, entry.Name()))
-		} else if entry.IsDir() && entry.Name() == "_classic_" {
-			logger.Success("Found Classic World of Warcraft installation at: %s", filepath.Join(wowPath, entry.Name()))
-			viper.Set("wowPath.classic", filepath.Join(wowPath, entry.Name()))
-		} else if entry.IsDir() && entry.Name() == "_classic_era_" {
-			logger.Success("Found Classic Era World of Warcraft installation at: %s", filepath.Join(wowPath, entry.Name()))
-			viper.Set("wowPath.classicEra", filepath.Join(wowPath, entry.Name()))
-		} else if entry.IsDir() && entry.Name() == "_classic_era_ptr_" {
-			logger.Success("Found Classic Era PTR World of Warcraft installation at: %s", filepath.Join(wowPath, entry.Name()))
-			viper.Set("wowPath.classicEraPtr", filepath.Join(wowPath, entry.Name()))
-		} else if entry.IsDir() && entry.Name() == "_ptr_" {
-			logger.Success("Found PTR World of Warcraft installation at: %s", filepath.Join(wowPath, entry.Name()))
-			viper.Set("wowPath.ptr", filepath.Join(wowPath, entry.Name()))
-		} else if entry.IsDir() && entry.Name() == "_xptr_" {
-			logger.Success("Found XPTR World of Warcraft installation at: %s", filepath.Join(wowPath, entry.Name()))
-			viper.Set("wowPath.xptr", filepath.Join(wowPath, entry.Name()))
-		} else if entry.IsDir() && entry.Name() == "_classic_ptr_" {
-			logger.Success("Found Classic PTR World of Warcraft installation at: %s", filepath.Join(wowPath, entry.Name()))
-			viper.Set("wowPath.classicPtr", filepath.Join(wowPath, entry.Name()))
+		if !entry.IsDir() {
+			continue
+		}
+		for _, flavor := range knownFlavors() {
+			if install, _ := flavor.install(); entry.Name() == install.Dir {
+				logger.Success("Found %s World of Warcraft installation at: %s", install.Label, filepath.Join(wowPath, entry.Name()))
+				viper.Set("wowPath."+string(flavor), filepath.Join(wowPath, entry.Name()))
+			}
 		}
 	}
 
@@ -267,7 +249,7 @@ func runConfigWizard() error {
 		logger.Info("1. Set or update Base World of Warcraft installation path")
 		nextNum := 2
 		if len(wowPaths) >= 1 {
-			for _, flavor := range knownFlavors {
+			for _, flavor := range knownFlavors() {
 				logger.Info("%d. Update %s World of Warcraft installation path", nextNum, capitalize(string(flavor)))
 				numberFlavorMap[nextNum] = flavor
 				nextNum++
@@ -356,7 +338,7 @@ var configCmd = &cobra.Command{
 					return fmt.Errorf("no subcommand provided for %s configuration", args[0])
 				}
 				validSecondaryArgs := []string{"base"}
-				for _, flavor := range knownFlavors {
+				for _, flavor := range knownFlavors() {
 					validSecondaryArgs = append(validSecondaryArgs, string(flavor))
 				}
 				if slices.Contains(validSecondaryArgs, args[1]) {
@@ -28,7 +28,6 @@ import (
 
 	"github.com/McTalian/wow-build-tools/internal/changelog"
 	"github.com/McTalian/wow-build-tools/internal/logger"
-	"github.com/McTalian/wow-build-tools/internal/pkg"
 	"github.com/McTalian/wow-build-tools/internal/toc"
 	"github.com/McTalian/wow-build-tools/internal/upload"
 	"github.com/spf13/cobra"
@@ -42,6 +41,7 @@ var curseCmd = &cobra.Command{
 	
 	Input, label, interface versions, and CurseForge project ID are required.
 	The CF_API_KEY environment variable must also be set.`,
+	PreRunE: requireSiteFlags,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tmp := os.TempDir()
 		tmpToc, err := os.CreateTemp(tmp, "wbt*.toc")
@@ -104,16 +104,19 @@ var curseCmd = &cobra.Command{
 			return err
 		}
 
-		pkgMeta := &pkg.PkgMeta{}
+		gameVersions := toc.NewGameVersionSet()
+		gameVersions.AddToc(tocFile)
 
+		plan := sitePlan()
 		curseArgs := upload.UploadCurseArgs{
-			TocFiles:    []*toc.Toc{tocFile},
-			ZipPath:     UploadInput,
-			FileLabel:   UploadLabel,
-			PkgMeta:     pkgMeta,
-			Changelog:   changelog,
-			ReleaseType: UploadReleaseType,
-			CurseId:     curseId,
+			TocFiles:     []*toc.Toc{tocFile},
+			GameVersions: gameVersions,
+			ZipPath:      UploadInput,
+			FileLabel:    UploadLabel,
+			Changelog:    changelog,
+			ReleaseType:  UploadReleaseType,
+			CurseId:      curseId,
+			Plan:         plan,
 		}
 
 		err = upload.UploadToCurse(curseArgs)
@@ -122,12 +125,13 @@ var curseCmd = &cobra.Command{
 			return err
 		}
 
-		return nil
+		return printPlan(plan)
 	},
 }
 
 func init() {
 	uploadCmd.AddCommand(curseCmd)
+	addSiteFlags(curseCmd, true)
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -0,0 +1,76 @@
+/*
+Copyright © 2025 Rob "McTalian" Anderson
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/McTalian/wow-build-tools/internal/cmdimpl"
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+)
+
+// externalsCmd represents the externals command
+var externalsCmd = &cobra.Command{
+	Use:   "externals",
+	Short: "Manage the externals of an addon",
+	Long: dedent.Dedent(`
+		The externals of the pkgmeta file are pinned to the revisions they resolved to in the .wbt.lock
+		file of the top directory. Builds check out the pinned revisions, and "build --frozen" fails when an
+		external isn't pinned, so release builds are reproducible. Commit the .wbt.lock file along with
+		the pkgmeta file.`),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := rootCmd.PersistentPreRunE(cmd, args); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("topDir") && !cmd.Flags().Changed("releaseDir") {
+			releaseDir = filepath.Join(topDir, ".release")
+		}
+		return nil
+	},
+}
+
+var externalsUpdateCmd = &cobra.Command{
+	Use:   "update [path...]",
+	Short: "Resolve the externals again and pin their latest revisions",
+	Long: dedent.Dedent(`
+		Resolve the externals again, bypassing the cache, and pin the revisions in the .wbt.lock file,
+		creating it if needed. Only the externals with the given paths are updated when there are any.`),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdimpl.ExternalsUpdate(&cmdimpl.ExternalsArgs{
+			TopDir:      topDir,
+			ReleaseDir:  releaseDir,
+			PkgmetaFile: pkgmetaFile,
+			Paths:       args,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(externalsCmd)
+	externalsCmd.AddCommand(externalsUpdateCmd)
+
+	externalsCmd.PersistentFlags().StringVarP(&topDir, "topDir", "t", ".", "The top level directory of the addon")
+	externalsCmd.PersistentFlags().StringVarP(&releaseDir, "releaseDir", "r", "."+string(os.PathSeparator)+".release", "The directory the externals are fetched into")
+	externalsCmd.PersistentFlags().StringVarP(&pkgmetaFile, "pkgmetaFile", "m", "", "Set the pkgmeta file to use.")
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2025 Rob "McTalian" Anderson
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/McTalian/wow-build-tools/internal/cmdimpl"
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+)
+
+var localeTableName string
+
+// localeCmd represents the locale command
+var localeCmd = &cobra.Command{
+	Use:   "locale",
+	Short: "Keep the localization of an addon in sync with its code",
+	Long: dedent.Dedent(`
+		Scan the Lua files of the addon for phrases, e.g. L["Hello"], and compare them with the base locale.
+
+		The base locale comes from the "localization" files of the pkgmeta file when set, otherwise from the
+		CurseForge localization of the project, which needs a CurseForge project id and the CF_API_KEY
+		environment variable.`),
+}
+
+func localeArgs() *cmdimpl.LocaleArgs {
+	return &cmdimpl.LocaleArgs{
+		TopDir:      topDir,
+		PkgmetaFile: pkgmetaFile,
+		CurseId:     curseId,
+		TableName:   localeTableName,
+	}
+}
+
+var localeExtractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "Print the phrases used in the code as a Lua table",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdimpl.LocaleExtract(localeArgs())
+	},
+}
+
+var localeDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Report phrases missing from the base locale and unused base locale strings",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdimpl.LocaleDiff(localeArgs())
+	},
+}
+
+var localePushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Add the phrases missing from the base locale to the CurseForge project",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdimpl.LocalePush(localeArgs())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(localeCmd)
+	localeCmd.AddCommand(localeExtractCmd)
+	localeCmd.AddCommand(localeDiffCmd)
+	localeCmd.AddCommand(localePushCmd)
+
+	localeCmd.PersistentFlags().StringVarP(&topDir, "topDir", "t", ".", "The top level directory of the addon")
+	localeCmd.PersistentFlags().StringVarP(&pkgmetaFile, "pkgmetaFile", "m", "", "Set the pkgmeta file to use.")
+	localeCmd.PersistentFlags().StringVarP(&curseId, "curseId", "p", "", "Set the CurseForge project ID for localization.")
+	localeCmd.PersistentFlags().StringVar(&localeTableName, "tableName", "L", "The name of the localization table in the code")
+}
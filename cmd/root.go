@@ -24,8 +24,10 @@ package cmd
 import (
 	"os"
 
+	"github.com/McTalian/wow-build-tools/internal/api"
 	"github.com/McTalian/wow-build-tools/internal/configdir"
 	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/toc"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -44,6 +46,54 @@ var rootCmd = &cobra.Command{
 	// Run: func(cmd *cobra.Command, args []string) { },
 }
 
+// apiEnv maps the `api` and `http` keys of .wbt.yaml to the environment variables that
+// override them.
+var apiEnv = map[string]string{
+	"api.curseApiUrl":     "WBT_CURSE_API_URL",
+	"api.wagoApiUrl":      "WBT_WAGO_API_URL",
+	"api.wowiApiUrl":      "WBT_WOWI_API_URL",
+	"api.githubApiUrl":    "WBT_GITHUB_API_URL",
+	"api.githubUploadUrl": "WBT_GITHUB_UPLOAD_URL",
+	"api.licenseUrl":      "WBT_LICENSE_URL",
+	"http.timeout":        "WBT_HTTP_TIMEOUT",
+	"http.proxy":          "WBT_HTTP_PROXY",
+	"http.userAgent":      "WBT_HTTP_USER_AGENT",
+}
+
+// configureApi points the uploads and GitHub calls at the base URLs and sends them with the
+// HTTP client of the configuration file or environment.
+func configureApi() error {
+	for key, env := range apiEnv {
+		if err := viper.BindEnv(key, env); err != nil {
+			return err
+		}
+	}
+	viper.SetDefault("http.userAgent", "wow-build-tools")
+
+	err := api.SetEndpoints(api.Endpoints{
+		CurseApiUrl:     viper.GetString("api.curseApiUrl"),
+		WagoApiUrl:      viper.GetString("api.wagoApiUrl"),
+		WowiApiUrl:      viper.GetString("api.wowiApiUrl"),
+		GitHubApiUrl:    viper.GetString("api.githubApiUrl"),
+		GitHubUploadUrl: viper.GetString("api.githubUploadUrl"),
+		LicenseUrl:      viper.GetString("api.licenseUrl"),
+	})
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClient(api.ClientOptions{
+		Timeout:   viper.GetDuration("http.timeout"),
+		Proxy:     viper.GetString("http.proxy"),
+		UserAgent: viper.GetString("http.userAgent"),
+	})
+	if err != nil {
+		return err
+	}
+	api.SetClient(client)
+	return nil
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -89,6 +139,23 @@ func init() {
 				return err
 			}
 		}
+
+		if viper.IsSet("flavors") {
+			var flavors []toc.FlavorInfo
+			if err = viper.UnmarshalKey("flavors", &flavors); err != nil {
+				logger.Error("Failed to read flavors from configuration file: %v", err)
+				return err
+			}
+			if err = toc.RegisterFlavors(flavors); err != nil {
+				logger.Error("Failed to register flavors from configuration file: %v", err)
+				return err
+			}
+		}
+
+		if err = configureApi(); err != nil {
+			logger.Error("Failed to configure the API URLs and HTTP client: %v", err)
+			return err
+		}
 		return nil
 	}
 	// Cobra also supports local flags, which will only run
@@ -23,68 +23,173 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
+	"github.com/lithammer/dedent"
 	"github.com/spf13/cobra"
+
+	"github.com/McTalian/wow-build-tools/internal/cmdimpl"
+	"github.com/McTalian/wow-build-tools/internal/upload"
 )
 
 var (
 	UploadInput             string
+	UploadNoLibInput        string
 	UploadLabel             string
 	UploadInterfaceVersions []int
 	UploadChangelog         string
 	UploadReleaseType       string
+	// uploadTagReleaseType is the --release-type of upload all and upload github, which default
+	// to the release type of the current tag rather than alpha
+	uploadTagReleaseType string
 )
 
+// sitePlan returns the plan the single site upload commands record their request in for a
+// dry run, or nil when they upload.
+func sitePlan() *upload.UploadPlan {
+	if !dryRunUpload {
+		return nil
+	}
+	return upload.NewUploadPlan()
+}
+
+// printPlan prints the plan of a dry run of a single site upload command, which has no release
+// directory to write it to.
+func printPlan(plan *upload.UploadPlan) error {
+	if plan == nil {
+		return nil
+	}
+	contents, err := plan.JSON()
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(contents))
+	return nil
+}
+
 // uploadCmd represents the upload command
 var uploadCmd = &cobra.Command{
 	Use:   "upload",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("upload called")
+	Short: "Upload a packaged addon without building it",
+	Long: dedent.Dedent(`
+		Upload an addon zip to CurseForge, WoWInterface, Wago or GitHub, e.g. to retry a release whose
+		upload failed without building it again.
+
+		"upload all" and "upload github" use the zips, TOC files, pkgmeta file and changelog of the
+		project in the top directory. The single site commands upload any zip, with the interface
+		versions, label and project ID given as flags.
+
+		With --dryRunUpload nothing is uploaded. "upload all" and "upload github" write the requests
+		to upload-plan.json in the release directory, the single site commands print them.`),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := rootCmd.PersistentPreRunE(cmd, args); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("topDir") && !cmd.Flags().Changed("releaseDir") {
+			releaseDir = filepath.Join(topDir, ".release")
+		}
+		return nil
+	},
+}
+
+// requireSiteFlags is the PreRunE of the single site upload commands, it fails like a required
+// flag does without the persistent --input flag, which upload all and upload github default.
+func requireSiteFlags(cmd *cobra.Command, args []string) error {
+	if !cmd.Flags().Changed("input") {
+		return fmt.Errorf(`required flag(s) "input" not set`)
+	}
+	return nil
+}
+
+// addSiteFlags adds the flags of the single site upload commands, wowi has no release type.
+func addSiteFlags(c *cobra.Command, withReleaseType bool) {
+	c.Flags().StringVarP(&UploadLabel, "label", "l", "", "Label for the uploaded file")
+	c.Flags().IntSliceVar(&UploadInterfaceVersions, "interface-versions", []int{}, "Interface versions that your addon supports.")
+	if withReleaseType {
+		c.Flags().StringVarP(&UploadReleaseType, "release-type", "r", "alpha", "Release type for the uploaded file")
+	}
+	for _, name := range []string{"label", "interface-versions"} {
+		if err := c.MarkFlagRequired(name); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func uploadArgs() *cmdimpl.UploadArgs {
+	return &cmdimpl.UploadArgs{
+		TopDir:       topDir,
+		ReleaseDir:   releaseDir,
+		PkgmetaFile:  pkgmetaFile,
+		NameTemplate: nameTemplate,
+		ZipPath:      UploadInput,
+		NoLibZipPath: UploadNoLibInput,
+		Label:        UploadLabel,
+		ReleaseType:  uploadTagReleaseType,
+		Changelog:    UploadChangelog,
+		CurseId:      curseId,
+		WowiId:       wowiId,
+		WagoId:       wagoId,
+		DryRun:       dryRunUpload,
+	}
+}
+
+var uploadAllCmd = &cobra.Command{
+	Use:   "all",
+	Short: "Upload the zips of an earlier build to every configured site",
+	Long: dedent.Dedent(`
+		Upload the zips of an earlier build to CurseForge, WoWInterface, Wago and GitHub. Sites without a
+		project ID in the TOC files, or without their API token, are skipped like they are by build. The
+		addons of a workspace are uploaded to their own projects, unless their packages are combined.
+
+		The zips default to the ones the name template gives in the release directory, so pass the same
+		--nameTemplate as the build. The no-lib zip is uploaded too when it exists.`),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdimpl.UploadAll(uploadArgs())
+	},
+}
+
+var uploadGitHubCmd = &cobra.Command{
+	Use:   "github",
+	Short: "Upload the zips of an earlier build to the GitHub release",
+	Long: dedent.Dedent(`
+		Create or update the GitHub release of the current tag and attach the zips of an earlier build,
+		along with the release.json metadata. The GITHUB_OAUTH environment variable must be set.
+
+		The zips default to the ones the name template gives in the release directory, so pass the same
+		--nameTemplate as the build. The no-lib zip is uploaded too when it exists.`),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdimpl.UploadGitHub(uploadArgs())
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(uploadCmd)
+	uploadCmd.AddCommand(uploadAllCmd)
+	uploadCmd.AddCommand(uploadGitHubCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// uploadCmd.PersistentFlags().String("foo", "", "A help for foo")
 	uploadCmd.PersistentFlags().StringVarP(&UploadInput, "input", "i", "", "Path to the addon zip file to upload")
 	err := uploadCmd.MarkPersistentFlagFilename("input")
 	if err != nil {
 		panic(err)
 	}
-	err = uploadCmd.MarkPersistentFlagRequired("input")
-	if err != nil {
-		panic(err)
-	}
-	uploadCmd.PersistentFlags().StringVarP(&UploadLabel, "label", "l", "", "Label for the uploaded file")
-	err = uploadCmd.MarkPersistentFlagRequired("label")
-	if err != nil {
-		panic(err)
-	}
-	uploadCmd.PersistentFlags().IntSliceVar(&UploadInterfaceVersions, "interface-versions", []int{}, "Interface versions that your addon supports.")
-	err = uploadCmd.MarkPersistentFlagRequired("interface-versions")
-	if err != nil {
-		panic(err)
-	}
 	uploadCmd.PersistentFlags().StringVarP(&UploadChangelog, "changelog", "c", "", "Path to the changelog file")
 	err = uploadCmd.MarkPersistentFlagFilename("changelog")
 	if err != nil {
 		panic(err)
 	}
-	uploadCmd.PersistentFlags().StringVarP(&UploadReleaseType, "release-type", "r", "alpha", "Release type for the uploaded file")
+	uploadCmd.PersistentFlags().BoolVar(&dryRunUpload, "dryRunUpload", false, "Write or print the upload requests instead of sending them")
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// uploadCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	for _, c := range []*cobra.Command{uploadAllCmd, uploadGitHubCmd} {
+		c.Flags().StringVarP(&topDir, "topDir", "t", ".", "The top level directory of the addon")
+		c.Flags().StringVar(&releaseDir, "releaseDir", "."+string(os.PathSeparator)+".release", "The directory with the release files of the build.")
+		c.Flags().StringVarP(&pkgmetaFile, "pkgmetaFile", "m", "", "Set the pkgmeta file to use.")
+		c.Flags().StringVarP(&nameTemplate, "nameTemplate", "n", "", "The name template the zips were built with.")
+		c.Flags().StringVar(&UploadNoLibInput, "nolib-input", "", "Path to the no-lib zip file to upload")
+		c.Flags().StringVarP(&UploadLabel, "label", "l", "", "Label for the uploaded files, defaults to the label of the name template")
+		c.Flags().StringVarP(&uploadTagReleaseType, "release-type", "r", "", "Release type for the uploaded files, defaults to the release type of the current tag")
+	}
+	uploadAllCmd.Flags().StringVarP(&curseId, "curseId", "p", "", "Set the CurseForge project ID for uploading. (Use 0 to unset the TOC value)")
+	uploadAllCmd.Flags().StringVarP(&wowiId, "wowiId", "w", "", "Set the WoWInterface project ID for uploading. (Use 0 to unset the TOC value)")
+	uploadAllCmd.Flags().StringVarP(&wagoId, "wagoId", "a", "", "Set the Wago project ID for uploading. (Use 0 to unset the TOC value)")
 }
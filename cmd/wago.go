@@ -41,6 +41,7 @@ var wagoCmd = &cobra.Command{
 	
 	Input, label, and Wago.io project ID are required.
 	The WAGO_API_TOKEN environment variable must also be set.`,
+	PreRunE: requireSiteFlags,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tmp := os.TempDir()
 		tmpToc, err := os.CreateTemp(tmp, "wbt*.toc")
@@ -103,13 +104,19 @@ var wagoCmd = &cobra.Command{
 			return err
 		}
 
+		gameVersions := toc.NewGameVersionSet()
+		gameVersions.AddToc(tocFile)
+
+		plan := sitePlan()
 		wagoArgs := upload.UploadWagoArgs{
-			ZipPath:     UploadInput,
-			FileLabel:   UploadLabel,
-			ReleaseType: UploadReleaseType,
-			TocFiles:    []*toc.Toc{tocFile},
-			Changelog:   changelog,
-			WagoId:      wagoId,
+			ZipPath:      UploadInput,
+			FileLabel:    UploadLabel,
+			ReleaseType:  UploadReleaseType,
+			TocFiles:     []*toc.Toc{tocFile},
+			GameVersions: gameVersions,
+			Changelog:    changelog,
+			WagoId:       wagoId,
+			Plan:         plan,
 		}
 
 		err = upload.UploadToWago(wagoArgs)
@@ -118,12 +125,13 @@ var wagoCmd = &cobra.Command{
 			return err
 		}
 
-		return nil
+		return printPlan(plan)
 	},
 }
 
 func init() {
 	uploadCmd.AddCommand(wagoCmd)
+	addSiteFlags(wagoCmd, true)
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
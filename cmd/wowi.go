@@ -43,6 +43,7 @@ var wowiCmd = &cobra.Command{
 	
 	Input, label, and WoWInterface project ID are required.
 	The WOWI_API_TOKEN environment variable must also be set.`,
+	PreRunE: requireSiteFlags,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tmp := os.TempDir()
 		tmpToc, err := os.CreateTemp(tmp, "wbt*.toc")
@@ -105,13 +106,19 @@ var wowiCmd = &cobra.Command{
 			return err
 		}
 
+		gameVersions := toc.NewGameVersionSet()
+		gameVersions.AddToc(tocFile)
+
+		plan := sitePlan()
 		w := upload.UploadWowiArgs{
 			TocFiles:       []*toc.Toc{tocFile},
+			GameVersions:   gameVersions,
 			ProjectVersion: UploadProjectVersion,
 			ZipPath:        UploadInput,
 			FileLabel:      UploadLabel,
 			Changelog:      changelog,
 			WowiId:         wowiId,
+			Plan:           plan,
 		}
 
 		err = upload.UploadToWowi(w)
@@ -120,12 +127,13 @@ var wowiCmd = &cobra.Command{
 			return err
 		}
 
-		return nil
+		return printPlan(plan)
 	},
 }
 
 func init() {
 	uploadCmd.AddCommand(wowiCmd)
+	addSiteFlags(wowiCmd, false)
 
 	// Here you will define your flags and configuration settings.
 
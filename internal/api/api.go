@@ -0,0 +1,154 @@
+// Package api holds the base URLs of the sites releases are published to and the HTTP client
+// the requests are sent with, so both can be pointed elsewhere, e.g. at GitHub Enterprise or
+// at a local stand-in server in tests.
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoints are the base URLs of the APIs, each ending with a slash.
+type Endpoints struct {
+	CurseApiUrl     string
+	WagoApiUrl      string
+	WowiApiUrl      string
+	GitHubApiUrl    string
+	GitHubUploadUrl string
+	// LicenseUrl is where the license of a CurseForge project is looked up
+	LicenseUrl string
+}
+
+var defaultEndpoints = Endpoints{
+	CurseApiUrl:     "https://wow.curseforge.com/api/",
+	WagoApiUrl:      "https://addons.wago.io/api/",
+	WowiApiUrl:      "https://api.wowinterface.com/addons/",
+	GitHubApiUrl:    "https://api.github.com/",
+	GitHubUploadUrl: "https://uploads.github.com/",
+	LicenseUrl:      "https://www.wowace.com/project/",
+}
+
+var (
+	mu        sync.RWMutex
+	endpoints = defaultEndpoints
+	client    = http.DefaultClient
+)
+
+// withSlash makes sure the base URL ends with a slash, the paths are appended to it.
+func withSlash(baseUrl string) string {
+	if baseUrl == "" || strings.HasSuffix(baseUrl, "/") {
+		return baseUrl
+	}
+	return baseUrl + "/"
+}
+
+// SetEndpoints merges the base URLs, e.g. from the `api` key of .wbt.yaml, into the current
+// ones. URLs left empty keep their current value.
+func SetEndpoints(overrides Endpoints) error {
+	merged := Current()
+	for _, field := range []struct {
+		override string
+		target   *string
+	}{
+		{overrides.CurseApiUrl, &merged.CurseApiUrl},
+		{overrides.WagoApiUrl, &merged.WagoApiUrl},
+		{overrides.WowiApiUrl, &merged.WowiApiUrl},
+		{overrides.GitHubApiUrl, &merged.GitHubApiUrl},
+		{overrides.GitHubUploadUrl, &merged.GitHubUploadUrl},
+		{overrides.LicenseUrl, &merged.LicenseUrl},
+	} {
+		if field.override == "" {
+			continue
+		}
+		if u, err := url.Parse(field.override); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid API URL %s", field.override)
+		}
+		*field.target = withSlash(field.override)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	endpoints = merged
+	return nil
+}
+
+// ResetEndpoints restores the base URLs of the public sites.
+func ResetEndpoints() {
+	mu.Lock()
+	defer mu.Unlock()
+	endpoints = defaultEndpoints
+}
+
+// Current returns the base URLs requests are sent to.
+func Current() Endpoints {
+	mu.RLock()
+	defer mu.RUnlock()
+	return endpoints
+}
+
+// ClientOptions configure the HTTP client, e.g. from the `http` key of .wbt.yaml.
+type ClientOptions struct {
+	// Timeout limits the time of a request including reading the response, zero means no limit
+	Timeout time.Duration
+	// Proxy is the URL of the proxy requests go through, the HTTP_PROXY and HTTPS_PROXY
+	// environment variables are used when it's empty
+	Proxy     string
+	UserAgent string
+}
+
+// userAgentTransport sets the User-Agent header of the requests that don't have one.
+type userAgentTransport struct {
+	userAgent string
+	next      http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// NewClient returns an HTTP client with the options.
+func NewClient(opts ClientOptions) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Proxy != "" {
+		proxyUrl, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %s: %w", opts.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if opts.UserAgent != "" {
+		roundTripper = &userAgentTransport{userAgent: opts.UserAgent, next: transport}
+	}
+
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: roundTripper,
+	}, nil
+}
+
+// SetClient replaces the client requests are sent with, nil restores http.DefaultClient.
+func SetClient(c *http.Client) {
+	mu.Lock()
+	defer mu.Unlock()
+	if c == nil {
+		c = http.DefaultClient
+	}
+	client = c
+}
+
+// Client returns the client requests are sent with.
+func Client() *http.Client {
+	mu.RLock()
+	defer mu.RUnlock()
+	return client
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetEndpoints(t *testing.T) {
+	defer ResetEndpoints()
+
+	require.NoError(t, SetEndpoints(Endpoints{
+		GitHubApiUrl:    "https://github.example.com/api/v3",
+		GitHubUploadUrl: "https://github.example.com/api/uploads/",
+	}))
+	current := Current()
+	assert.Equal(t, "https://github.example.com/api/v3/", current.GitHubApiUrl)
+	assert.Equal(t, "https://github.example.com/api/uploads/", current.GitHubUploadUrl)
+	assert.Equal(t, defaultEndpoints.CurseApiUrl, current.CurseApiUrl)
+
+	assert.Error(t, SetEndpoints(Endpoints{WagoApiUrl: "addons.wago.io/api"}))
+	assert.Equal(t, defaultEndpoints.WagoApiUrl, Current().WagoApiUrl)
+
+	ResetEndpoints()
+	assert.Equal(t, defaultEndpoints, Current())
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient(ClientOptions{Proxy: "://proxy"})
+	assert.Error(t, err)
+
+	client, err := NewClient(ClientOptions{Proxy: "http://localhost:3128", UserAgent: "wow-build-tools"})
+	require.NoError(t, err)
+	assert.IsType(t, &userAgentTransport{}, client.Transport)
+}
@@ -0,0 +1,191 @@
+package cmdimpl
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/McTalian/wow-build-tools/internal/configdir"
+	"github.com/McTalian/wow-build-tools/internal/external"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+)
+
+// formatSize returns a size in bytes as e.g. "1.5 MB".
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGT"[exp])
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func readCache() (string, []external.CacheEntry, error) {
+	cacheDir, err := configdir.GetExternalsCache()
+	if err != nil {
+		return "", nil, err
+	}
+	entries, err := external.ReadCache(cacheDir)
+	if err != nil {
+		return "", nil, err
+	}
+	return cacheDir, entries, nil
+}
+
+// CacheList is the implementation of the cache list command.
+func CacheList() error {
+	_, entries, err := readCache()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		logger.Info("The externals cache is empty")
+		return nil
+	}
+
+	for _, entry := range entries {
+		fmt.Printf("📦 %s\n", entry.Name())
+		if entry.Info.URL != "" {
+			fmt.Printf("    URL: %s\n", entry.Info.URL)
+			fmt.Printf("    Ref: %s\n", entry.Info.Ref)
+		}
+		fmt.Printf("    Type: %s\n", external.TypeColor(entry.Type()))
+		fmt.Printf("    Last Updated: %s\n", formatTime(entry.LastUpdated))
+		if projects := entry.Projects(); len(projects) > 0 {
+			fmt.Printf("    Last Used: %s by %s\n", formatTime(entry.LastUsed()), strings.Join(projects, ", "))
+		}
+		fmt.Printf("    Size: %s\n", formatSize(entry.Size))
+	}
+	return nil
+}
+
+// CachePrune is the implementation of the cache prune command, it removes the caches no build
+// used in the given number of days.
+func CachePrune(days int) error {
+	cacheDir, err := configdir.GetExternalsCache()
+	if err != nil {
+		return err
+	}
+
+	pruned, err := external.PruneCache(cacheDir, time.Duration(days)*24*time.Hour)
+	var size int64
+	for _, entry := range pruned {
+		logger.Verbose("Removed %s, last used %s", entry.Name(), formatTime(entry.LastUsed()))
+		size += entry.Size
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Success("✨ Pruned %d cached externals (%s) unused for %d days", len(pruned), formatSize(size), days)
+	return nil
+}
+
+// CacheClear is the implementation of the cache clear command.
+func CacheClear() error {
+	if err := configdir.DeleteExternalsCache(); err != nil {
+		return err
+	}
+	logger.Success("✨ Cleared the externals cache")
+	return nil
+}
+
+// verifyCache verifies the integrity of the cached externals and returns the number of
+// corrupted ones, which are removed when remove is set.
+func verifyCache(entries []external.CacheEntry, remove bool) (int, error) {
+	corrupted := 0
+	for _, entry := range entries {
+		logger.Verbose("Verifying %s", entry.Name())
+		if err := external.VerifyCache(entry.Dir); err != nil {
+			logger.Warn("%s is corrupted: %v", entry.Name(), err)
+			corrupted++
+			if !remove {
+				continue
+			}
+			if err := os.RemoveAll(entry.Dir); err != nil {
+				return corrupted, fmt.Errorf("failed to remove %s: %w", entry.Dir, err)
+			}
+			logger.Info("Removed %s, the next build fetches it again", entry.Name())
+		}
+	}
+	return corrupted, nil
+}
+
+// CacheInfo is the implementation of the cache info command, it summarizes the cache and
+// verifies the integrity of every cached external.
+func CacheInfo() error {
+	cacheDir, entries, err := readCache()
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, entry := range entries {
+		size += entry.Size
+	}
+	corrupted, err := verifyCache(entries, false)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Location: %s\n", cacheDir)
+	fmt.Printf("Externals: %d\n", len(entries))
+	fmt.Printf("Size: %s\n", formatSize(size))
+	if corrupted > 0 {
+		return fmt.Errorf("%d cached externals are corrupted, they are removed by `wow-build-tools cache verify --remove`", corrupted)
+	}
+	logger.Success("✨ All cached externals are intact")
+	return nil
+}
+
+// selectCacheEntries returns the cached externals with the names, or all of them when no names
+// are given.
+func selectCacheEntries(entries []external.CacheEntry, names []string) ([]external.CacheEntry, error) {
+	if len(names) == 0 {
+		return entries, nil
+	}
+	var selected []external.CacheEntry
+	for _, name := range names {
+		i := slices.IndexFunc(entries, func(e external.CacheEntry) bool { return e.Name() == name })
+		if i == -1 {
+			return nil, fmt.Errorf("%s is not in the externals cache, see `wow-build-tools cache list`", name)
+		}
+		selected = append(selected, entries[i])
+	}
+	return selected, nil
+}
+
+// CacheVerify is the implementation of the cache verify command, it verifies the integrity of
+// the named cached externals, or of all of them when no names are given.
+func CacheVerify(names []string, remove bool) error {
+	_, entries, err := readCache()
+	if err != nil {
+		return err
+	}
+
+	if entries, err = selectCacheEntries(entries, names); err != nil {
+		return err
+	}
+	corrupted, err := verifyCache(entries, remove)
+	if err != nil {
+		return err
+	}
+	if corrupted > 0 && !remove {
+		return fmt.Errorf("%d of %d cached externals are corrupted, remove them with --remove", corrupted, len(entries))
+	}
+	logger.Success("✨ Verified %d cached externals", len(entries))
+	return nil
+}
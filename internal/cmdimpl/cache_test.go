@@ -0,0 +1,44 @@
+package cmdimpl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/McTalian/wow-build-tools/internal/external"
+)
+
+func TestVerifyCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	intact := newTestProject(t, map[string]string{"LibFoo.lua": "local version = 1\n"})
+	require.NoError(t, os.Rename(intact, filepath.Join(cacheDir, "LibFoo")))
+	// Not a checkout, like a cache whose .git directory was lost
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "LibBar"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "LibBar", "LibBar.lua"), []byte("garbage"), 0644))
+
+	entries, err := external.ReadCache(cacheDir)
+	require.NoError(t, err)
+
+	_, err = selectCacheEntries(entries, []string{"LibBaz"})
+	assert.ErrorContains(t, err, "LibBaz is not in the externals cache")
+
+	selected, err := selectCacheEntries(entries, []string{"LibFoo"})
+	require.NoError(t, err)
+	corrupted, err := verifyCache(selected, true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, corrupted)
+
+	corrupted, err = verifyCache(entries, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, corrupted)
+	assert.DirExists(t, filepath.Join(cacheDir, "LibBar"))
+
+	corrupted, err = verifyCache(entries, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, corrupted)
+	assert.NoDirExists(t, filepath.Join(cacheDir, "LibBar"))
+	assert.DirExists(t, filepath.Join(cacheDir, "LibFoo"))
+}
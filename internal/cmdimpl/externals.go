@@ -0,0 +1,44 @@
+package cmdimpl
+
+import (
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/pkg"
+	"github.com/McTalian/wow-build-tools/pkg/packager"
+)
+
+type ExternalsArgs struct {
+	TopDir      string
+	ReleaseDir  string
+	PkgmetaFile string
+	// Paths are the externals to update, all of them when empty
+	Paths []string
+}
+
+// ExternalsUpdate is the implementation of the externals update command, it resolves the
+// externals again and pins the new revisions in the lock file.
+func ExternalsUpdate(args *ExternalsArgs) error {
+	l := logger.DefaultLogger
+
+	ctx, err := packager.NewBuildContext(&packager.Options{
+		TopDir:        args.TopDir,
+		ReleaseDir:    args.ReleaseDir,
+		PkgmetaFile:   args.PkgmetaFile,
+		SkipChangelog: true,
+		SkipUpload:    true,
+	})
+	if err != nil {
+		return err
+	}
+
+	stages := packager.NewPipeline(
+		&packager.ResolveStage{},
+		&packager.CopyStage{},
+		&packager.ExternalsStage{Update: true, UpdatePaths: args.Paths},
+	)
+	if err = stages.Run(ctx); err != nil {
+		return err
+	}
+
+	l.Success("✨ Pinned %d externals in %s", len(ctx.PkgMeta.Externals), pkg.LockFileName)
+	return nil
+}
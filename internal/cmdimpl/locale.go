@@ -0,0 +1,205 @@
+package cmdimpl
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/McTalian/wow-build-tools/internal/injector"
+	"github.com/McTalian/wow-build-tools/internal/locale"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+	"github.com/McTalian/wow-build-tools/pkg/packager"
+)
+
+type LocaleArgs struct {
+	TopDir      string
+	PkgmetaFile string
+	CurseId     string
+	TableName   string
+}
+
+// localeScan copies the project into a temporary package directory the same way a build does,
+// so ignored files are left out, and scans it for phrases. The release directory of the last
+// build is left alone.
+func localeScan(args *LocaleArgs) (*packager.BuildContext, []locale.Phrase, error) {
+	releaseDir, err := os.MkdirTemp("", "wbt-locale-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating scan directory: %v", err)
+	}
+	defer os.RemoveAll(releaseDir)
+
+	ctx, err := packager.NewBuildContext(&packager.Options{
+		TopDir:        args.TopDir,
+		ReleaseDir:    releaseDir,
+		PkgmetaFile:   args.PkgmetaFile,
+		CurseId:       args.CurseId,
+		SkipChangelog: true,
+		SkipUpload:    true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = packager.NewPipeline(&packager.ResolveStage{}, &packager.CopyStage{}).Run(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	phrases, err := locale.Scan(ctx.PackageDir, args.TableName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error scanning for phrases: %v", err)
+	}
+	logger.Info("Found %d phrases in %s", len(phrases), ctx.ProjectName)
+
+	return ctx, phrases, nil
+}
+
+func baseLocale(ctx *packager.BuildContext) string {
+	if ctx.PkgMeta.Localization.BaseLocale != "" {
+		return ctx.PkgMeta.Localization.BaseLocale
+	}
+	return "enUS"
+}
+
+func curseLocalization(ctx *packager.BuildContext) (*injector.CurseLocalization, error) {
+	projectId := ctx.CurseId()
+	if projectId == "" {
+		return nil, fmt.Errorf("no CurseForge project id, set one with --curseId or in the TOC file")
+	}
+	apiKey, found := os.LookupEnv("CF_API_KEY")
+	if !found {
+		return nil, fmt.Errorf("CF_API_KEY not set")
+	}
+	return injector.NewCurseLocalization(projectId, apiKey), nil
+}
+
+// curseLocaleKeys returns the keys of the base locale in the CurseForge project. The export
+// prefixes the keys of namespaced phrases with their namespace, which the code doesn't, so
+// it's cut off like Catalog.PhraseKeys does.
+func curseLocaleKeys(ctx *packager.BuildContext, tableName string) ([]string, error) {
+	curse, err := curseLocalization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := curse.Export(&tokens.LocalizationToken{
+		Locale:              baseLocale(ctx),
+		Format:              tokens.LuaAdditiveTable,
+		HandleUnlocalized:   tokens.UnlocalizedIgnore,
+		HandleSubnamespaces: tokens.SubnamespacesConcat,
+		TableName:           tableName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys := locale.LuaKeys(contents, tableName)
+	for i, key := range keys {
+		if slash := strings.LastIndex(key, "/"); slash >= 0 {
+			keys[i] = key[slash+1:]
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys), nil
+}
+
+// baseLocaleKeys returns the keys of the base locale from the pkgmeta localization files, or
+// from CurseForge when there are none.
+func baseLocaleKeys(ctx *packager.BuildContext, tableName string) ([]string, error) {
+	localization := ctx.PkgMeta.Localization
+	if len(localization.Files) == 0 {
+		return curseLocaleKeys(ctx, tableName)
+	}
+
+	catalog, err := locale.Load(ctx.TopDir, localization.BaseLocale, localization.Files)
+	if err != nil {
+		return nil, err
+	}
+	return catalog.PhraseKeys(), nil
+}
+
+// LocaleExtract is the implementation of the locale extract command, it prints the phrases
+// used in the code as an additive Lua table.
+func LocaleExtract(args *LocaleArgs) error {
+	_, phrases, err := localeScan(args)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, len(phrases))
+	for i, phrase := range phrases {
+		keys[i] = phrase.Key
+	}
+	fmt.Println(locale.FormatPhrases(args.TableName, keys))
+	return nil
+}
+
+// LocaleDiff is the implementation of the locale diff command, it reports the phrases missing
+// from the base locale and the base locale strings the code no longer uses.
+func LocaleDiff(args *LocaleArgs) error {
+	l := logger.DefaultLogger
+	ctx, phrases, err := localeScan(args)
+	if err != nil {
+		return err
+	}
+
+	keys, err := baseLocaleKeys(ctx, args.TableName)
+	if err != nil {
+		l.Error("Base Locale Error: %v", err)
+		return err
+	}
+
+	diff := locale.DiffPhrases(phrases, keys)
+	for _, phrase := range diff.Missing {
+		l.Warn("Missing from %s: %s (%s)", baseLocale(ctx), phrase.Key, strings.Join(phrase.Locations, ", "))
+	}
+	for _, key := range diff.Unused {
+		l.Warn("Unused: %s", key)
+	}
+
+	if len(diff.Missing) == 0 && len(diff.Unused) == 0 {
+		l.Success("✨ %s is in sync with the code", baseLocale(ctx))
+	} else {
+		l.Info("%d missing and %d unused phrases", len(diff.Missing), len(diff.Unused))
+	}
+	return nil
+}
+
+// LocalePush is the implementation of the locale push command, it imports the phrases missing
+// from the base locale of the CurseForge project.
+func LocalePush(args *LocaleArgs) error {
+	l := logger.DefaultLogger
+	ctx, phrases, err := localeScan(args)
+	if err != nil {
+		return err
+	}
+
+	curse, err := curseLocalization(ctx)
+	if err != nil {
+		l.Error("Localization Error: %v", err)
+		return err
+	}
+	keys, err := curseLocaleKeys(ctx, args.TableName)
+	if err != nil {
+		l.Error("Base Locale Error: %v", err)
+		return err
+	}
+
+	diff := locale.DiffPhrases(phrases, keys)
+	if len(diff.Missing) == 0 {
+		l.Info("No new phrases to push")
+		return nil
+	}
+
+	newKeys := make([]string, len(diff.Missing))
+	for i, phrase := range diff.Missing {
+		newKeys[i] = phrase.Key
+		l.Verbose("New phrase: %s", phrase.Key)
+	}
+	if err = curse.Import(baseLocale(ctx), locale.FormatPhrases(args.TableName, newKeys)); err != nil {
+		l.Error("Localization Import Error: %v", err)
+		return err
+	}
+
+	l.Success("✨ Pushed %d new phrases to %s", len(newKeys), baseLocale(ctx))
+	return nil
+}
@@ -0,0 +1,87 @@
+package cmdimpl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/McTalian/wow-build-tools/internal/api"
+)
+
+// newTestProject creates a committed git repository with an origin remote containing the files.
+func newTestProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	_, err = r.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"https://github.com/example/Project.git"},
+	})
+	require.NoError(t, err)
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+	}
+	w, err := r.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, w.AddGlob("."))
+	_, err = w.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)},
+	})
+	require.NoError(t, err)
+	return dir
+}
+
+func TestLocale_NamespacedExport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("CF_API_KEY", "secret")
+
+	// Stands in for the CurseForge localization export and import endpoints
+	var imported []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/projects/1234/localization/export":
+			if r.URL.Query().Get("concatenante-subnamespaces") != "true" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte("L[\"Hello\"] = true\nL[\"Options/Title\"] = true\nL[\"Options/Hello\"] = true\nL[\"Old\"] = true\n"))
+		case "/projects/1234/localization/import":
+			imported = append(imported, r.FormValue("localizations"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	require.NoError(t, api.SetEndpoints(api.Endpoints{CurseApiUrl: server.URL}))
+	t.Cleanup(api.ResetEndpoints)
+
+	topDir := newTestProject(t, map[string]string{
+		"Project.toc": "## Interface: 110100\n## Title: Project\n\nCore.lua\n",
+		"Core.lua":    "print(L[\"Hello\"], L[\"Title\"], L[\"New\"])\n",
+		".pkgmeta":    "package-as: Project\n",
+	})
+	args := &LocaleArgs{TopDir: topDir, CurseId: "1234", TableName: "L"}
+
+	ctx, _, err := localeScan(args)
+	require.NoError(t, err)
+	keys, err := baseLocaleKeys(ctx, args.TableName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hello", "Old", "Title"}, keys)
+
+	require.NoError(t, LocaleDiff(args))
+	require.NoError(t, LocalePush(args))
+	assert.Equal(t, []string{"L[\"New\"] = true"}, imported)
+}
@@ -0,0 +1,152 @@
+package cmdimpl
+
+import (
+	"fmt"
+
+	"github.com/McTalian/wow-build-tools/internal/changelog"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/upload"
+	"github.com/McTalian/wow-build-tools/pkg/packager"
+)
+
+type UploadArgs struct {
+	TopDir       string
+	ReleaseDir   string
+	PkgmetaFile  string
+	NameTemplate string
+
+	// ZipPath and NoLibZipPath default to the zips named by the name template
+	ZipPath      string
+	NoLibZipPath string
+	// Label, Changelog and ReleaseType override the ones of the project when set
+	Label       string
+	Changelog   string
+	ReleaseType string
+
+	CurseId string
+	WowiId  string
+	WagoId  string
+
+	// DryRun writes the upload plan to the release directory instead of uploading
+	DryRun bool
+}
+
+// uploadOverridesStage applies the flags of the upload commands on top of what was resolved
+// from the project.
+type uploadOverridesStage struct {
+	args *UploadArgs
+}
+
+func (s *uploadOverridesStage) Name() string { return "upload-overrides" }
+
+func (s *uploadOverridesStage) Run(ctx *packager.BuildContext) error {
+	switch s.args.ReleaseType {
+	case "":
+	case "alpha", "beta", "release":
+		ctx.ReleaseType = s.args.ReleaseType
+	default:
+		return fmt.Errorf("invalid release type %s, expected alpha, beta or release", s.args.ReleaseType)
+	}
+	ctx.FileLabel = s.args.Label
+	if s.args.Changelog != "" {
+		ctx.Changelog = &changelog.Changelog{
+			PreExistingFilePath: s.args.Changelog,
+			MarkupType:          changelog.MarkdownMT,
+		}
+	}
+	return nil
+}
+
+// runUpload resolves the project, or every addon of a workspace, and uploads the zips of an
+// earlier build with the publish stage, replaced when one is given. It returns the contexts
+// of the uploaded packages.
+func runUpload(args *UploadArgs, publish packager.Stage) ([]*packager.BuildContext, error) {
+	opts := &packager.Options{
+		TopDir:       args.TopDir,
+		ReleaseDir:   args.ReleaseDir,
+		PkgmetaFile:  args.PkgmetaFile,
+		NameTemplate: args.NameTemplate,
+		CurseId:      args.CurseId,
+		WowiId:       args.WowiId,
+		WagoId:       args.WagoId,
+		DryRunUpload: args.DryRun,
+	}
+	ws, err := packager.FindWorkspace(opts)
+	if err != nil {
+		return nil, err
+	}
+	if ws != nil && !ws.Combine && (args.ZipPath != "" || args.NoLibZipPath != "") {
+		return nil, fmt.Errorf("each addon of the workspace is uploaded from its own zips, --input and --nolib-input can't be used")
+	}
+
+	p := packager.UploadPipeline(&packager.PrebuiltPackageStage{
+		ZipPath:      args.ZipPath,
+		NoLibZipPath: args.NoLibZipPath,
+	})
+	if err = p.InsertAfter(packager.StageResolve, &uploadOverridesStage{args: args}); err != nil {
+		return nil, err
+	}
+	if args.Changelog != "" {
+		p.Skip(packager.StageChangelog)
+	}
+	if publish != nil {
+		if err = p.Replace(packager.StagePublish, publish); err != nil {
+			return nil, err
+		}
+	}
+
+	if ws == nil {
+		ctx, err := packager.NewBuildContext(opts)
+		if err != nil {
+			return nil, err
+		}
+		return []*packager.BuildContext{ctx}, p.Run(ctx)
+	}
+
+	ctxs, err := ws.Run(p)
+	if err != nil {
+		return nil, err
+	}
+	if ws.Combine {
+		// The addons were uploaded as the combined package only
+		return ctxs[:1], nil
+	}
+	return ctxs, nil
+}
+
+// uploaded reports the zips as uploaded, or as planned for a dry run.
+func uploaded(ctxs []*packager.BuildContext, args *UploadArgs) {
+	for _, ctx := range ctxs {
+		if args.DryRun {
+			logger.Success("✨ Planned the upload of %s", ctx.ZipPath)
+			continue
+		}
+		logger.Success("✨ Uploaded %s", ctx.ZipPath)
+	}
+}
+
+// UploadAll is the implementation of the upload all command, it uploads the zips of an
+// earlier build to every target the project is configured for.
+func UploadAll(args *UploadArgs) error {
+	ctxs, err := runUpload(args, nil)
+	if err != nil {
+		return err
+	}
+
+	uploaded(ctxs, args)
+	return nil
+}
+
+// UploadGitHub is the implementation of the upload github command, it attaches the zips of an
+// earlier build to the GitHub release of the current tag.
+func UploadGitHub(args *UploadArgs) error {
+	ctxs, err := runUpload(args, &packager.PublishStage{
+		Registry: upload.NewRegistry(&upload.GitHubPublisher{}),
+	})
+	if err != nil {
+		return err
+	}
+
+	uploaded(ctxs, args)
+	return nil
+}
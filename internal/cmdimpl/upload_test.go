@@ -0,0 +1,62 @@
+package cmdimpl
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/McTalian/wow-build-tools/internal/upload"
+	"github.com/McTalian/wow-build-tools/pkg/packager"
+)
+
+// recordingPublisher records the zip and CurseForge project id of the releases it was given.
+type recordingPublisher struct {
+	mu        sync.Mutex
+	published map[string]string
+}
+
+func (p *recordingPublisher) Name() string                             { return upload.PublisherCurse }
+func (p *recordingPublisher) Enabled(a *upload.ReleaseArtifact) bool   { return true }
+func (p *recordingPublisher) Validate(a *upload.ReleaseArtifact) error { return nil }
+func (p *recordingPublisher) Publish(a *upload.ReleaseArtifact) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published[filepath.Base(a.ZipPath)] = a.TocFiles[0].CurseId
+	return nil
+}
+
+func TestUploadAll_Workspace(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	topDir := newTestProject(t, map[string]string{
+		"Core/Core.toc":     "## Interface: 110100\n## Title: Core\n## X-Curse-Project-ID: 1\n\nCore.lua\n",
+		"Core/Core.lua":     "local _, ns = ...\n",
+		"Plugin/Plugin.toc": "## Interface: 110100\n## Title: Plugin\n## X-Curse-Project-ID: 2\n\nPlugin.lua\n",
+		"Plugin/Plugin.lua": "local _, ns = ...\n",
+	})
+	releaseDir := filepath.Join(t.TempDir(), ".release")
+
+	_, err := build(&BuildArgs{
+		TopDir:        topDir,
+		ReleaseDir:    releaseDir,
+		NameTemplate:  "{package-name}",
+		SkipChangelog: true,
+		SkipUpload:    true,
+	})
+	require.NoError(t, err)
+
+	args := &UploadArgs{TopDir: topDir, ReleaseDir: releaseDir, NameTemplate: "{package-name}"}
+	rec := &recordingPublisher{published: make(map[string]string)}
+	ctxs, err := runUpload(args, &packager.PublishStage{Registry: upload.NewRegistry(rec)})
+	require.NoError(t, err)
+	assert.Len(t, ctxs, 2)
+	assert.Equal(t, map[string]string{"Core.zip": "1", "Plugin.zip": "2"}, rec.published)
+
+	args.ZipPath = filepath.Join(releaseDir, "Core.zip")
+	_, err = runUpload(args, &packager.PublishStage{Registry: upload.NewRegistry(rec)})
+	assert.ErrorContains(t, err, "--input")
+}
@@ -0,0 +1,268 @@
+package external
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"gopkg.in/yaml.v3"
+)
+
+// cacheInfoFile is kept in the cache of every external, it isn't copied into packages since
+// it's a dotfile like the .lastUpdated markers.
+const cacheInfoFile = ".cacheInfo"
+
+// CacheInfo is what the cache of an external was checked out for, and when each project last
+// used it.
+type CacheInfo struct {
+	URL      string               `yaml:"url"`
+	Type     string               `yaml:"type"`
+	Ref      string               `yaml:"ref"`
+	Projects map[string]time.Time `yaml:"projects"`
+}
+
+// CacheEntry is the cache of an external in the externals cache directory.
+type CacheEntry struct {
+	Dir  string
+	Info CacheInfo
+	// LastUpdated is the newest .lastUpdated marker, zero when the checkout never completed
+	LastUpdated time.Time
+	Size        int64
+}
+
+func readCacheInfo(dir string) (CacheInfo, error) {
+	var info CacheInfo
+	contents, err := os.ReadFile(filepath.Join(dir, cacheInfoFile))
+	if err != nil {
+		return info, err
+	}
+	if err = yaml.Unmarshal(contents, &info); err != nil {
+		return info, fmt.Errorf("failed to parse %s: %w", cacheInfoFile, err)
+	}
+	return info, nil
+}
+
+// RecordCacheUse notes in the cache directory that the project used it, so caches no recent
+// build uses can be pruned.
+func (e *ExternalEntry) RecordCacheUse(cacheDir string, project string) error {
+	info, err := readCacheInfo(cacheDir)
+	if err != nil && !os.IsNotExist(err) {
+		e.LogGroup.Verbose("Replacing unreadable cache info: %v", err)
+	}
+	info.URL = e.URL
+	info.Type = e.EType.ToString()
+	info.Ref = e.Ref()
+	if info.Projects == nil {
+		info.Projects = make(map[string]time.Time)
+	}
+	info.Projects[project] = time.Now().UTC().Truncate(time.Second)
+
+	contents, err := yaml.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache info: %w", err)
+	}
+	if err = os.WriteFile(filepath.Join(cacheDir, cacheInfoFile), contents, 0644); err != nil {
+		return fmt.Errorf("failed to write cache info: %w", err)
+	}
+	return nil
+}
+
+// Name is the name of the cache directory.
+func (c *CacheEntry) Name() string {
+	return filepath.Base(c.Dir)
+}
+
+// LastUsed is the last time a project used the cache, or when it was last updated for caches
+// from before projects were recorded.
+func (c *CacheEntry) LastUsed() time.Time {
+	lastUsed := c.LastUpdated
+	for _, used := range c.Info.Projects {
+		if used.After(lastUsed) {
+			lastUsed = used
+		}
+	}
+	return lastUsed
+}
+
+// Type returns the VCS of the cache, from the cache info or the checkout itself.
+func (c *CacheEntry) Type() VcsType {
+	if t := ToVcsType(c.Info.Type); t != Unknown {
+		return t
+	}
+	for _, t := range []VcsType{Git, Svn, Hg} {
+		if _, err := os.Stat(filepath.Join(c.Dir, "."+t.ToString())); err == nil {
+			return t
+		}
+	}
+	return Unknown
+}
+
+// Projects returns the names of the projects that used the cache, most recent first.
+func (c *CacheEntry) Projects() []string {
+	var projects []string
+	for project := range c.Info.Projects {
+		projects = append(projects, project)
+	}
+	slices.SortFunc(projects, func(a, b string) int {
+		return c.Info.Projects[b].Compare(c.Info.Projects[a])
+	})
+	return projects
+}
+
+func readCacheEntry(dir string) (CacheEntry, error) {
+	entry := CacheEntry{Dir: dir}
+
+	info, err := readCacheInfo(dir)
+	if err == nil {
+		entry.Info = info
+	} else if !os.IsNotExist(err) {
+		return entry, err
+	}
+
+	markers, err := filepath.Glob(filepath.Join(dir, ".lastUpdated*"))
+	if err != nil {
+		return entry, fmt.Errorf("failed to glob lastUpdated files: %w", err)
+	}
+	for _, marker := range markers {
+		contents, err := os.ReadFile(marker)
+		if err != nil {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, string(contents)); err == nil && t.After(entry.LastUpdated) {
+			entry.LastUpdated = t
+		}
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			entry.Size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return entry, fmt.Errorf("failed to get the size of %s: %w", dir, err)
+	}
+
+	return entry, nil
+}
+
+// ReadCache lists the caches in the externals cache directory, sorted by name.
+func ReadCache(cacheDir string) ([]CacheEntry, error) {
+	dirEntries, err := os.ReadDir(cacheDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var entries []CacheEntry
+	for _, d := range dirEntries {
+		if !d.IsDir() {
+			continue
+		}
+		entry, err := readCacheEntry(filepath.Join(cacheDir, d.Name()))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// PruneCache removes the caches no project used within maxAge and returns them.
+func PruneCache(cacheDir string, maxAge time.Duration) ([]CacheEntry, error) {
+	entries, err := ReadCache(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []CacheEntry
+	cutoff := time.Now().Add(-maxAge)
+	for _, entry := range entries {
+		if entry.LastUsed().After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(entry.Dir); err != nil {
+			return pruned, fmt.Errorf("failed to remove %s: %w", entry.Dir, err)
+		}
+		pruned = append(pruned, entry)
+	}
+	return pruned, nil
+}
+
+// verifyGitCache reads every file of the checked out commit, which fails on missing or
+// corrupted objects.
+func verifyGitCache(dir string) error {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to read commit %s: %w", head.Hash(), err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("failed to read tree of %s: %w", head.Hash(), err)
+	}
+	return tree.Files().ForEach(func(f *object.File) error {
+		r, err := f.Reader()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		defer r.Close()
+		if _, err = io.Copy(io.Discard, r); err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		return nil
+	})
+}
+
+// VerifyCache checks the integrity of a cache, e.g. that no git objects are corrupted. It reads
+// the whole cache, builds only verify caches that fail to check out. An svn cache is checked
+// with `svn info`, which only fails when the working copy database is missing or corrupted.
+// The checks of svn and hg caches are skipped when they aren't installed.
+func VerifyCache(dir string) error {
+	entry := CacheEntry{Dir: dir}
+	switch t := entry.Type(); t {
+	case Git:
+		return verifyGitCache(dir)
+	case Svn, Hg:
+		tool := t.ToString()
+		if _, err := exec.LookPath(tool); err != nil {
+			return nil
+		}
+		args := []string{"info"}
+		if t == Hg {
+			args = []string{"verify", "--quiet"}
+		}
+		cmd := exec.Command(tool, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "HGPLAIN=1")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s %s failed: %w, output: %s", tool, args[0], err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s is not a git, svn or hg checkout", dir)
+	}
+}
@@ -0,0 +1,88 @@
+package external
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/McTalian/wow-build-tools/internal/logger"
+)
+
+// newCachedRepo creates a git checkout in the cache directory, like a cached external.
+func newCachedRepo(t *testing.T, cacheDir string, name string, contents string) string {
+	t.Helper()
+	dir := filepath.Join(cacheDir, name)
+	r, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "LibFoo.lua"), []byte(contents), 0644))
+	w, err := r.Worktree()
+	require.NoError(t, err)
+	_, err = w.Add("LibFoo.lua")
+	require.NoError(t, err)
+	_, err = w.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)},
+	})
+	require.NoError(t, err)
+	return dir
+}
+
+func TestReadCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	dir := newCachedRepo(t, cacheDir, "https:__github.com_example_LibFoo_", "local version = 1\n")
+	legacyDir := newCachedRepo(t, cacheDir, "https:__github.com_example_LibBar_", "local version = 1\n")
+
+	updated := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.WriteFile(filepath.Join(legacyDir, ".lastUpdated"), []byte(updated.Format(time.RFC3339)), 0644))
+
+	e := &ExternalEntry{URL: "https://github.com/example/LibFoo", EType: Git, LogGroup: logger.NewLogGroup("test")}
+	require.NoError(t, e.RecordCacheUse(dir, "Project"))
+	require.NoError(t, e.RecordCacheUse(dir, "Other"))
+
+	entries, err := ReadCache(cacheDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	legacy, entry := entries[0], entries[1]
+	assert.Equal(t, "https://github.com/example/LibFoo", entry.Info.URL)
+	assert.Equal(t, "default", entry.Info.Ref)
+	assert.Equal(t, Git, entry.Type())
+	assert.ElementsMatch(t, []string{"Project", "Other"}, entry.Projects())
+	assert.WithinDuration(t, time.Now(), entry.LastUsed(), time.Minute)
+	assert.Positive(t, entry.Size)
+
+	assert.Empty(t, legacy.Info.URL)
+	assert.Equal(t, Git, legacy.Type())
+	assert.True(t, updated.Equal(legacy.LastUpdated))
+	assert.True(t, updated.Equal(legacy.LastUsed()))
+
+	pruned, err := PruneCache(cacheDir, 30*24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, pruned, 1)
+	assert.Equal(t, legacyDir, pruned[0].Dir)
+	assert.NoDirExists(t, legacyDir)
+	assert.DirExists(t, dir)
+}
+
+func TestVerifyCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	contents := "local version = 1\n"
+	dir := newCachedRepo(t, cacheDir, "LibFoo", contents)
+	require.NoError(t, VerifyCache(dir))
+
+	// Corrupt the object of LibFoo.lua
+	hash := plumbing.ComputeHash(plumbing.BlobObject, []byte(contents)).String()
+	object := filepath.Join(dir, ".git", "objects", hash[:2], hash[2:])
+	require.FileExists(t, object)
+	require.NoError(t, os.Remove(object))
+	require.NoError(t, os.WriteFile(object, []byte("not a git object"), 0644))
+	assert.Error(t, VerifyCache(dir))
+
+	assert.Error(t, VerifyCache(t.TempDir()))
+}
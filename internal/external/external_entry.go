@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"gopkg.in/yaml.v3"
@@ -27,8 +28,16 @@ type ExternalEntry struct {
 	DestPath     string
 	LogGroup     *logger.LogGroup
 	RepoCacheDir string
+	// Subtree is the folder of the cache that's copied into the package, set by the checkout
+	Subtree string `yaml:"-"`
+	// Pinned is set when the external is checked out at a revision from the lock file
+	Pinned bool `yaml:"-"`
 }
 
+// pinnedCacheValidity is how long the cache of a pinned external is reused, its contents can't
+// change since the cache is specific to the revision.
+const pinnedCacheValidity = 100 * 365 * 24 * time.Hour
+
 // Known URL patterns for different repo types
 var repoTypePatterns = map[string]VcsType{
 	"git.curseforge.com": Git,
@@ -210,6 +219,47 @@ func (e *ExternalEntry) GetRepoCachePath() string {
 	return filepath.Join(cacheDir, safeName)
 }
 
+// CopySource returns the directory copied into the package, the cache or the subtree of it.
+func (e *ExternalEntry) CopySource() string {
+	if e.Subtree != "" {
+		return filepath.Join(e.RepoCacheDir, e.Subtree)
+	}
+	return e.RepoCacheDir
+}
+
+// Ref describes what the external asks for, e.g. `tag:latest` or `branch:main`, before the
+// checkout resolves it.
+func (e *ExternalEntry) Ref() string {
+	if e.CheckoutType == "" {
+		return "default"
+	}
+	return e.CheckoutType + ":" + e.Tag
+}
+
+// Pin makes the external check out the given revision, as returned by Vcs.Revision, into a
+// cache specific to that revision.
+func (e *ExternalEntry) Pin(revision string) {
+	if e.EType == Svn {
+		// SVN revisions are "<url>@<revision>" since tags and branches live at their own URL
+		if i := strings.LastIndex(revision, "@"); i != -1 {
+			e.URL = revision[:i]
+			revision = revision[i+1:]
+		}
+	}
+	e.CheckoutType = "commit"
+	e.Tag = revision
+	e.Pinned = true
+	e.RepoCacheDir = e.GetRepoCachePath()
+}
+
+// cacheValidity returns how long the cache of the external is reused before it's updated.
+func (e *ExternalEntry) cacheValidity() time.Duration {
+	if e.Pinned {
+		return pinnedCacheValidity
+	}
+	return 24 * time.Hour
+}
+
 func (e *ExternalEntry) String(spaces int) string {
 	indent := strings.Repeat(" ", spaces)
 	str := fmt.Sprintf("\n%sURL=%s\n%sType=%s", indent, e.URL, indent, TypeColor(e.EType))
@@ -5,11 +5,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
 )
 
 type GitExternal struct {
@@ -59,6 +60,278 @@ func (gE *GitExternal) lookForCurseSlug() error {
 	})
 }
 
+// subtree returns the folder of the repository the external needs, if any. The Path of
+// CurseForge URLs starts with the project slug, which isn't a folder of the repository.
+func (gE *GitExternal) subtree() string {
+	e := gE.metadata
+	for _, prefix := range cursePrefixes {
+		if strings.HasPrefix(e.URL, prefix) {
+			return strings.TrimPrefix(strings.TrimPrefix(e.Path, e.CurseSlug), urlPathSeparator)
+		}
+	}
+	return e.Path
+}
+
+// useSubtree copies only the subtree into the package when the checkout has it.
+func (gE *GitExternal) useSubtree() {
+	e := gE.metadata
+	subtree := gE.subtree()
+	if subtree == "" {
+		return
+	}
+	if info, err := os.Stat(filepath.Join(gE.getRepoCachePath(), subtree)); err == nil && info.IsDir() {
+		e.Subtree = subtree
+	}
+}
+
+// shallowRef returns the reference a shallow clone fetches, it's empty for the default branch
+// of the remote. Commits aren't references, they are fetched by their hash with cloneCommit.
+func (gE *GitExternal) shallowRef() (plumbing.ReferenceName, bool) {
+	e := gE.metadata
+	switch e.CheckoutType {
+	case "branch":
+		return plumbing.NewBranchReferenceName(e.Tag), true
+	case "tag":
+		return plumbing.NewTagReferenceName(e.Tag), true
+	case "commit":
+		return "", false
+	default:
+		return "", true
+	}
+}
+
+// remoteHead returns the default branch of the remote repository.
+func remoteHead(url string) (plumbing.ReferenceName, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+	refs, err := remote.List(&git.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list references of %s: %w", url, err)
+	}
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+			return ref.Target(), nil
+		}
+	}
+	return "", fmt.Errorf("no default branch found for %s", url)
+}
+
+// pinnedCommit returns the commit a commit checkout asks for, when it's a full hash that can be
+// fetched without the history to look it up in.
+func (gE *GitExternal) pinnedCommit() (plumbing.Hash, bool) {
+	e := gE.metadata
+	if e.CheckoutType != "commit" || !plumbing.IsHash(e.Tag) {
+		return plumbing.ZeroHash, false
+	}
+	return plumbing.NewHash(e.Tag), true
+}
+
+// cloneCommit fetches only the commit into a new repository, which needs a server that allows
+// fetching commits by their hash.
+func (gE *GitExternal) cloneCommit(repoCachePath string, hash plumbing.Hash) (*git.Repository, error) {
+	repo, err := git.PlainInit(repoCachePath, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{gE.metadata.URL},
+	}); err != nil {
+		return nil, err
+	}
+	err = repo.Fetch(&git.FetchOptions{
+		RefSpecs: []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:refs/pinned/%s", hash, hash))},
+		Depth:    1,
+		Tags:     git.NoTags,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// clone clones the repository into the cache without checking it out. Branches, tags and
+// commits only fetch their latest commit, falling back to a full clone when the server doesn't
+// support shallow clones or fetching commits by their hash.
+func (gE *GitExternal) clone(repoCachePath string) (*git.Repository, error) {
+	e := gE.metadata
+	if hash, ok := gE.pinnedCommit(); ok {
+		repo, err := gE.cloneCommit(repoCachePath, hash)
+		if err == nil {
+			return repo, nil
+		}
+		e.LogGroup.Verbose("GIT: Shallow fetch of %s at %s failed, falling back to a full clone: %v", e.URL, hash, err)
+		if err = os.RemoveAll(repoCachePath); err != nil {
+			return nil, fmt.Errorf("failed to remove existing cache dir at %s: %w", repoCachePath, err)
+		}
+	}
+
+	ref, ok := gE.shallowRef()
+	if ok && ref == "" {
+		// A single branch clone of HEAD doesn't track the default branch, so it's never updated
+		var err error
+		if ref, err = remoteHead(e.URL); err != nil {
+			e.LogGroup.Verbose("GIT: %v", err)
+			ok = false
+		}
+	}
+	if ok {
+		repo, err := git.PlainClone(repoCachePath, false, &git.CloneOptions{
+			URL:           e.URL,
+			ReferenceName: ref,
+			SingleBranch:  true,
+			Depth:         1,
+			NoCheckout:    true,
+			Tags:          git.NoTags,
+		})
+		if err == nil {
+			return repo, nil
+		}
+		e.LogGroup.Verbose("GIT: Shallow clone of %s failed, falling back to a full clone: %v", e.URL, err)
+		if err = os.RemoveAll(repoCachePath); err != nil {
+			return nil, fmt.Errorf("failed to remove existing cache dir at %s: %w", repoCachePath, err)
+		}
+	}
+
+	return git.PlainClone(repoCachePath, false, &git.CloneOptions{
+		URL:        e.URL,
+		NoCheckout: true,
+	})
+}
+
+// fetch updates the cache, shallow caches only fetch the latest commit of their branch or tag.
+func (gE *GitExternal) fetch(repo *git.Repository) error {
+	if hash, ok := gE.pinnedCommit(); ok {
+		if _, err := repo.CommitObject(hash); err == nil {
+			// A commit never changes, there is nothing to update
+			return nil
+		}
+	}
+
+	opts := &git.FetchOptions{
+		Prune: true,
+		Tags:  git.AllTags,
+	}
+	if shallow, err := repo.Storer.Shallow(); err == nil && len(shallow) > 0 {
+		opts.Depth = 1
+		opts.Tags = git.NoTags
+	}
+
+	err := repo.Fetch(opts)
+	if err != nil && err != git.NoErrAlreadyUpToDate && opts.Depth > 0 {
+		gE.metadata.LogGroup.Verbose("GIT: Shallow fetch failed, falling back to a full fetch: %v", err)
+		opts.Depth = 0
+		err = repo.Fetch(opts)
+	}
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+	return nil
+}
+
+// syncBranch points the branch at the commit of its remote branch, since fetching only updates
+// the remote branches of the cache.
+func syncBranch(repo *git.Repository, branch string) (plumbing.ReferenceName, error) {
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	if remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true); err == nil {
+		if err = repo.Storer.SetReference(plumbing.NewHashReference(branchRef, remoteRef.Hash())); err != nil {
+			return "", fmt.Errorf("failed to update branch %s: %w", branch, err)
+		}
+	}
+	return branchRef, nil
+}
+
+// findCommit returns the commit with the given hash, which can be abbreviated to 7 or more
+// characters.
+func findCommit(repo *git.Repository, hash string) (plumbing.Hash, error) {
+	if plumbing.IsHash(hash) {
+		return plumbing.NewHash(hash), nil
+	}
+	if len(hash) < 7 {
+		return plumbing.ZeroHash, fmt.Errorf("invalid commit hash or abbreviated hash: %s", hash)
+	}
+
+	cIter, err := repo.CommitObjects()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get commit objects with abbreviated hash %s: %w", hash, err)
+	}
+	defer cIter.Close()
+
+	var ErrFoundCommit = fmt.Errorf("found commit")
+
+	var commit *object.Commit
+	if err = cIter.ForEach(func(c *object.Commit) error {
+		if strings.HasPrefix(c.Hash.String(), hash) {
+			commit = c
+			return ErrFoundCommit
+		}
+		return nil
+	}); err != nil && err != ErrFoundCommit {
+		return plumbing.ZeroHash, fmt.Errorf("failed to iterate commit objects with abbreviated hash %s: %w", hash, err)
+	}
+	if commit == nil {
+		return plumbing.ZeroHash, fmt.Errorf("commit not found with abbreviated hash %s", hash)
+	}
+	return commit.Hash, nil
+}
+
+// defaultBranch returns the branch HEAD of the cache points to.
+func defaultBranch(repo *git.Repository) (string, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return "", fmt.Errorf("failed to get references: %w", err)
+	}
+	defaultBranch := ""
+	if err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.SymbolicReference && ref.Name().String() == "HEAD" {
+			if defaultBranch != "" {
+				return fmt.Errorf("multiple default branches found")
+			}
+			defaultBranch = strings.TrimPrefix(ref.Target().String(), "refs/heads/")
+		}
+		return nil
+	}); err != nil {
+		return "", fmt.Errorf("failed to iterate references: %w", err)
+	}
+	if defaultBranch == "" {
+		return "", fmt.Errorf("failed to determine default branch")
+	}
+	return defaultBranch, nil
+}
+
+// sparseDirs returns the subtree when the commit being checked out has it, so the rest of the
+// repository isn't written to the cache.
+func (gE *GitExternal) sparseDirs(repo *git.Repository, opts *git.CheckoutOptions) []string {
+	subtree := gE.subtree()
+	if subtree == "" {
+		return nil
+	}
+
+	hash := opts.Hash
+	if opts.Branch != "" {
+		resolved, err := repo.ResolveRevision(plumbing.Revision(opts.Branch))
+		if err != nil {
+			return nil
+		}
+		hash = *resolved
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil
+	}
+	if _, err = tree.Tree(subtree); err != nil {
+		gE.metadata.LogGroup.Verbose("GIT: %s is not a folder of %s, checking out the whole repository", subtree, gE.metadata.URL)
+		return nil
+	}
+	return []string{subtree}
+}
+
 func (gE *GitExternal) Checkout() error {
 	// Determine the cached repository path.
 	repoCachePath := gE.getRepoCachePath()
@@ -74,10 +347,11 @@ func (gE *GitExternal) Checkout() error {
 			return fmt.Errorf("GIT: failed to delete lastUpdated marker: %w", err)
 		}
 	} else {
-		if stale, err := helper.IsStale(lastUpdatedPath, 24*time.Hour); err != nil {
+		if stale, err := helper.IsStale(lastUpdatedPath, e.cacheValidity()); err != nil {
 			return err
 		} else if !stale {
 			e.LogGroup.Verbose("GIT: Cache is up-to-date for %s", e.DestPath)
+			gE.useSubtree()
 			if err = gE.lookForCurseSlug(); err != nil {
 				return err
 			}
@@ -89,10 +363,7 @@ func (gE *GitExternal) Checkout() error {
 	var repo *git.Repository
 	if _, err := os.Stat(repoCachePath); os.IsNotExist(err) {
 		e.LogGroup.Verbose("GIT: Cloning %s into cache: %s", e.URL, repoCachePath)
-		repo, err = git.PlainClone(repoCachePath, false, &git.CloneOptions{
-			URL:      e.URL,
-			Progress: nil,
-		})
+		repo, err = gE.clone(repoCachePath)
 		if err != nil {
 			return fmt.Errorf("failed to clone into cache %s: %w", e.URL, err)
 		}
@@ -102,10 +373,7 @@ func (gE *GitExternal) Checkout() error {
 			return fmt.Errorf("failed to open cache %s: %w", repoCachePath, err)
 		}
 		e.LogGroup.Verbose("GIT: Fetching latest changes in cache for %s", e.URL)
-		if err = repo.Fetch(&git.FetchOptions{
-			Prune: true,
-			Tags:  git.AllTags,
-		}); err != nil && err != git.NoErrAlreadyUpToDate {
+		if err = gE.fetch(repo); err != nil {
 			return fmt.Errorf("failed to update cache: %w", err)
 		}
 	}
@@ -116,103 +384,45 @@ func (gE *GitExternal) Checkout() error {
 		return fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	// Perform the checkout based on the type.
+	// Work out what to check out based on the type.
+	opts := &git.CheckoutOptions{Force: true}
+	var target string
 	switch e.CheckoutType {
 	case "branch":
 		e.LogGroup.Verbose("GIT: Checking out branch %s", e.Tag)
-		err = worktree.Checkout(&git.CheckoutOptions{
-			Branch: plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", e.Tag)),
-			Create: false,
-			Force:  true,
-		})
-		if err != nil {
-			return fmt.Errorf("git checkout branch failed: %w", err)
+		target = "branch " + e.Tag
+		if opts.Branch, err = syncBranch(repo, e.Tag); err != nil {
+			return err
 		}
 	case "tag":
 		e.LogGroup.Verbose("GIT: Checking out tag %s", e.Tag)
-		err = worktree.Checkout(&git.CheckoutOptions{
-			Branch: plumbing.ReferenceName(fmt.Sprintf("refs/tags/%s", e.Tag)),
-			Create: false,
-			Force:  true,
-		})
-		if err != nil {
-			return fmt.Errorf("git checkout tag failed: %w", err)
-		}
+		target = "tag " + e.Tag
+		opts.Branch = plumbing.NewTagReferenceName(e.Tag)
 	case "commit":
 		e.LogGroup.Verbose("GIT: Checking out commit %s", e.Tag)
-		if plumbing.IsHash(e.Tag) {
-			commitHash := plumbing.NewHash(e.Tag)
-			err = worktree.Checkout(&git.CheckoutOptions{
-				Hash:  commitHash,
-				Force: true,
-			})
-			if err != nil {
-				return fmt.Errorf("git checkout of commit %s failed: %w", e.Tag, err)
-			}
-		} else if len(e.Tag) >= 7 {
-			cIter, err := repo.CommitObjects()
-			if err != nil {
-				return fmt.Errorf("failed to get commit objects with abbreviated hash %s: %w", e.Tag, err)
-			}
-			defer cIter.Close()
-
-			var ErrFoundCommit = fmt.Errorf("found commit")
-
-			var commit *object.Commit
-			if err = cIter.ForEach(func(c *object.Commit) error {
-				if strings.HasPrefix(c.Hash.String(), e.Tag) {
-					commit = c
-					return ErrFoundCommit
-				}
-				return nil
-			}); err != nil && err != ErrFoundCommit {
-				return fmt.Errorf("failed to iterate commit objects with abbreviated hash %s: %w", e.Tag, err)
-			}
-			if commit == nil {
-				return fmt.Errorf("commit not found with abbreviated hash %s", e.Tag)
-			}
-			err = worktree.Checkout(&git.CheckoutOptions{
-				Hash:  commit.Hash,
-				Force: true,
-			})
-			if err != nil {
-				return fmt.Errorf("git checkout of commit %s failed: %w", commit.Hash.String(), err)
-			}
-		} else {
-			return fmt.Errorf("invalid commit hash or abbreviated hash: %s", e.Tag)
+		if opts.Hash, err = findCommit(repo, e.Tag); err != nil {
+			return err
 		}
+		target = "commit " + opts.Hash.String()
 	default:
 		e.LogGroup.Verbose("GIT: Checking out default branch")
-		refs, err := repo.References()
+		branch, err := defaultBranch(repo)
 		if err != nil {
-			return fmt.Errorf("failed to get references: %w", err)
-		}
-		defaultBranch := ""
-		if err = refs.ForEach(func(ref *plumbing.Reference) error {
-			if ref.Type() == plumbing.SymbolicReference && ref.Name().String() == "HEAD" {
-				if defaultBranch != "" {
-					return fmt.Errorf("multiple default branches found")
-				}
-				defaultBranch = strings.TrimPrefix(ref.Target().String(), "refs/heads/")
-			}
-			return nil
-		}); err != nil {
-			return fmt.Errorf("failed to iterate references: %w", err)
-		}
-		if defaultBranch == "" {
-			return fmt.Errorf("failed to determine default branch")
+			return err
 		}
-		err = worktree.Checkout(&git.CheckoutOptions{
-			Branch: plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", defaultBranch)),
-			Create: false,
-			Force:  true,
-		})
-		if err != nil {
-			return fmt.Errorf("git checkout %s failed: %w", defaultBranch, err)
+		target = branch
+		if opts.Branch, err = syncBranch(repo, branch); err != nil {
+			return err
 		}
-		e.Tag = defaultBranch
+		e.Tag = branch
 	}
 
+	opts.SparseCheckoutDirectories = gE.sparseDirs(repo, opts)
+	if err = worktree.Checkout(opts); err != nil {
+		return fmt.Errorf("git checkout of %s failed: %w", target, err)
+	}
+	gE.useSubtree()
+
 	// Write the marker file now that the checkout is complete.
 	if err := helper.Write(lastUpdatedPath); err != nil {
 		return err
@@ -225,6 +435,19 @@ func (gE *GitExternal) Checkout() error {
 	return nil
 }
 
+// Revision returns the hash of the commit checked out in the cache.
+func (gE *GitExternal) Revision() (string, error) {
+	repo, err := git.PlainOpen(gE.getRepoCachePath())
+	if err != nil {
+		return "", fmt.Errorf("failed to open cache %s: %w", gE.getRepoCachePath(), err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD of %s: %w", gE.metadata.URL, err)
+	}
+	return head.Hash().String(), nil
+}
+
 // getRepoCachePath returns the cache path for a specific repository.
 func (e *GitExternal) getRepoCachePath() string {
 	return e.metadata.RepoCacheDir
@@ -0,0 +1,201 @@
+package external
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/McTalian/wow-build-tools/internal/logger"
+)
+
+// commitFiles writes the files to the repository and commits them.
+func commitFiles(t *testing.T, r *git.Repository, files map[string]string) plumbing.Hash {
+	t.Helper()
+	w, err := r.Worktree()
+	require.NoError(t, err)
+	for name, contents := range files {
+		path := filepath.Join(w.Filesystem.Root(), name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+		_, err = w.Add(name)
+		require.NoError(t, err)
+	}
+	hash, err := w.Commit("Update", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)},
+	})
+	require.NoError(t, err)
+	return hash
+}
+
+func TestGitExternal_Checkout(t *testing.T) {
+	upstream := t.TempDir()
+	r, err := git.PlainInit(upstream, false)
+	require.NoError(t, err)
+	first := commitFiles(t, r, map[string]string{
+		"LibFoo.lua":          "local version = 1\n",
+		"LibBar/LibBar.lua":   "local version = 1\n",
+		"Docs/Large/Doc.html": "<html></html>\n",
+	})
+	_, err = r.CreateTag("1.0.0", first, nil)
+	require.NoError(t, err)
+	_, err = r.CreateTag("1.0.1", first, &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)},
+		Message: "1.0.1",
+	})
+	require.NoError(t, err)
+	second := commitFiles(t, r, map[string]string{"LibFoo.lua": "local version = 2\n"})
+
+	tests := []struct {
+		name         string
+		checkoutType string
+		tag          string
+		path         string
+		wantTag      string
+		wantRevision plumbing.Hash
+		wantFiles    []string
+		wantNoFiles  []string
+	}{
+		{name: "Default branch", wantTag: "master", wantRevision: second, wantFiles: []string{"LibFoo.lua", "Docs/Large/Doc.html"}},
+		{name: "Branch", checkoutType: "branch", tag: "master", wantTag: "master", wantRevision: second},
+		{name: "Tag", checkoutType: "tag", tag: "1.0.0", wantTag: "1.0.0", wantRevision: first},
+		{name: "Annotated tag", checkoutType: "tag", tag: "1.0.1", wantTag: "1.0.1", wantRevision: first},
+		{name: "Commit", checkoutType: "commit", tag: first.String(), wantTag: first.String(), wantRevision: first},
+		{name: "Abbreviated commit", checkoutType: "commit", tag: first.String()[:7], wantTag: first.String()[:7], wantRevision: first},
+		{
+			name:         "Subtree",
+			checkoutType: "tag",
+			tag:          "1.0.0",
+			path:         "LibBar",
+			wantTag:      "1.0.0",
+			wantRevision: first,
+			wantFiles:    []string{"LibBar/LibBar.lua"},
+			wantNoFiles:  []string{"LibFoo.lua", "Docs/Large/Doc.html"},
+		},
+		{name: "Missing subtree", path: "LibBaz", wantTag: "master", wantRevision: second, wantFiles: []string{"LibFoo.lua"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &ExternalEntry{
+				URL:          upstream,
+				EType:        Git,
+				CheckoutType: tt.checkoutType,
+				Tag:          tt.tag,
+				Path:         tt.path,
+				DestPath:     "Libs/LibFoo",
+				RepoCacheDir: filepath.Join(t.TempDir(), "cache"),
+				LogGroup:     logger.NewLogGroup("test"),
+			}
+			gE, err := NewGitExternal(e, false)
+			require.NoError(t, err)
+			require.NoError(t, gE.Checkout())
+
+			assert.Equal(t, tt.wantTag, e.Tag)
+			revision, err := gE.Revision()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRevision.String(), revision)
+			for _, file := range tt.wantFiles {
+				assert.FileExists(t, filepath.Join(e.RepoCacheDir, file))
+			}
+			for _, file := range tt.wantNoFiles {
+				assert.NoFileExists(t, filepath.Join(e.RepoCacheDir, file))
+			}
+			if tt.path == "LibBar" {
+				assert.Equal(t, filepath.Join(e.RepoCacheDir, "LibBar"), e.CopySource())
+			} else {
+				assert.Equal(t, e.RepoCacheDir, e.CopySource())
+			}
+			assert.NoError(t, VerifyCache(e.RepoCacheDir))
+		})
+	}
+}
+
+func TestGitExternal_ShallowUpdate(t *testing.T) {
+	upstream := t.TempDir()
+	r, err := git.PlainInit(upstream, false)
+	require.NoError(t, err)
+	commitFiles(t, r, map[string]string{"LibFoo.lua": "local version = 1\n"})
+	commitFiles(t, r, map[string]string{"LibFoo.lua": "local version = 2\n"})
+
+	e := &ExternalEntry{
+		URL:          upstream,
+		EType:        Git,
+		DestPath:     "Libs/LibFoo",
+		RepoCacheDir: filepath.Join(t.TempDir(), "cache"),
+		LogGroup:     logger.NewLogGroup("test"),
+	}
+	gE, err := NewGitExternal(e, true)
+	require.NoError(t, err)
+	require.NoError(t, gE.Checkout())
+
+	cache, err := git.PlainOpen(e.RepoCacheDir)
+	require.NoError(t, err)
+	shallow, err := cache.Storer.Shallow()
+	require.NoError(t, err)
+	assert.Len(t, shallow, 1)
+
+	third := commitFiles(t, r, map[string]string{"LibFoo.lua": "local version = 3\n"})
+	require.NoError(t, gE.Checkout())
+	revision, err := gE.Revision()
+	require.NoError(t, err)
+	assert.Equal(t, third.String(), revision)
+	contents, err := os.ReadFile(filepath.Join(e.RepoCacheDir, "LibFoo.lua"))
+	require.NoError(t, err)
+	assert.Equal(t, "local version = 3\n", string(contents))
+}
+
+func TestGitExternal_ShallowCommit(t *testing.T) {
+	upstream := t.TempDir()
+	r, err := git.PlainInit(upstream, false)
+	require.NoError(t, err)
+	first := commitFiles(t, r, map[string]string{"LibFoo.lua": "local version = 1\n"})
+	pinned := commitFiles(t, r, map[string]string{"LibFoo.lua": "local version = 2\n"})
+	commitFiles(t, r, map[string]string{"LibFoo.lua": "local version = 3\n"})
+	// Like GitHub, allow fetching commits by their hash. Without it the checkout falls back to
+	// a full clone, as the Commit case of TestGitExternal_Checkout does.
+	cfg, err := r.Config()
+	require.NoError(t, err)
+	cfg.Raw.Section("uploadpack").SetOption("allowReachableSHA1InWant", "true")
+	require.NoError(t, r.SetConfig(cfg))
+
+	e := &ExternalEntry{
+		URL:          upstream,
+		EType:        Git,
+		DestPath:     "Libs/LibFoo",
+		RepoCacheDir: filepath.Join(t.TempDir(), "cache"),
+		LogGroup:     logger.NewLogGroup("test"),
+	}
+	// Pinned by the lock file
+	e.Pin(pinned.String())
+	gE, err := NewGitExternal(e, true)
+	require.NoError(t, err)
+	require.NoError(t, gE.Checkout())
+
+	revision, err := gE.Revision()
+	require.NoError(t, err)
+	assert.Equal(t, pinned.String(), revision)
+	contents, err := os.ReadFile(filepath.Join(e.RepoCacheDir, "LibFoo.lua"))
+	require.NoError(t, err)
+	assert.Equal(t, "local version = 2\n", string(contents))
+
+	cache, err := git.PlainOpen(e.RepoCacheDir)
+	require.NoError(t, err)
+	shallow, err := cache.Storer.Shallow()
+	require.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{pinned}, shallow)
+	_, err = cache.CommitObject(first)
+	assert.Error(t, err, "the history before the pinned commit should not be fetched")
+
+	// Updating the cache doesn't fetch anything else
+	require.NoError(t, gE.Checkout())
+	revision, err = gE.Revision()
+	require.NoError(t, err)
+	assert.Equal(t, pinned.String(), revision)
+}
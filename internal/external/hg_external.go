@@ -0,0 +1,198 @@
+package external
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// HgExternal implements the Vcs interface for Mercurial repositories.
+type HgExternal struct {
+	BaseVcs
+	metadata       *ExternalEntry
+	forceExternals bool
+}
+
+func (h *HgExternal) lookForCurseSlug() error {
+	e := h.metadata
+	if e.CurseSlug != "" {
+		return nil
+	}
+
+	repoCachePath := h.getRepoCachePath()
+
+	// Walk repoCachePath and look for the string @curseforge-project-slug in any file.
+	return filepath.Walk(repoCachePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to walk path: %w", err)
+		}
+		if e.CurseSlug != "" {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == ".hg" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		file, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+
+		fileStr := string(file)
+		if strings.Contains(fileStr, "@curseforge-project-slug") {
+			e.LogGroup.Debug("Found @curseforge-project-slug in %s", path)
+			slug := strings.Split(fileStr, "@curseforge-project-slug")[1]
+			slug = strings.TrimSpace(slug)
+			slug = strings.TrimPrefix(slug, ":")
+			slug = strings.TrimSpace(slug)
+			slug = strings.Split(slug, "@")[0]
+			e.CurseSlug = strings.TrimSpace(slug)
+			e.LogGroup.Debug("Updated CurseSlug to %s", e.CurseSlug)
+			return nil
+		}
+
+		return nil
+	})
+}
+
+// NewHgExternal creates a new instance of HgExternal.
+func NewHgExternal(e *ExternalEntry, forceExternals bool) (*HgExternal, error) {
+	if e.EType != Hg {
+		return nil, fmt.Errorf("external entry is not an hg type")
+	}
+
+	if _, err := exec.LookPath("hg"); err != nil {
+		return nil, fmt.Errorf("hg is not installed")
+	}
+
+	return &HgExternal{
+		metadata:       e,
+		forceExternals: forceExternals,
+	}, nil
+}
+
+func (h *HgExternal) getRepoCachePath() string {
+	return h.metadata.RepoCacheDir
+}
+
+// GetURL returns the Mercurial repository URL.
+func (h *HgExternal) GetURL() string {
+	return h.metadata.URL
+}
+
+func (h *HgExternal) hg(dir string, args ...string) (string, error) {
+	cmd := exec.Command("hg", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "HGPLAIN=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("hg %s failed: %w, output: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(output), nil
+}
+
+// getLatestTag returns the tag of the newest tagged revision in the cache.
+func (h *HgExternal) getLatestTag() (string, error) {
+	output, err := h.hg(h.getRepoCachePath(), "tags", "--template", "{tag}\\n")
+	if err != nil {
+		return "", err
+	}
+	for _, tag := range strings.Split(output, "\n") {
+		if tag != "" && tag != "tip" {
+			return tag, nil
+		}
+	}
+	return "", fmt.Errorf("no tags found in %s", h.GetURL())
+}
+
+// Checkout clones the repository into the cache, or pulls when it's stale, and updates it to
+// the requested tag, branch or commit.
+func (h *HgExternal) Checkout() error {
+	repoCachePath := h.getRepoCachePath()
+	e := h.metadata
+
+	// Instantiate the last-updated helper for the cache.
+	helper := NewLastUpdatedHelper(repoCachePath, ".lastUpdated", h.forceExternals, e.LogGroup)
+	lastUpdatedPath := helper.FilePath(e.Tag)
+
+	// If forced, delete any existing marker; otherwise, if the marker exists and is fresh, skip heavy operations.
+	if helper.Force {
+		if err := helper.Delete(lastUpdatedPath); err != nil {
+			return fmt.Errorf("HG: failed to delete lastUpdated marker: %w", err)
+		}
+	} else {
+		if stale, err := helper.IsStale(lastUpdatedPath, e.cacheValidity()); err != nil {
+			return err
+		} else if !stale {
+			e.LogGroup.Verbose("HG: Cache is up-to-date for %s", e.DestPath)
+			return h.lookForCurseSlug()
+		}
+	}
+
+	// Clone or update the cached repository.
+	if _, err := os.Stat(filepath.Join(repoCachePath, ".hg")); os.IsNotExist(err) {
+		e.LogGroup.Verbose("HG: Cloning %s into cache: %s", e.URL, repoCachePath)
+		if err := os.RemoveAll(repoCachePath); err != nil {
+			return fmt.Errorf("failed to remove existing cache dir at %s: %w", repoCachePath, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(repoCachePath), os.ModePerm); err != nil {
+			return fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		if _, err := h.hg(filepath.Dir(repoCachePath), "clone", "--noupdate", e.URL, repoCachePath); err != nil {
+			return fmt.Errorf("failed to clone into cache %s: %w", e.URL, err)
+		}
+	} else {
+		e.LogGroup.Verbose("HG: Pulling latest changes in cache for %s", e.URL)
+		if _, err := h.hg(repoCachePath, "pull"); err != nil {
+			return fmt.Errorf("failed to update cache: %w", err)
+		}
+	}
+
+	// Perform the update based on the type.
+	var rev string
+	switch e.CheckoutType {
+	case "branch", "commit":
+		rev = e.Tag
+	case "tag":
+		if e.Tag == "latest" || e.Tag == "" {
+			tag, err := h.getLatestTag()
+			if err != nil {
+				return err
+			}
+			e.Tag = tag
+		}
+		rev = e.Tag
+	default:
+		rev = "default"
+		e.Tag = rev
+	}
+
+	e.LogGroup.Verbose("HG: Updating to %s %s", e.CheckoutType, rev)
+	if _, err := h.hg(repoCachePath, "update", "--clean", "--rev", rev); err != nil {
+		return fmt.Errorf("hg update to %s failed: %w", rev, err)
+	}
+
+	// Write the marker file now that the checkout is complete.
+	if err := helper.Write(lastUpdatedPath); err != nil {
+		return err
+	}
+
+	e.LogGroup.Debug("HG: %s checkout successful: %s", e.DestPath, e.Tag)
+	return h.lookForCurseSlug()
+}
+
+// Revision returns the changeset ID of the working directory of the cache.
+func (h *HgExternal) Revision() (string, error) {
+	output, err := h.hg(h.getRepoCachePath(), "log", "--rev", ".", "--template", "{node}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
@@ -0,0 +1,90 @@
+package external
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestHgExternal_LegacyUrl(t *testing.T) {
+	var e ExternalEntry
+	require.NoError(t, yaml.Unmarshal([]byte("url: hg://hg.wowace.com/wow/libfoo/mainline\ntag: 1.0.0"), &e))
+	assert.Equal(t, Hg, e.EType)
+	assert.Equal(t, "https://repos.wowace.com/wow/libfoo", e.URL)
+	assert.Equal(t, "tag", e.CheckoutType)
+
+	_, err := NewHgExternal(&ExternalEntry{EType: Git}, false)
+	assert.Error(t, err)
+}
+
+func runHg(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("hg", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "HGPLAIN=1", "HGUSER=Jane Doe <jane@example.com>")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func TestHgExternal_Checkout(t *testing.T) {
+	if _, err := exec.LookPath("hg"); err != nil {
+		t.Skip("hg is not installed")
+	}
+
+	upstream := t.TempDir()
+	runHg(t, upstream, "init")
+	lib := filepath.Join(upstream, "LibFoo.lua")
+	require.NoError(t, os.WriteFile(lib, []byte("-- @curseforge-project-slug: libfoo@\nlocal version = 1\n"), 0644))
+	runHg(t, upstream, "add", "LibFoo.lua")
+	runHg(t, upstream, "commit", "-m", "Version 1")
+	runHg(t, upstream, "tag", "1.0.0")
+	require.NoError(t, os.WriteFile(lib, []byte("-- @curseforge-project-slug: libfoo@\nlocal version = 2\n"), 0644))
+	runHg(t, upstream, "commit", "-m", "Version 2")
+
+	tests := []struct {
+		name         string
+		checkoutType string
+		tag          string
+		wantTag      string
+		wantContents string
+	}{
+		{name: "Default branch", wantTag: "default", wantContents: "local version = 2"},
+		{name: "Latest tag", checkoutType: "tag", tag: "latest", wantTag: "1.0.0", wantContents: "local version = 1"},
+		{name: "Tag", checkoutType: "tag", tag: "1.0.0", wantTag: "1.0.0", wantContents: "local version = 1"},
+		{name: "Branch", checkoutType: "branch", tag: "default", wantTag: "default", wantContents: "local version = 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &ExternalEntry{
+				URL:          upstream,
+				EType:        Hg,
+				CheckoutType: tt.checkoutType,
+				Tag:          tt.tag,
+				DestPath:     "Libs/LibFoo",
+				RepoCacheDir: filepath.Join(t.TempDir(), "cache"),
+				LogGroup:     logger.NewLogGroup("test"),
+			}
+			hE, err := NewHgExternal(e, false)
+			require.NoError(t, err)
+			require.NoError(t, hE.Checkout())
+
+			assert.Equal(t, tt.wantTag, e.Tag)
+			assert.Equal(t, "libfoo", e.CurseSlug)
+			contents, err := os.ReadFile(filepath.Join(e.RepoCacheDir, "LibFoo.lua"))
+			require.NoError(t, err)
+			assert.Contains(t, string(contents), tt.wantContents)
+
+			// A fresh cache is reused without pulling
+			e.CurseSlug = ""
+			require.NoError(t, hE.Checkout())
+			assert.Equal(t, "libfoo", e.CurseSlug)
+		})
+	}
+}
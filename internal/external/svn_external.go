@@ -1,6 +1,7 @@
 package external
 
 import (
+	"encoding/xml"
 	"fmt"
 	"os"
 	"os/exec"
@@ -84,6 +85,34 @@ func (s *SvnExternal) GetURL() string {
 	return s.metadata.URL
 }
 
+// svnInfo is the part of `svn info --xml` that identifies the checkout.
+type svnInfo struct {
+	Entry struct {
+		Revision string `xml:"revision,attr"`
+		URL      string `xml:"url"`
+	} `xml:"entry"`
+}
+
+// Revision returns the URL and revision of the cache as "<url>@<revision>", since the checkout
+// of a tag or branch has its own URL.
+func (s *SvnExternal) Revision() (string, error) {
+	cmd := exec.Command("svn", "info", "--xml")
+	cmd.Dir = s.getRepoCachePath()
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("svn info failed for %s: %w", s.getRepoCachePath(), err)
+	}
+
+	var info svnInfo
+	if err := xml.Unmarshal(output, &info); err != nil {
+		return "", fmt.Errorf("failed to parse svn info: %w", err)
+	}
+	if info.Entry.URL == "" || info.Entry.Revision == "" {
+		return "", fmt.Errorf("no revision found in svn info for %s", s.getRepoCachePath())
+	}
+	return info.Entry.URL + "@" + info.Entry.Revision, nil
+}
+
 type svnTagMeta struct {
 	Tag    string
 	TagUrl string
@@ -283,7 +312,7 @@ func (s *SvnExternal) Checkout() error {
 		}
 	} else {
 		// Otherwise, check if the cache is stale.
-		stale, err := helper.IsStale(lastUpdatedPath, 24*time.Hour)
+		stale, err := helper.IsStale(lastUpdatedPath, e.cacheValidity())
 		if err != nil {
 			return err
 		}
@@ -2,6 +2,9 @@ package external
 
 type Vcs interface {
 	Checkout() error
+	// Revision returns the revision the cache is checked out at, which Checkout resolved the
+	// tag or branch to. ExternalEntry.Pin checks out the same revision again.
+	Revision() (string, error)
 	lookForCurseSlug() error
 }
 
@@ -3,15 +3,40 @@ package github
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 
+	"github.com/McTalian/wow-build-tools/internal/api"
 	"github.com/McTalian/wow-build-tools/internal/logger"
 )
 
-var githubApiUrl = "https://api.github.com/"
-var githubUploadUrl = "https://uploads.github.com/"
 var authHeaderValue string
 
+// apiUrl and uploadUrl are the base URLs of the GitHub REST API and of release asset uploads,
+// which differ on GitHub Enterprise Server.
+func apiUrl() string {
+	return api.Current().GitHubApiUrl
+}
+
+func uploadUrl() string {
+	return api.Current().GitHubUploadUrl
+}
+
+// ReleasesUrl is the endpoint the releases of the repository are created with.
+func ReleasesUrl(slug string) string {
+	return fmt.Sprintf("%srepos/%s/releases", apiUrl(), slug)
+}
+
+// ReleaseUrl is the endpoint of a release of the repository.
+func ReleaseUrl(slug string, releaseId int) string {
+	return fmt.Sprintf("%s/%d", ReleasesUrl(slug), releaseId)
+}
+
+// AssetUploadUrl is the endpoint the file is uploaded to as an asset of the release.
+func AssetUploadUrl(slug string, releaseId int, filename string) string {
+	return fmt.Sprintf("%srepos/%s/releases/%d/assets?name=%s", uploadUrl(), slug, releaseId, url.QueryEscape(filename))
+}
+
 func IsTokenSet() bool {
 	if os.Getenv("GITHUB_OAUTH") == "" {
 		return false
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/McTalian/wow-build-tools/internal/api"
 	"github.com/McTalian/wow-build-tools/internal/logger"
 )
 
@@ -37,7 +38,7 @@ func (r *GitHubRelease) getPayload() (*bytes.Buffer, error) {
 }
 
 func (r *GitHubRelease) UpdateRelease(newPayload GitHubReleasePayload) error {
-	url := fmt.Sprintf("%srepos/%s/releases/%d", githubApiUrl, r.Slug, r.Id)
+	url := ReleaseUrl(r.Slug, r.Id)
 
 	r.GitHubReleasePayload = newPayload
 	body, err := r.getPayload()
@@ -57,7 +58,7 @@ func (r *GitHubRelease) UpdateRelease(newPayload GitHubReleasePayload) error {
 		return err
 	}
 
-	client := &http.Client{}
+	client := api.Client()
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -77,7 +78,7 @@ func CreateRelease(slug string, payload GitHubReleasePayload) (release *GitHubRe
 		Slug:                 slug,
 	}
 
-	url := fmt.Sprintf("%srepos/%s/releases", githubApiUrl, r.Slug)
+	url := ReleasesUrl(r.Slug)
 
 	body, err := r.getPayload()
 	if err != nil {
@@ -96,7 +97,7 @@ func CreateRelease(slug string, payload GitHubReleasePayload) (release *GitHubRe
 		return nil, err
 	}
 
-	client := &http.Client{}
+	client := api.Client()
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -115,7 +116,7 @@ func CreateRelease(slug string, payload GitHubReleasePayload) (release *GitHubRe
 var ErrReleaseNotFound = fmt.Errorf("release not found")
 
 func GetRelease(slug, tag string) (release *GitHubRelease, err error) {
-	url := fmt.Sprintf("%srepos/%s/releases/tags/%s", githubApiUrl, slug, tag)
+	url := fmt.Sprintf("%srepos/%s/releases/tags/%s", apiUrl(), slug, tag)
 
 	req, err := http.NewRequest("GET", url, nil)
 
@@ -126,7 +127,7 @@ func GetRelease(slug, tag string) (release *GitHubRelease, err error) {
 		return
 	}
 
-	resp, err := http.Get(url)
+	resp, err := api.Client().Do(req)
 	if err != nil {
 		return
 	}
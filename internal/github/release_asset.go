@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/McTalian/wow-build-tools/internal/api"
 	"github.com/McTalian/wow-build-tools/internal/logger"
 )
 
@@ -30,7 +30,7 @@ func (ghRA *GitHubReleaseAsset) downloadAsset(logGroup *logger.LogGroup) (io.Rea
 		return nil, err
 	}
 
-	client := &http.Client{}
+	client := api.Client()
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -46,7 +46,7 @@ func (ghRA *GitHubReleaseAsset) downloadAsset(logGroup *logger.LogGroup) (io.Rea
 }
 
 func getAssetId(slug string, releaseId int, filename string) (int, error) {
-	url := fmt.Sprintf("%srepos/%s/releases/%d/assets", githubApiUrl, slug, releaseId)
+	url := fmt.Sprintf("%srepos/%s/releases/%d/assets", apiUrl(), slug, releaseId)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -60,7 +60,7 @@ func getAssetId(slug string, releaseId int, filename string) (int, error) {
 		return -1, err
 	}
 
-	client := &http.Client{}
+	client := api.Client()
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -85,7 +85,7 @@ func getAssetId(slug string, releaseId int, filename string) (int, error) {
 }
 
 func getAsset(slug string, assetId int) (*GitHubReleaseAsset, error) {
-	url := fmt.Sprintf("%srepos/%s/releases/assets/%d", githubApiUrl, slug, assetId)
+	url := fmt.Sprintf("%srepos/%s/releases/assets/%d", apiUrl(), slug, assetId)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -99,7 +99,7 @@ func getAsset(slug string, assetId int) (*GitHubReleaseAsset, error) {
 		return nil, err
 	}
 
-	client := &http.Client{}
+	client := api.Client()
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -120,7 +120,7 @@ func getAsset(slug string, assetId int) (*GitHubReleaseAsset, error) {
 }
 
 func deleteAsset(slug string, assetId int, logGroup *logger.LogGroup) error {
-	url := fmt.Sprintf("%srepos/%s/releases/assets/%d", githubApiUrl, slug, assetId)
+	url := fmt.Sprintf("%srepos/%s/releases/assets/%d", apiUrl(), slug, assetId)
 
 	req, err := http.NewRequest("DELETE", url, nil)
 	if err != nil {
@@ -134,7 +134,7 @@ func deleteAsset(slug string, assetId int, logGroup *logger.LogGroup) error {
 		return err
 	}
 
-	client := &http.Client{}
+	client := api.Client()
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -164,8 +164,7 @@ func UploadGitHubAsset(slug string, releaseId int, filename string, filePath str
 		}
 	}
 
-	encodedFilename := url.QueryEscape(filename)
-	url := fmt.Sprintf("%srepos/%s/releases/%d/assets?name=%s", githubUploadUrl, slug, releaseId, encodedFilename)
+	url := AssetUploadUrl(slug, releaseId, filename)
 
 	file, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
 	if err != nil {
@@ -195,7 +194,7 @@ func UploadGitHubAsset(slug string, releaseId int, filename string, filePath str
 		return err
 	}
 
-	client := &http.Client{}
+	client := api.Client()
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -3,6 +3,8 @@ package github
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/McTalian/wow-build-tools/internal/toc"
@@ -36,11 +38,11 @@ func GetReleaseMetadataContents(name string, version string, gameVersions toc.Ga
 			NoLib:    noLib,
 		}
 
-		for flavor, versions := range gameVersions {
-			for _, v := range versions {
+		for _, flavor := range slices.Sorted(maps.Keys(gameVersions)) {
+			for _, v := range gameVersions[flavor] {
 				flavorStr := flavor.ToString()
-				if flavor == toc.Retail {
-					flavorStr = "mainline"
+				if f, ok := toc.LookupFlavor(flavor); ok && f.ReleaseFlavor != "" {
+					flavorStr = f.ReleaseFlavor
 				}
 				release.Metadata = append(release.Metadata, metadata{
 					Flavor:    flavorStr,
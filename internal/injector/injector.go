@@ -40,13 +40,28 @@ func isInjectableExtension(ext string) bool {
 }
 
 type Injector struct {
-	simpleTokens    tokens.NormalizedSimpleTokenMap
-	buildTypeTokens tokens.NormalizedBuildTypeTokenMap
-	vcs             repo.VcsRepo
-	pkgDir          string
-	logGroup        *logger.LogGroup
-	NoLibStripFiles []string
-	unixLineEndings bool
+	simpleTokens        tokens.NormalizedSimpleTokenMap
+	buildTypeTokens     tokens.NormalizedBuildTypeTokenMap
+	fileBuildTypeTokens map[string]tokens.NormalizedBuildTypeTokenMap
+	isPlainCopy         func(relPath string) bool
+	localization        LocalizationSource
+	vcs                 repo.VcsRepo
+	pkgDir              string
+	logGroup            *logger.LogGroup
+	NoLibStripFiles     []string
+	unixLineEndings     bool
+}
+
+// SetFileBuildTypeTokens overrides the build type tokens used for a single file,
+// e.g. a flavor-specific TOC file split from a multi-flavor TOC file.
+func (i *Injector) SetFileBuildTypeTokens(filePath string, buildTypeTokens tokens.BuildTypeTokenMap) {
+	i.fileBuildTypeTokens[filepath.Clean(filePath)] = normalizeBuildTypeTokens(buildTypeTokens)
+}
+
+// SetPlainCopy sets the check for files, relative to the package directory, that are copied
+// as-is without token replacement, e.g. the pkgmeta `plain-copy` patterns.
+func (i *Injector) SetPlainCopy(isPlainCopy func(relPath string) bool) {
+	i.isPlainCopy = isPlainCopy
 }
 
 func (i *Injector) findAndReplaceInFile(filePath string) error {
@@ -77,7 +92,12 @@ func (i *Injector) findAndReplaceInFile(filePath string) error {
 		output = strings.ReplaceAll(output, n.Normalized, n.Value)
 	}
 
-	for token, n := range i.buildTypeTokens {
+	buildTypeTokens := i.buildTypeTokens
+	if fileBuildTypeTokens, ok := i.fileBuildTypeTokens[filepath.Clean(filePath)]; ok {
+		buildTypeTokens = fileBuildTypeTokens
+	}
+
+	for token, n := range buildTypeTokens {
 		if n.Normalized != "" && n.NormalizedEnd != "" && strings.Contains(output, n.Normalized) && strings.Contains(output, n.NormalizedEnd) {
 			ext := filepath.Ext(filePath)
 			if ext == ".toc" {
@@ -182,6 +202,13 @@ func (i *Injector) findAndReplaceInFile(filePath string) error {
 		output = strings.Join(newLines, "\n")
 	}
 
+	if i.localization != nil && strings.Contains(output, "@localization(") {
+		output, err = i.replaceLocalization(output, filePath)
+		if err != nil {
+			return err
+		}
+	}
+
 	if strings.Contains(output, tokens.NoLibStrip.NormalizeToken()) {
 		i.NoLibStripFiles = append(i.NoLibStripFiles, filePath)
 	}
@@ -223,11 +250,10 @@ func (i *Injector) ensureLineEndings(filePath string) error {
 	return nil
 }
 
-func (i *Injector) Execute() error {
-	i.logGroup = logger.NewLogGroup("💉 Injecting tokens into package directory")
-	defer i.logGroup.Flush(true)
-
-	return filepath.WalkDir(i.pkgDir, func(path string, d fs.DirEntry, err error) error {
+// WalkFiles calls fn for each file under dir that tokens can be injected into, e.g. Lua, XML
+// and TOC files.
+func WalkFiles(dir string, fn func(path string) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -252,30 +278,31 @@ func (i *Injector) Execute() error {
 			return nil
 		}
 
-		return i.findAndReplaceInFile(path)
+		return fn(path)
 	})
 }
 
-func NewInjector(simpleTokens tokens.SimpleTokenMap, vR repo.VcsRepo, pkgDir string, buildTypeTokens tokens.BuildTypeTokenMap, unixLineEndings bool) (*Injector, error) {
-	if len(simpleTokens) == 0 {
-		return nil, fmt.Errorf("no simple tokens provided")
-	}
-
-	normalizedMap := make(tokens.NormalizedSimpleTokenMap)
+func (i *Injector) Execute() error {
+	i.logGroup = logger.NewLogGroup("💉 Injecting tokens into package directory")
+	defer i.logGroup.Flush(true)
 
-	for token, value := range simpleTokens {
-		if !tokens.IsValidToken(string(token)) {
-			return nil, tokens.ErrInvalidTokenValue{}
+	return WalkFiles(i.pkgDir, func(path string) error {
+		if i.isPlainCopy != nil {
+			relPath, err := filepath.Rel(i.pkgDir, path)
+			if err != nil {
+				return err
+			}
+			if i.isPlainCopy(relPath) {
+				i.logGroup.Verbose("Skipping plain-copy file %s", relPath)
+				return nil
+			}
 		}
 
-		t := token.NormalizeToken()
-
-		normalizedMap[token] = tokens.NormalizedSimpleToken{
-			Normalized: t,
-			Value:      value,
-		}
-	}
+		return i.findAndReplaceInFile(path)
+	})
+}
 
+func normalizeBuildTypeTokens(buildTypeTokens tokens.BuildTypeTokenMap) tokens.NormalizedBuildTypeTokenMap {
 	normalizeBuildTypeMap := make(tokens.NormalizedBuildTypeTokenMap)
 
 	for token, value := range buildTypeTokens {
@@ -297,12 +324,36 @@ func NewInjector(simpleTokens tokens.SimpleTokenMap, vR repo.VcsRepo, pkgDir str
 		}
 	}
 
+	return normalizeBuildTypeMap
+}
+
+func NewInjector(simpleTokens tokens.SimpleTokenMap, vR repo.VcsRepo, pkgDir string, buildTypeTokens tokens.BuildTypeTokenMap, unixLineEndings bool) (*Injector, error) {
+	if len(simpleTokens) == 0 {
+		return nil, fmt.Errorf("no simple tokens provided")
+	}
+
+	normalizedMap := make(tokens.NormalizedSimpleTokenMap)
+
+	for token, value := range simpleTokens {
+		if !tokens.IsValidToken(string(token)) {
+			return nil, tokens.ErrInvalidTokenValue{}
+		}
+
+		t := token.NormalizeToken()
+
+		normalizedMap[token] = tokens.NormalizedSimpleToken{
+			Normalized: t,
+			Value:      value,
+		}
+	}
+
 	i := Injector{
-		simpleTokens:    normalizedMap,
-		buildTypeTokens: normalizeBuildTypeMap,
-		vcs:             vR,
-		pkgDir:          pkgDir,
-		unixLineEndings: unixLineEndings,
+		simpleTokens:        normalizedMap,
+		buildTypeTokens:     normalizeBuildTypeTokens(buildTypeTokens),
+		fileBuildTypeTokens: make(map[string]tokens.NormalizedBuildTypeTokenMap),
+		vcs:                 vR,
+		pkgDir:              pkgDir,
+		unixLineEndings:     unixLineEndings,
 	}
 
 	return &i, nil
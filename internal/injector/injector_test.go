@@ -276,3 +276,69 @@ func TestInjector_FindAndReplaceInFile_BuildTypeTokens(t *testing.T) {
 		})
 	}
 }
+
+func TestInjector_SetFileBuildTypeTokens(t *testing.T) {
+	contents := "#@retail@\nretail.lua\n#@end-retail@\n#@version-classic@\nclassic.lua\n#@end-version-classic@\n"
+	bTTM := tokens.BuildTypeTokenMap{
+		tokens.Retail:         false,
+		tokens.VersionClassic: false,
+	}
+
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "Project.toc")
+	classicPath := filepath.Join(dir, "Project_Vanilla.toc")
+	require.NoError(t, os.WriteFile(basePath, []byte(contents), 0644))
+	require.NoError(t, os.WriteFile(classicPath, []byte(contents), 0644))
+
+	vcsRepo := &repo.MockVcsRepo{}
+	injector, err := NewInjector(tokens.SimpleTokenMap{
+		tokens.BuildDate: "value1",
+	}, vcsRepo, dir, bTTM, true)
+	require.NoError(t, err)
+	injector.logGroup = logger.NewLogGroup("💉 Injecting tokens into package directory")
+
+	injector.SetFileBuildTypeTokens(classicPath, tokens.BuildTypeTokenMap{
+		tokens.Retail:         false,
+		tokens.VersionClassic: true,
+	})
+
+	require.NoError(t, injector.findAndReplaceInFile(basePath))
+	require.NoError(t, injector.findAndReplaceInFile(classicPath))
+
+	result, err := os.ReadFile(basePath)
+	require.NoError(t, err)
+	assert.Equal(t, "#@retail@\n#retail.lua\n#@end-retail@\n#@version-classic@\n#classic.lua\n#@end-version-classic@\n", string(result))
+
+	result, err = os.ReadFile(classicPath)
+	require.NoError(t, err)
+	assert.Equal(t, "#@retail@\n#retail.lua\n#@end-retail@\n#@version-classic@\nclassic.lua\n#@end-version-classic@\n", string(result))
+}
+
+func TestInjector_SetPlainCopy(t *testing.T) {
+	contents := "local date = \"@build-date@\"\n"
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Libs", "Data"), 0755))
+	injectedPath := filepath.Join(dir, "Project.lua")
+	plainPath := filepath.Join(dir, "Libs", "Data", "Strings.lua")
+	require.NoError(t, os.WriteFile(injectedPath, []byte(contents), 0644))
+	require.NoError(t, os.WriteFile(plainPath, []byte(contents), 0644))
+
+	injector, err := NewInjector(tokens.SimpleTokenMap{
+		tokens.BuildDate: "2025-01-01",
+	}, &repo.MockVcsRepo{}, dir, tokens.BuildTypeTokenMap{}, true)
+	require.NoError(t, err)
+
+	injector.SetPlainCopy(func(relPath string) bool {
+		return relPath == filepath.Join("Libs", "Data", "Strings.lua")
+	})
+	require.NoError(t, injector.Execute())
+
+	result, err := os.ReadFile(injectedPath)
+	require.NoError(t, err)
+	assert.Equal(t, "local date = \"2025-01-01\"\n", string(result))
+
+	result, err = os.ReadFile(plainPath)
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(result))
+}
@@ -0,0 +1,194 @@
+package injector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/McTalian/wow-build-tools/internal/api"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+)
+
+// LocalizationSource provides the strings that replace `@localization(...)@` tokens.
+type LocalizationSource interface {
+	Export(token *tokens.LocalizationToken) (string, error)
+}
+
+// CurseLocalization exports strings from the localization system of a CurseForge project.
+type CurseLocalization struct {
+	ProjectId string
+	ApiKey    string
+	BaseUrl   string
+	Client    *http.Client
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+func NewCurseLocalization(projectId string, apiKey string) *CurseLocalization {
+	return &CurseLocalization{
+		ProjectId: projectId,
+		ApiKey:    apiKey,
+		BaseUrl:   api.Current().CurseApiUrl,
+		Client:    api.Client(),
+		cache:     make(map[string]string),
+	}
+}
+
+func (c *CurseLocalization) exportUrl(token *tokens.LocalizationToken) string {
+	return fmt.Sprintf("%sprojects/%s/localization/export?%s", c.BaseUrl, c.ProjectId, token.Query().Encode())
+}
+
+// Export fetches the strings for the token, responses are cached since the same token is
+// usually used in several files.
+func (c *CurseLocalization) Export(token *tokens.LocalizationToken) (string, error) {
+	url := c.exportUrl(token)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if contents, ok := c.cache[url]; ok {
+		return contents, nil
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create localization request: %w", err)
+	}
+	req.Header.Set("x-api-token", c.ApiKey)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch localization: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read localization: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch localization (%s): %s", token.Locale, resp.Status)
+	}
+
+	contents := strings.TrimRight(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n")
+	if strings.HasPrefix(strings.TrimSpace(contents), "<") {
+		return "", fmt.Errorf("unexpected localization response (%s), check the project id and CF_API_KEY", token.Locale)
+	}
+
+	c.cache[url] = contents
+	return contents, nil
+}
+
+// Import adds phrases to the CurseForge project, contents is an additive Lua table of the
+// locale, e.g. `L["Hello"] = true`. Existing phrases the contents don't mention are kept.
+func (c *CurseLocalization) Import(locale string, contents string) error {
+	metadata, err := json.Marshal(map[string]string{
+		"language":                locale,
+		"missing-phrase-handling": "DoNothing",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode localization metadata: %w", err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err = writer.WriteField("metadata", string(metadata)); err != nil {
+		return fmt.Errorf("failed to write metadata field: %w", err)
+	}
+	if err = writer.WriteField("localizations", contents); err != nil {
+		return fmt.Errorf("failed to write localizations field: %w", err)
+	}
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+
+	url := fmt.Sprintf("%sprojects/%s/localization/import", c.BaseUrl, c.ProjectId)
+	req, err := http.NewRequest("POST", url, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create localization import request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("x-api-token", c.ApiKey)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to import localization: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to import localization (%s): %s", locale, resp.Status)
+	}
+
+	// Exports of the locale are stale now
+	c.mu.Lock()
+	clear(c.cache)
+	c.mu.Unlock()
+
+	return nil
+}
+
+// SetLocalization sets the source of the strings for `@localization(...)@` tokens.
+// Without one the tokens are left as they are.
+func (i *Injector) SetLocalization(source LocalizationSource) {
+	i.localization = source
+}
+
+// singleKeyValue returns the value assigned to key in an exported localization table, either
+// an additive table of `L["key"] = value` lines or a table of `["key"] = value,` fields.
+func singleKeyValue(contents string, token *tokens.LocalizationToken) (string, error) {
+	keyRegex := regexp.MustCompile(fmt.Sprintf(`^\s*(?:%s)?\[%q\]\s*=\s*(.*?)\s*,?\s*$`, regexp.QuoteMeta(token.TableName), token.Key))
+	for _, line := range strings.Split(contents, "\n") {
+		if m := keyRegex.FindStringSubmatch(line); m != nil {
+			return m[1], nil
+		}
+	}
+	return "", fmt.Errorf("localization key %s not found (%s)", token.Key, token.Locale)
+}
+
+// replaceLocalization replaces the localization tokens of the file contents. Tokens exporting a
+// table replace their whole line, tokens with a `key` are replaced in place by its value.
+func (i *Injector) replaceLocalization(output string, filePath string) (string, error) {
+	lines := strings.Split(output, "\n")
+	var newLines []string
+	for _, line := range lines {
+		match := tokens.LocalizationRegex.FindStringSubmatchIndex(line)
+		if match == nil {
+			newLines = append(newLines, line)
+			continue
+		}
+
+		token, err := tokens.ParseLocalizationToken(line[match[2]:match[3]])
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", filePath, err)
+		}
+		if token.HandleSubnamespaces == tokens.SubnamespacesSubtable {
+			i.logGroup.Warn("%s: handle-subnamespaces=\"subtable\" is not supported, include each full subnamespace instead", filePath)
+		}
+
+		contents, err := i.localization.Export(token)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", filePath, err)
+		}
+
+		if token.Key != "" {
+			value, err := singleKeyValue(contents, token)
+			if err != nil {
+				return "", fmt.Errorf("%s: %v", filePath, err)
+			}
+			newLines = append(newLines, line[:match[0]]+value+line[match[1]:])
+		} else {
+			newLines = append(newLines, contents)
+		}
+		i.logGroup.Verbose("Replaced localization (%s) in %s", token.Locale, filePath)
+	}
+
+	return strings.Join(newLines, "\n"), nil
+}
@@ -0,0 +1,179 @@
+package injector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/McTalian/wow-build-tools/internal/repo"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLocalizationServer stands in for the CurseForge localization export endpoint.
+func newLocalizationServer(t *testing.T, requests *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if r.Header.Get("x-api-token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/projects/1234/localization/export" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		q := r.URL.Query()
+		switch q.Get("lang") {
+		case "enUS":
+			w.Write([]byte("L[\"Hello\"] = true\r\nL[\"Goodbye\"] = \"Goodbye\"\r\n"))
+		case "deDE":
+			if q.Get("export-type") != "Table" || q.Get("unlocalized") != "Ignore" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte("local L = {\n\t[\"Hello\"] = \"Hallo\",\n}\n"))
+		case "html":
+			w.Write([]byte("<html>Sign in</html>"))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestLocalization(t *testing.T, apiKey string) (*CurseLocalization, *int32) {
+	var requests int32
+	server := newLocalizationServer(t, &requests)
+	l := NewCurseLocalization("1234", apiKey)
+	l.BaseUrl = server.URL + "/"
+	return l, &requests
+}
+
+func TestCurseLocalization_Export(t *testing.T) {
+	l, requests := newTestLocalization(t, "secret")
+
+	contents, err := l.Export(&tokens.LocalizationToken{Locale: "enUS", TableName: "L"})
+	require.NoError(t, err)
+	assert.Equal(t, "L[\"Hello\"] = true\nL[\"Goodbye\"] = \"Goodbye\"", contents)
+
+	_, err = l.Export(&tokens.LocalizationToken{Locale: "enUS", TableName: "L"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests), "Expected the second export to be cached")
+
+	_, err = l.Export(&tokens.LocalizationToken{Locale: "html"})
+	assert.Error(t, err)
+
+	_, err = l.Export(&tokens.LocalizationToken{Locale: "xxXX"})
+	assert.Error(t, err)
+
+	forbidden, _ := newTestLocalization(t, "wrong")
+	_, err = forbidden.Export(&tokens.LocalizationToken{Locale: "enUS"})
+	assert.Error(t, err)
+}
+
+func TestInjector_SetLocalization(t *testing.T) {
+	l, _ := newTestLocalization(t, "secret")
+
+	dir := t.TempDir()
+	enUSPath := filepath.Join(dir, "enUS.lua")
+	deDEPath := filepath.Join(dir, "deDE.lua")
+	keyPath := filepath.Join(dir, "Key.lua")
+	tableKeyPath := filepath.Join(dir, "TableKey.lua")
+	require.NoError(t, os.WriteFile(enUSPath, []byte("local L = NewLocale(\"enUS\")\n--@localization(locale=\"enUS\", format=\"lua_additive_table\", same-key-is-true=true)@\n"), 0644))
+	require.NoError(t, os.WriteFile(deDEPath, []byte("--@localization(locale=\"deDE\", format=\"lua_table\", handle-unlocalized=\"ignore\")@--\nreturn L\n"), 0644))
+	require.NoError(t, os.WriteFile(keyPath, []byte("print(@localization(locale=\"enUS\", key=\"Goodbye\")@)\n"), 0644))
+	require.NoError(t, os.WriteFile(tableKeyPath, []byte("print(@localization(locale=\"deDE\", format=\"lua_table\", handle-unlocalized=\"ignore\", key=\"Hello\")@)\n"), 0644))
+
+	injector, err := NewInjector(tokens.SimpleTokenMap{
+		tokens.BuildDate: "2025-01-01",
+	}, &repo.MockVcsRepo{}, dir, tokens.BuildTypeTokenMap{}, true)
+	require.NoError(t, err)
+	injector.SetLocalization(l)
+	require.NoError(t, injector.Execute())
+
+	result, err := os.ReadFile(enUSPath)
+	require.NoError(t, err)
+	assert.Equal(t, "local L = NewLocale(\"enUS\")\nL[\"Hello\"] = true\nL[\"Goodbye\"] = \"Goodbye\"\n", string(result))
+
+	result, err = os.ReadFile(deDEPath)
+	require.NoError(t, err)
+	assert.Equal(t, "local L = {\n\t[\"Hello\"] = \"Hallo\",\n}\nreturn L\n", string(result))
+
+	result, err = os.ReadFile(keyPath)
+	require.NoError(t, err)
+	assert.Equal(t, "print(\"Goodbye\")\n", string(result))
+
+	result, err = os.ReadFile(tableKeyPath)
+	require.NoError(t, err)
+	assert.Equal(t, "print(\"Hallo\")\n", string(result))
+}
+
+func TestInjector_LocalizationErrors(t *testing.T) {
+	l, _ := newTestLocalization(t, "secret")
+
+	tests := map[string]string{
+		"Invalid token": "--@localization(locale=\"enUS\", format=\"json\")@\n",
+		"Failed export": "--@localization(locale=\"xxXX\")@\n",
+		"Missing key":   "print(@localization(locale=\"enUS\", key=\"Missing\")@)\n",
+	}
+
+	for name, contents := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "Locale.lua"), []byte(contents), 0644))
+
+			injector, err := NewInjector(tokens.SimpleTokenMap{
+				tokens.BuildDate: "2025-01-01",
+			}, &repo.MockVcsRepo{}, dir, tokens.BuildTypeTokenMap{}, true)
+			require.NoError(t, err)
+			injector.SetLocalization(l)
+			assert.Error(t, injector.Execute())
+		})
+	}
+}
+
+func TestInjector_WithoutLocalization(t *testing.T) {
+	contents := "--@localization(locale=\"enUS\")@\n"
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Locale.lua")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+
+	injector, err := NewInjector(tokens.SimpleTokenMap{
+		tokens.BuildDate: "2025-01-01",
+	}, &repo.MockVcsRepo{}, dir, tokens.BuildTypeTokenMap{}, true)
+	require.NoError(t, err)
+	require.NoError(t, injector.Execute())
+
+	result, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(result))
+}
+
+func TestCurseLocalization_Import(t *testing.T) {
+	var metadata, localizations string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/projects/1234/localization/import" || r.Header.Get("x-api-token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		metadata = r.FormValue("metadata")
+		localizations = r.FormValue("localizations")
+	}))
+	t.Cleanup(server.Close)
+
+	l := NewCurseLocalization("1234", "secret")
+	l.BaseUrl = server.URL + "/"
+	require.NoError(t, l.Import("enUS", "L[\"New\"] = true"))
+	assert.JSONEq(t, `{"language": "enUS", "missing-phrase-handling": "DoNothing"}`, metadata)
+	assert.Equal(t, "L[\"New\"] = true", localizations)
+
+	l.ApiKey = "wrong"
+	assert.Error(t, l.Import("enUS", "L[\"New\"] = true"))
+}
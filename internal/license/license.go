@@ -3,24 +3,23 @@ package license
 import (
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/McTalian/wow-build-tools/internal/api"
 	"github.com/McTalian/wow-build-tools/internal/tokens"
 	"golang.org/x/net/html"
 )
 
-var baseUrl = "https://www.wowace.com/project/"
 var licensePath = "/license"
 
 func downloadLicense(curseProjectId string) (string, error) {
 	// Download license file from curse project
-	url := fmt.Sprintf("%s%s%s", baseUrl, curseProjectId, licensePath)
+	url := fmt.Sprintf("%s%s%s", api.Current().LicenseUrl, curseProjectId, licensePath)
 
 	// Download license file
-	resp, err := http.Get(url)
+	resp, err := api.Client().Get(url)
 	if err != nil {
 		return "", err
 	}
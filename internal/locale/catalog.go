@@ -0,0 +1,125 @@
+package locale
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var localeRegex = regexp.MustCompile(`^[a-z]{2}[A-Z]{2}$`)
+
+// IsLocale reports whether the name is a WoW locale, e.g. enUS or deDE.
+func IsLocale(name string) bool {
+	return localeRegex.MatchString(name)
+}
+
+// StringKey identifies a string, namespaces are separated by slashes, e.g. `Options/General`.
+type StringKey struct {
+	Namespace string
+	Key       string
+}
+
+func (k StringKey) String() string {
+	if k.Namespace == "" {
+		return k.Key
+	}
+	return k.Namespace + "/" + k.Key
+}
+
+func compareStringKeys(a, b StringKey) int {
+	if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Key, b.Key)
+}
+
+// Catalog holds the translated strings of a project by locale.
+type Catalog struct {
+	BaseLocale string
+	strings    map[string]map[StringKey]string
+
+	reported map[string]bool
+}
+
+func NewCatalog(baseLocale string) *Catalog {
+	if baseLocale == "" {
+		baseLocale = "enUS"
+	}
+	return &Catalog{
+		BaseLocale: baseLocale,
+		strings:    make(map[string]map[StringKey]string),
+		reported:   make(map[string]bool),
+	}
+}
+
+// Set adds a translated string, an empty value is treated as untranslated.
+func (c *Catalog) Set(locale string, key StringKey, value string) {
+	if value == "" {
+		return
+	}
+	if c.strings[locale] == nil {
+		c.strings[locale] = make(map[StringKey]string)
+	}
+	c.strings[locale][key] = value
+}
+
+func (c *Catalog) Get(locale string, key StringKey) (string, bool) {
+	value, ok := c.strings[locale][key]
+	return value, ok
+}
+
+// Locales returns the locales with at least one string, in a stable order.
+func (c *Catalog) Locales() []string {
+	return slices.Sorted(maps.Keys(c.strings))
+}
+
+// Keys returns the keys of the base locale, in a stable order.
+func (c *Catalog) Keys() []StringKey {
+	return slices.SortedFunc(maps.Keys(c.strings[c.BaseLocale]), compareStringKeys)
+}
+
+// PhraseKeys returns the keys of the base locale the way the code refers to them, without
+// their namespace since a namespace is selected by the `@localization@` token, sorted and
+// without duplicates.
+func (c *Catalog) PhraseKeys() []string {
+	var keys []string
+	for _, key := range c.Keys() {
+		keys = append(keys, key.Key)
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// LocaleKeys returns the keys translated in the locale, in a stable order.
+func (c *Catalog) LocaleKeys(locale string) []StringKey {
+	return slices.SortedFunc(maps.Keys(c.strings[locale]), compareStringKeys)
+}
+
+// Missing returns the keys of the base locale that the locale has no translation for.
+func (c *Catalog) Missing(locale string) []StringKey {
+	var missing []StringKey
+	for _, key := range c.Keys() {
+		if _, ok := c.strings[locale][key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// Merge adds the strings of another catalog, replacing existing ones.
+func (c *Catalog) Merge(other *Catalog) {
+	for locale, strings := range other.strings {
+		for key, value := range strings {
+			c.Set(locale, key, value)
+		}
+	}
+}
+
+func (c *Catalog) validate() error {
+	if len(c.strings[c.BaseLocale]) == 0 {
+		return fmt.Errorf("no strings found for the base locale %s", c.BaseLocale)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package locale
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+)
+
+// exportKeys returns the keys selected by the namespace of the token, along with the name
+// each is exported as.
+func (c *Catalog) exportKeys(locale string, token *tokens.LocalizationToken) ([]StringKey, map[StringKey]string) {
+	namespaces := []string{""}
+	if token.Namespace != "" {
+		namespaces = strings.Split(token.Namespace, ",")
+		for i := range namespaces {
+			namespaces[i] = strings.TrimSpace(namespaces[i])
+		}
+	}
+	concat := token.HandleSubnamespaces == tokens.SubnamespacesConcat
+
+	names := make(map[StringKey]string)
+	var keys []StringKey
+	for _, key := range append(c.Keys(), c.LocaleKeys(locale)...) {
+		if _, ok := names[key]; ok {
+			continue
+		}
+		for _, namespace := range namespaces {
+			var name string
+			switch {
+			case key.Namespace == namespace:
+				name = key.Key
+			case concat && namespace == "":
+				name = key.String()
+			case concat && strings.HasPrefix(key.Namespace, namespace+"/"):
+				name = strings.TrimPrefix(key.Namespace, namespace+"/") + "/" + key.Key
+			default:
+				continue
+			}
+			names[key] = name
+			keys = append(keys, key)
+			break
+		}
+	}
+
+	slices.SortFunc(keys, func(a, b StringKey) int {
+		return strings.Compare(names[a], names[b])
+	})
+	return keys, names
+}
+
+// Export builds the Lua for a localization token in the same format as the CurseForge
+// localization export, so the catalog can stand in for it.
+func (c *Catalog) Export(token *tokens.LocalizationToken) (string, error) {
+	locale := token.Locale
+	if locale == "" {
+		locale = c.BaseLocale
+	}
+	if !IsLocale(locale) {
+		return "", fmt.Errorf("unknown locale %s", locale)
+	}
+	tableName := token.TableName
+	if tableName == "" {
+		tableName = "L"
+	}
+
+	c.reportMissing(locale)
+
+	keys, names := c.exportKeys(locale, token)
+	var lines []string
+	if token.Format == tokens.LuaTable {
+		lines = append(lines, fmt.Sprintf("local %s = {", tableName))
+	}
+	for _, key := range keys {
+		name := luaString(names[key], token.EscapeNonAscii)
+		value, ok := c.Get(locale, key)
+		commented := false
+		if !ok {
+			switch token.HandleUnlocalized {
+			case tokens.UnlocalizedIgnore:
+				continue
+			case tokens.UnlocalizedBlank:
+				value, commented = "", true
+			case tokens.UnlocalizedComment:
+				value, _ = c.Get(c.BaseLocale, key)
+				commented = true
+			default:
+				value, _ = c.Get(c.BaseLocale, key)
+			}
+		}
+
+		valueString := luaString(value, token.EscapeNonAscii)
+		if token.SameKeyIsTrue && value == names[key] {
+			valueString = "true"
+		}
+
+		var line string
+		if token.Format == tokens.LuaTable {
+			line = fmt.Sprintf("\t[%s] = %s,", name, valueString)
+		} else {
+			line = fmt.Sprintf("%s[%s] = %s", tableName, name, valueString)
+		}
+		if commented {
+			line = "-- " + strings.TrimPrefix(line, "\t")
+			if token.Format == tokens.LuaTable {
+				line = "\t" + line
+			}
+		}
+		lines = append(lines, line)
+	}
+	if token.Format == tokens.LuaTable {
+		lines = append(lines, "}")
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// reportMissing warns once per locale about the keys without a translation.
+func (c *Catalog) reportMissing(locale string) {
+	if c.reported[locale] || locale == c.BaseLocale {
+		return
+	}
+	c.reported[locale] = true
+
+	missing := c.Missing(locale)
+	if len(missing) == 0 {
+		return
+	}
+	names := make([]string, len(missing))
+	for i, key := range missing {
+		names[i] = key.String()
+	}
+	logger.Warn("%s is missing %d of %d strings: %s", locale, len(missing), len(c.Keys()), strings.Join(names, ", "))
+}
+
+// luaString quotes s as a Lua string literal, non-ASCII bytes are written as decimal escapes
+// when escapeNonAscii is set.
+func luaString(s string, escapeNonAscii bool) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '"' || ch == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(ch)
+		case ch == '\n':
+			b.WriteString(`\n`)
+		case ch == '\r':
+			b.WriteString(`\r`)
+		case ch == '\t':
+			b.WriteString(`\t`)
+		case ch >= 0x80 && escapeNonAscii:
+			fmt.Fprintf(&b, "\\%03d", ch)
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package locale
+
+import (
+	"testing"
+
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCatalog() *Catalog {
+	c := NewCatalog("enUS")
+	c.Set("enUS", StringKey{Key: "Hello"}, "Hello")
+	c.Set("enUS", StringKey{Key: "Goodbye"}, "Goodbye")
+	c.Set("enUS", StringKey{Key: "Quote"}, "Say \"hi\"\n")
+	c.Set("enUS", StringKey{Namespace: "Options", Key: "Title"}, "Options")
+	c.Set("enUS", StringKey{Namespace: "Options/General", Key: "Title"}, "General")
+	c.Set("deDE", StringKey{Key: "Hello"}, "Hallo")
+	c.Set("deDE", StringKey{Key: "Goodbye"}, "Tschüss")
+	c.Set("deDE", StringKey{Namespace: "Options", Key: "Title"}, "Optionen")
+	return c
+}
+
+func TestCatalog_Export(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   string
+		expected string
+	}{
+		{
+			name:     "Additive table",
+			params:   `locale="enUS", same-key-is-true=true`,
+			expected: "L[\"Goodbye\"] = true\nL[\"Hello\"] = true\nL[\"Quote\"] = \"Say \\\"hi\\\"\\n\"",
+		},
+		{
+			name:     "Table",
+			params:   `locale="deDE", format="lua_table", table-name="Locale"`,
+			expected: "local Locale = {\n\t[\"Goodbye\"] = \"Tschüss\",\n\t[\"Hello\"] = \"Hallo\",\n\t[\"Quote\"] = \"Say \\\"hi\\\"\\n\",\n}",
+		},
+		{
+			name:     "Unlocalized as comment",
+			params:   `locale="deDE", handle-unlocalized="comment"`,
+			expected: "L[\"Goodbye\"] = \"Tschüss\"\nL[\"Hello\"] = \"Hallo\"\n-- L[\"Quote\"] = \"Say \\\"hi\\\"\\n\"",
+		},
+		{
+			name:     "Unlocalized as blank",
+			params:   `locale="deDE", format="lua_table", handle-unlocalized="blank"`,
+			expected: "local L = {\n\t[\"Goodbye\"] = \"Tschüss\",\n\t[\"Hello\"] = \"Hallo\",\n\t-- [\"Quote\"] = \"\",\n}",
+		},
+		{
+			name:     "Unlocalized ignored",
+			params:   `locale="deDE", handle-unlocalized="ignore", escape-non-ascii=true`,
+			expected: "L[\"Goodbye\"] = \"Tsch\\195\\188ss\"\nL[\"Hello\"] = \"Hallo\"",
+		},
+		{
+			name:     "Namespace",
+			params:   `locale="deDE", namespace="Options"`,
+			expected: "L[\"Title\"] = \"Optionen\"",
+		},
+		{
+			name:     "Concatenated subnamespaces",
+			params:   `locale="enUS", namespace="Options", handle-subnamespaces="concat"`,
+			expected: "L[\"General/Title\"] = \"General\"\nL[\"Title\"] = \"Options\"",
+		},
+	}
+
+	c := newTestCatalog()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tokens.ParseLocalizationToken(tt.params)
+			require.NoError(t, err)
+
+			contents, err := c.Export(token)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, contents)
+		})
+	}
+
+	_, err := c.Export(&tokens.LocalizationToken{Locale: "english"})
+	assert.Error(t, err)
+}
+
+func TestCatalog_Missing(t *testing.T) {
+	c := newTestCatalog()
+	assert.Equal(t, []StringKey{{Key: "Quote"}, {Namespace: "Options/General", Key: "Title"}}, c.Missing("deDE"))
+	assert.Len(t, c.Missing("frFR"), 5)
+	assert.Empty(t, c.Missing("enUS"))
+
+	other := NewCatalog("enUS")
+	other.Set("deDE", StringKey{Key: "Quote"}, "Sag \"hallo\"")
+	c.Merge(other)
+	assert.Len(t, c.Missing("deDE"), 1)
+}
@@ -0,0 +1,228 @@
+package locale
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Load reads the locale files matching the patterns, relative to topDir, into a catalog.
+//
+// JSON and PO files hold a single locale named after the file, e.g. `Locales/deDE.json`.
+// CSV files hold every locale, with a `key` column, an optional `namespace` column and a
+// column per locale.
+func Load(topDir string, baseLocale string, patterns []string) (*Catalog, error) {
+	c := NewCatalog(baseLocale)
+
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(topDir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid localization pattern %s: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no localization files match %s", pattern)
+		}
+		for _, match := range matches {
+			if !slices.Contains(files, match) {
+				files = append(files, match)
+			}
+		}
+	}
+
+	for _, file := range files {
+		if err := c.LoadFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// LoadFile reads a JSON, PO or CSV locale file into the catalog.
+func (c *Catalog) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading localization file: %v", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	ext := strings.ToLower(filepath.Ext(path))
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	switch ext {
+	case ".json", ".po":
+		if !IsLocale(name) {
+			return fmt.Errorf("%s: file name must be a locale, e.g. enUS%s", path, ext)
+		}
+		if ext == ".json" {
+			err = c.parseJson(name, data)
+		} else {
+			err = c.parsePo(name, data)
+		}
+	case ".csv":
+		err = c.parseCsv(data)
+	default:
+		return fmt.Errorf("%s: unsupported localization file type %s", path, ext)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// parseJson reads an object of keys to strings, nested objects are namespaces.
+func (c *Catalog) parseJson(locale string, data []byte) error {
+	var strings map[string]any
+	if err := json.Unmarshal(data, &strings); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	return c.addJsonObject(locale, "", strings)
+}
+
+func (c *Catalog) addJsonObject(locale string, namespace string, object map[string]any) error {
+	for key, value := range object {
+		switch v := value.(type) {
+		case string:
+			c.Set(locale, StringKey{Namespace: namespace, Key: key}, v)
+		case map[string]any:
+			subNamespace := key
+			if namespace != "" {
+				subNamespace = namespace + "/" + key
+			}
+			if err := c.addJsonObject(locale, subNamespace, v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("value of %s must be a string or an object", key)
+		}
+	}
+	return nil
+}
+
+type poEntry struct {
+	ctxt, id, str string
+	hasId         bool
+}
+
+// parsePo reads a gettext catalog, `msgctxt` is used as the namespace. Plural forms are
+// read from `msgstr[0]`.
+func (c *Catalog) parsePo(locale string, data []byte) error {
+	var entry poEntry
+	var current *string
+	add := func() {
+		if entry.hasId && entry.id != "" {
+			c.Set(locale, StringKey{Namespace: entry.ctxt, Key: entry.id}, entry.str)
+		}
+		entry = poEntry{}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keyword, rest := "", line
+		if !strings.HasPrefix(line, `"`) {
+			keyword, rest, _ = strings.Cut(line, " ")
+		}
+		value, err := strconv.Unquote(strings.TrimSpace(rest))
+		if err != nil {
+			return fmt.Errorf("line %d: invalid string: %v", lineNumber, err)
+		}
+
+		switch keyword {
+		case "":
+			if current == nil {
+				return fmt.Errorf("line %d: unexpected string", lineNumber)
+			}
+			*current += value
+			continue
+		case "msgctxt":
+			add()
+			entry.ctxt = value
+			current = &entry.ctxt
+		case "msgid":
+			if entry.hasId {
+				add()
+			}
+			entry.id, entry.hasId = value, true
+			current = &entry.id
+		case "msgstr", "msgstr[0]":
+			entry.str = value
+			current = &entry.str
+		case "msgid_plural":
+			current = nil
+		default:
+			if strings.HasPrefix(keyword, "msgstr[") {
+				current = nil
+				continue
+			}
+			return fmt.Errorf("line %d: unsupported keyword %s", lineNumber, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	add()
+
+	return nil
+}
+
+// parseCsv reads a table with a key column and a column per locale.
+func (c *Catalog) parseCsv(data []byte) error {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("missing header row")
+	}
+
+	header := records[0]
+	keyColumn := slices.Index(header, "key")
+	if keyColumn == -1 {
+		return fmt.Errorf("missing key column")
+	}
+	namespaceColumn := slices.Index(header, "namespace")
+
+	for i, column := range header {
+		if i != keyColumn && i != namespaceColumn && !IsLocale(column) {
+			return fmt.Errorf("column %s is not a locale", column)
+		}
+	}
+
+	for _, record := range records[1:] {
+		key := StringKey{Key: record[keyColumn]}
+		if namespaceColumn != -1 {
+			key.Namespace = record[namespaceColumn]
+		}
+		if key.Key == "" {
+			continue
+		}
+		for i, value := range record {
+			if i != keyColumn && i != namespaceColumn {
+				c.Set(header[i], key, value)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package locale
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeLocaleFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+	}
+	return dir
+}
+
+func TestLoad_Json(t *testing.T) {
+	dir := writeLocaleFiles(t, map[string]string{
+		"Locales/enUS.json": "\ufeff" + `{"Hello": "Hello", "Options": {"General": {"Title": "General"}}}`,
+		"Locales/deDE.json": `{"Hello": "Hallo", "Goodbye": ""}`,
+	})
+
+	c, err := Load(dir, "", []string{"Locales/*.json"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "enUS", c.BaseLocale)
+	assert.Equal(t, []string{"deDE", "enUS"}, c.Locales())
+	assert.Equal(t, []StringKey{{Key: "Hello"}, {Namespace: "Options/General", Key: "Title"}}, c.Keys())
+
+	value, ok := c.Get("deDE", StringKey{Key: "Hello"})
+	assert.True(t, ok)
+	assert.Equal(t, "Hallo", value)
+	assert.Equal(t, []StringKey{{Namespace: "Options/General", Key: "Title"}}, c.Missing("deDE"))
+}
+
+func TestLoad_Po(t *testing.T) {
+	dir := writeLocaleFiles(t, map[string]string{
+		"Locales/enUS.po": `# Project strings
+msgid ""
+msgstr ""
+"Language: enUS\n"
+
+msgid "Hello"
+msgstr "Hello"
+
+msgctxt "Options"
+msgid "Title"
+msgstr ""
+"Multi "
+"line"
+
+msgid "Apple"
+msgid_plural "Apples"
+msgstr[0] "Apple"
+msgstr[1] "Apples"
+`,
+	})
+
+	c, err := Load(dir, "enUS", []string{"Locales/enUS.po"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []StringKey{{Key: "Apple"}, {Key: "Hello"}, {Namespace: "Options", Key: "Title"}}, c.Keys())
+	value, _ := c.Get("enUS", StringKey{Namespace: "Options", Key: "Title"})
+	assert.Equal(t, "Multi line", value)
+	value, _ = c.Get("enUS", StringKey{Key: "Apple"})
+	assert.Equal(t, "Apple", value)
+}
+
+func TestLoad_Csv(t *testing.T) {
+	dir := writeLocaleFiles(t, map[string]string{
+		"Locales.csv": "namespace,key,enUS,frFR\n,Hello,Hello,Bonjour\nOptions,Title,\"Title, long\",\n",
+	})
+
+	c, err := Load(dir, "enUS", []string{"Locales.csv"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"enUS", "frFR"}, c.Locales())
+	value, _ := c.Get("enUS", StringKey{Namespace: "Options", Key: "Title"})
+	assert.Equal(t, "Title, long", value)
+	assert.Equal(t, []StringKey{{Namespace: "Options", Key: "Title"}}, c.Missing("frFR"))
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		patterns []string
+	}{
+		{name: "No matches", files: map[string]string{"enUS.json": `{"A": "A"}`}, patterns: []string{"Locales/*.json"}},
+		{name: "File not named after a locale", files: map[string]string{"strings.json": `{"A": "A"}`}, patterns: []string{"*.json"}},
+		{name: "Invalid JSON", files: map[string]string{"enUS.json": `{"A": 1}`}, patterns: []string{"*.json"}},
+		{name: "Unsupported type", files: map[string]string{"enUS.lua": `L["A"] = "A"`}, patterns: []string{"*.lua"}},
+		{name: "Invalid PO string", files: map[string]string{"enUS.po": "msgid Hello\n"}, patterns: []string{"*.po"}},
+		{name: "CSV without key column", files: map[string]string{"Locales.csv": "id,enUS\nA,A\n"}, patterns: []string{"*.csv"}},
+		{name: "CSV with unknown column", files: map[string]string{"Locales.csv": "key,English\nA,A\n"}, patterns: []string{"*.csv"}},
+		{name: "Missing base locale", files: map[string]string{"deDE.json": `{"A": "A"}`}, patterns: []string{"*.json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeLocaleFiles(t, tt.files)
+			_, err := Load(dir, "enUS", tt.patterns)
+			assert.Error(t, err)
+		})
+	}
+}
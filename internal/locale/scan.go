@@ -0,0 +1,141 @@
+package locale
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/McTalian/wow-build-tools/internal/injector"
+)
+
+// Phrase is a localization key used in the code, along with where it's used, e.g. `Core.lua:12`.
+type Phrase struct {
+	Key       string
+	Locations []string
+}
+
+// phraseRegex matches `L["key"]` and `L['key']`, the last group is set for assignments.
+func phraseRegex(tableName string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`(?:^|[^\w.:])%s\[\s*("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*')\s*\](\s*=(?:[^=]|$))?`, regexp.QuoteMeta(tableName)))
+}
+
+// luaUnquote returns the value of a quoted Lua string, or its raw contents when it uses
+// escapes Go doesn't share.
+func luaUnquote(s string) string {
+	inner := s[1 : len(s)-1]
+	quoted := inner
+	if s[0] == '\'' {
+		quoted = strings.ReplaceAll(strings.ReplaceAll(inner, `\'`, `'`), `"`, `\"`)
+	}
+	if value, err := strconv.Unquote(`"` + quoted + `"`); err == nil {
+		return value
+	}
+	return inner
+}
+
+// Scan finds the phrases used in the Lua files under dir, i.e. `L["key"]` where L is the table
+// name. Assignments like `L["key"] = "value"` are locale definitions and aren't counted.
+func Scan(dir string, tableName string) ([]Phrase, error) {
+	regex := phraseRegex(tableName)
+	locations := make(map[string][]string)
+
+	err := injector.WalkFiles(dir, func(path string) error {
+		if filepath.Ext(path) != ".lua" {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", relPath, err)
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		lineNumber := 0
+		for scanner.Scan() {
+			lineNumber++
+			line := scanner.Text()
+			if strings.HasPrefix(strings.TrimSpace(line), "--") {
+				continue
+			}
+			for _, m := range regex.FindAllStringSubmatch(line, -1) {
+				if m[2] != "" {
+					continue
+				}
+				key := luaUnquote(m[1])
+				locations[key] = append(locations[key], fmt.Sprintf("%s:%d", filepath.ToSlash(relPath), lineNumber))
+			}
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var phrases []Phrase
+	for _, key := range slices.Sorted(maps.Keys(locations)) {
+		phrases = append(phrases, Phrase{Key: key, Locations: locations[key]})
+	}
+	return phrases, nil
+}
+
+// LuaKeys returns the keys assigned in a Lua localization table, e.g. a CurseForge export.
+func LuaKeys(contents string, tableName string) []string {
+	regex := phraseRegex(tableName)
+	var keys []string
+	for _, line := range strings.Split(contents, "\n") {
+		for _, m := range regex.FindAllStringSubmatch(line, -1) {
+			if m[2] == "" {
+				continue
+			}
+			if key := luaUnquote(m[1]); !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// PhraseDiff compares the phrases used in the code with the keys of the base locale.
+type PhraseDiff struct {
+	// Missing phrases are used in the code but have no string in the base locale
+	Missing []Phrase
+	// Unused keys have a string in the base locale but aren't used in the code
+	Unused []string
+}
+
+func DiffPhrases(phrases []Phrase, keys []string) PhraseDiff {
+	var diff PhraseDiff
+	for _, phrase := range phrases {
+		if !slices.Contains(keys, phrase.Key) {
+			diff.Missing = append(diff.Missing, phrase)
+		}
+	}
+	for _, key := range keys {
+		if !slices.ContainsFunc(phrases, func(p Phrase) bool { return p.Key == key }) {
+			diff.Unused = append(diff.Unused, key)
+		}
+	}
+	return diff
+}
+
+// FormatPhrases writes the keys as an additive Lua table with every value set to true, the
+// format the CurseForge localization import expects for new phrases.
+func FormatPhrases(tableName string, keys []string) string {
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = fmt.Sprintf("%s[%s] = true", tableName, luaString(key, false))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,56 @@
+package locale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan(t *testing.T) {
+	dir := writeLocaleFiles(t, map[string]string{
+		"Core.lua": "local L = ns.L\n" +
+			"print(L[\"Hello\"], L['It\\'s'])\n" +
+			"-- print(L[\"Commented\"])\n" +
+			"if L[\"Hello\"] == L[ \"Goodbye\" ] then end\n" +
+			"self.L[\"Other table\"] = 1\n",
+		"Locales/enUS.lua": "L[\"Hello\"] = true\nL[\"Defined\"]=\"Defined\"\n",
+		"Options.xml":      "<Ui>L[\"Xml\"]</Ui>\n",
+	})
+
+	phrases, err := Scan(dir, "L")
+	require.NoError(t, err)
+	assert.Equal(t, []Phrase{
+		{Key: "Goodbye", Locations: []string{"Core.lua:4"}},
+		{Key: "Hello", Locations: []string{"Core.lua:2", "Core.lua:4"}},
+		{Key: "It's", Locations: []string{"Core.lua:2"}},
+	}, phrases)
+}
+
+func TestLuaKeys(t *testing.T) {
+	contents := "L[\"Hello\"] = true\nL[\"Say \\\"hi\\\"\"] = \"Say\"\n-- L[\"Untranslated\"] = \"Untranslated\"\nprint(L[\"Used\"])"
+	assert.Equal(t, []string{"Hello", "Say \"hi\"", "Untranslated"}, LuaKeys(contents, "L"))
+}
+
+func TestDiffPhrases(t *testing.T) {
+	phrases := []Phrase{{Key: "Hello"}, {Key: "New"}}
+	diff := DiffPhrases(phrases, []string{"Hello", "Old"})
+	assert.Equal(t, []Phrase{{Key: "New"}}, diff.Missing)
+	assert.Equal(t, []string{"Old"}, diff.Unused)
+
+	assert.Equal(t, "L[\"New\"] = true\nL[\"Say \\\"hi\\\"\"] = true", FormatPhrases("L", []string{"New", "Say \"hi\""}))
+}
+
+func TestDiffPhrases_NamespacedCatalog(t *testing.T) {
+	dir := writeLocaleFiles(t, map[string]string{
+		"Locales/enUS.json": `{"Hello": "Hello", "Options": {"Title": "Options", "Hello": "Hello"}, "Old": "Old"}`,
+	})
+	c, err := Load(dir, "", []string{"Locales/*.json"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hello", "Old", "Title"}, c.PhraseKeys())
+
+	phrases := []Phrase{{Key: "Hello"}, {Key: "Title"}, {Key: "New"}}
+	diff := DiffPhrases(phrases, c.PhraseKeys())
+	assert.Equal(t, []Phrase{{Key: "New"}}, diff.Missing)
+	assert.Equal(t, []string{"Old"}, diff.Unused)
+}
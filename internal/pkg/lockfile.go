@@ -0,0 +1,142 @@
+package pkg
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/McTalian/wow-build-tools/internal/external"
+)
+
+// LockFileName is the file in the top directory that pins every external to the revision it
+// resolved to, so release builds don't pick up new commits of a branch.
+const LockFileName = ".wbt.lock"
+
+// LockedExternal is the revision an external resolved to, along with what the pkgmeta file
+// asked for so changes to the external can be detected.
+type LockedExternal struct {
+	Type     string `yaml:"type"`
+	URL      string `yaml:"url"`
+	Ref      string `yaml:"ref"`
+	Revision string `yaml:"revision"`
+}
+
+// ExternalsLock is the contents of the lock file, keyed by the path of the external.
+type ExternalsLock struct {
+	Externals map[string]*LockedExternal `yaml:"externals"`
+
+	// Frozen fails the fetch when an external isn't pinned, or doesn't resolve to its pin,
+	// instead of updating the lock.
+	Frozen bool `yaml:"-"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// NewExternalsLock creates an empty lock for the lock file in dir.
+func NewExternalsLock(dir string) *ExternalsLock {
+	return &ExternalsLock{
+		Externals: make(map[string]*LockedExternal),
+		path:      filepath.Join(dir, LockFileName),
+	}
+}
+
+// ReadExternalsLock reads the lock file in dir, the error wraps os.ErrNotExist when there is
+// none.
+func ReadExternalsLock(dir string) (*ExternalsLock, error) {
+	lock := NewExternalsLock(dir)
+	contents, err := os.ReadFile(lock.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", LockFileName, err)
+	}
+	if err := yaml.Unmarshal(contents, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", LockFileName, err)
+	}
+	if lock.Externals == nil {
+		lock.Externals = make(map[string]*LockedExternal)
+	}
+	return lock, nil
+}
+
+// Write saves the lock to its lock file.
+func (l *ExternalsLock) Write() error {
+	contents, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", LockFileName, err)
+	}
+	if err := os.WriteFile(l.path, contents, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", LockFileName, err)
+	}
+	return nil
+}
+
+// Unpin removes the pins of the externals with the given paths, or of every external when no
+// path is given, so they are resolved again.
+func (l *ExternalsLock) Unpin(paths ...string) {
+	if len(paths) == 0 {
+		clear(l.Externals)
+		return
+	}
+	for _, path := range paths {
+		delete(l.Externals, path)
+	}
+}
+
+// Prune removes the pins of externals that are no longer in the pkgmeta file.
+func (l *ExternalsLock) Prune(externals map[string]*external.ExternalEntry) {
+	for _, path := range slices.Collect(maps.Keys(l.Externals)) {
+		if _, ok := externals[path]; !ok {
+			delete(l.Externals, path)
+		}
+	}
+}
+
+// pin checks out the external at its locked revision. Externals that aren't locked, or whose
+// url, type or ref changed in the pkgmeta file, are resolved again unless the lock is frozen.
+func (l *ExternalsLock) pin(path string, entry *external.ExternalEntry) error {
+	// The checkouts of the externals pinned before this one record their revisions concurrently
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	locked, ok := l.Externals[path]
+	if !ok {
+		if l.Frozen {
+			return fmt.Errorf("%s is not in %s", path, LockFileName)
+		}
+		return nil
+	}
+
+	if locked.Type != entry.EType.ToString() || locked.URL != entry.URL || locked.Ref != entry.Ref() {
+		if l.Frozen {
+			return fmt.Errorf("%s changed since it was locked (%s %s %s)", path, locked.Type, locked.URL, locked.Ref)
+		}
+		entry.LogGroup.Verbose("%s changed since it was locked, resolving it again", path)
+		return nil
+	}
+
+	entry.LogGroup.Verbose("Pinned to %s", locked.Revision)
+	entry.Pin(locked.Revision)
+	return nil
+}
+
+// record locks the revision the external resolved to. A frozen lock only checks that it's
+// the pinned revision.
+func (l *ExternalsLock) record(path string, locked LockedExternal) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.Frozen {
+		if pinned := l.Externals[path]; pinned.Revision != locked.Revision {
+			return fmt.Errorf("%s resolved to %s instead of %s", path, locked.Revision, pinned.Revision)
+		}
+		return nil
+	}
+
+	l.Externals[path] = &locked
+	return nil
+}
@@ -0,0 +1,144 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/McTalian/wow-build-tools/internal/external"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+)
+
+func TestExternalsLock_ReadWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := ReadExternalsLock(dir)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	lock := NewExternalsLock(dir)
+	lock.Externals["Libs/LibStub"] = &LockedExternal{Type: "svn", URL: "https://repos.curseforge.com/wow/libstub/trunk", Ref: "tag:latest", Revision: "https://repos.curseforge.com/wow/libstub/tags/1.0@103"}
+	lock.Externals["Libs/LibFoo"] = &LockedExternal{Type: "git", URL: "https://github.com/example/LibFoo", Ref: "default", Revision: "0123456789abcdef0123456789abcdef01234567"}
+	require.NoError(t, lock.Write())
+
+	read, err := ReadExternalsLock(dir)
+	require.NoError(t, err)
+	assert.Equal(t, lock.Externals, read.Externals)
+
+	contents, err := os.ReadFile(filepath.Join(dir, LockFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), "    Libs/LibFoo:\n        type: git\n")
+
+	read.Prune(map[string]*external.ExternalEntry{"Libs/LibFoo": {}})
+	assert.Len(t, read.Externals, 1)
+	read.Unpin()
+	assert.Empty(t, read.Externals)
+}
+
+func TestExternalsLock_Pin(t *testing.T) {
+	locked := &LockedExternal{Type: "git", URL: "https://github.com/example/LibFoo", Ref: "branch:main", Revision: "0123456789abcdef0123456789abcdef01234567"}
+
+	tests := []struct {
+		name       string
+		path       string
+		branch     string
+		frozen     bool
+		wantPinned bool
+		wantErr    bool
+	}{
+		{name: "Pinned", path: "Libs/LibFoo", branch: "main", wantPinned: true},
+		{name: "Frozen pinned", path: "Libs/LibFoo", branch: "main", frozen: true, wantPinned: true},
+		{name: "Not locked", path: "Libs/LibBar", branch: "main"},
+		{name: "Frozen not locked", path: "Libs/LibBar", branch: "main", frozen: true, wantErr: true},
+		{name: "Changed ref", path: "Libs/LibFoo", branch: "dev"},
+		{name: "Frozen changed ref", path: "Libs/LibFoo", branch: "dev", frozen: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock := NewExternalsLock(t.TempDir())
+			lock.Frozen = tt.frozen
+			lock.Externals["Libs/LibFoo"] = locked
+
+			entry := &external.ExternalEntry{
+				URL:          "https://github.com/example/LibFoo",
+				EType:        external.Git,
+				CheckoutType: "branch",
+				Tag:          tt.branch,
+				LogGroup:     logger.NewLogGroup("test"),
+			}
+			err := lock.pin(tt.path, entry)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPinned, entry.Pinned)
+			if tt.wantPinned {
+				assert.Equal(t, "commit", entry.CheckoutType)
+				assert.Equal(t, locked.Revision, entry.Tag)
+			}
+		})
+	}
+}
+
+func TestExternalsLock_Record(t *testing.T) {
+	lock := NewExternalsLock(t.TempDir())
+	require.NoError(t, lock.record("Libs/LibFoo", LockedExternal{Type: "git", Revision: "abc"}))
+	assert.Equal(t, "abc", lock.Externals["Libs/LibFoo"].Revision)
+
+	lock.Frozen = true
+	assert.NoError(t, lock.record("Libs/LibFoo", LockedExternal{Type: "git", Revision: "abc"}))
+	assert.Error(t, lock.record("Libs/LibFoo", LockedExternal{Type: "git", Revision: "def"}))
+}
+
+func TestExternalsLock_PinWhileRecording(t *testing.T) {
+	lock := NewExternalsLock(t.TempDir())
+	paths := []string{"Libs/LibFoo", "Libs/LibBar", "Libs/LibBaz"}
+	for _, path := range paths {
+		lock.Externals[path] = &LockedExternal{Type: "git", URL: "https://github.com/example/" + path, Ref: "default", Revision: "abc"}
+	}
+
+	// Like fetchExternals, the checkout of an external records its revision while the next
+	// ones are pinned. The assertions wait for the goroutines, the testing.T calls of
+	// testify would synchronize them with the pins.
+	var wg sync.WaitGroup
+	errs := make(chan error, len(paths))
+	var pinErr error
+	for _, path := range paths {
+		for range 100 {
+			entry := &external.ExternalEntry{
+				URL:      "https://github.com/example/" + path,
+				EType:    external.Git,
+				LogGroup: logger.NewLogGroup("test"),
+			}
+			if err := lock.pin(path, entry); err != nil {
+				pinErr = err
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if err := lock.record(path, LockedExternal{Type: "git", URL: "https://github.com/example/" + path, Ref: "default", Revision: "def"}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	require.NoError(t, pinErr)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	for _, path := range paths {
+		assert.Equal(t, "def", lock.Externals[path].Revision)
+	}
+}
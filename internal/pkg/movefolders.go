@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/McTalian/wow-build-tools/internal/logger"
+)
+
+type moveFolder struct {
+	src  string
+	dest string
+}
+
+// moveFolderPaths returns the `move-folders` entries as paths in the release directory, the most
+// nested sources first so they are moved before their parents.
+func (p *PkgMeta) moveFolderPaths(releaseDir string) ([]moveFolder, error) {
+	var moves []moveFolder
+	for src, dest := range p.MoveFolders {
+		srcPath, err := releasePath(releaseDir, src)
+		if err != nil {
+			return nil, err
+		}
+		destPath, err := releasePath(releaseDir, dest)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, moveFolder{src: srcPath, dest: destPath})
+	}
+
+	slices.SortFunc(moves, func(a, b moveFolder) int {
+		if len(a.src) != len(b.src) {
+			return len(b.src) - len(a.src)
+		}
+		return strings.Compare(a.src, b.src)
+	})
+
+	return moves, nil
+}
+
+// releasePath resolves a `move-folders` path, which is relative to the release directory.
+func releasePath(releaseDir string, path string) (string, error) {
+	resolved := filepath.Join(releaseDir, filepath.FromSlash(path))
+	rel, err := filepath.Rel(releaseDir, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", fmt.Errorf("move-folders path %s must be a folder inside the release directory", path)
+	}
+	return resolved, nil
+}
+
+// MoveFolders applies the `move-folders` entries of the pkgmeta file to the release directory.
+// It returns the top-level folders of the release directory that received moved folders.
+func (p *PkgMeta) ApplyMoveFolders(releaseDir string) ([]string, error) {
+	moves, err := p.moveFolderPaths(releaseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var addonDirs []string
+	for _, move := range moves {
+		if _, err := os.Stat(move.src); err != nil {
+			logger.Warn("Unable to move %s: %v", move.src, err)
+			continue
+		}
+
+		if err := os.RemoveAll(move.dest); err != nil {
+			return nil, fmt.Errorf("error removing %s: %v", move.dest, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(move.dest), os.ModePerm); err != nil {
+			return nil, fmt.Errorf("error creating %s: %v", filepath.Dir(move.dest), err)
+		}
+		if err := os.Rename(move.src, move.dest); err != nil {
+			return nil, fmt.Errorf("error moving %s to %s: %v", move.src, move.dest, err)
+		}
+		logger.Verbose("Moved %s to %s", move.src, move.dest)
+
+		rel, _ := filepath.Rel(releaseDir, move.dest)
+		addonDir := filepath.Join(releaseDir, strings.Split(rel, string(os.PathSeparator))[0])
+		if !slices.Contains(addonDirs, addonDir) {
+			addonDirs = append(addonDirs, addonDir)
+		}
+	}
+
+	return addonDirs, nil
+}
+
+// MovedPath returns where a path in the release directory ends up once the `move-folders`
+// entries have been applied.
+func (p *PkgMeta) MovedPath(releaseDir string, path string) string {
+	moves, err := p.moveFolderPaths(releaseDir)
+	if err != nil {
+		return path
+	}
+
+	for _, move := range moves {
+		if path == move.src {
+			return move.dest
+		}
+		if rest, ok := strings.CutPrefix(path, move.src+string(os.PathSeparator)); ok {
+			return filepath.Join(move.dest, rest)
+		}
+	}
+
+	return path
+}
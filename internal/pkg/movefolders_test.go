@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(file), 0644))
+	}
+}
+
+func TestApplyMoveFolders(t *testing.T) {
+	releaseDir := t.TempDir()
+	writeFiles(t, releaseDir,
+		"MyAddon/MyAddon.toc",
+		"MyAddon/Modules/Options/MyAddon_Options.toc",
+		"MyAddon/Modules/Options/Libs/Widget/Widget.lua",
+		"MyAddon/Modules/Config/Config.lua",
+		"MyAddon_Options/Stale.lua",
+	)
+
+	p := &PkgMeta{MoveFolders: map[string]string{
+		"MyAddon/Modules/Options":             "MyAddon_Options",
+		"MyAddon/Modules/Options/Libs/Widget": "MyAddon_Widget",
+		"MyAddon/Modules/Config":              "MyAddon/Config",
+		"MyAddon/Modules/Missing":             "MyAddon_Missing",
+	}}
+
+	addonDirs, err := p.ApplyMoveFolders(releaseDir)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		filepath.Join(releaseDir, "MyAddon"),
+		filepath.Join(releaseDir, "MyAddon_Options"),
+		filepath.Join(releaseDir, "MyAddon_Widget"),
+	}, addonDirs)
+
+	assert.FileExists(t, filepath.Join(releaseDir, "MyAddon_Options", "MyAddon_Options.toc"))
+	assert.FileExists(t, filepath.Join(releaseDir, "MyAddon_Widget", "Widget.lua"))
+	assert.FileExists(t, filepath.Join(releaseDir, "MyAddon", "Config", "Config.lua"))
+	assert.NoDirExists(t, filepath.Join(releaseDir, "MyAddon", "Modules", "Options"))
+	assert.NoDirExists(t, filepath.Join(releaseDir, "MyAddon_Options", "Libs", "Widget"))
+	assert.NoFileExists(t, filepath.Join(releaseDir, "MyAddon_Options", "Stale.lua"))
+	assert.NoDirExists(t, filepath.Join(releaseDir, "MyAddon_Missing"))
+}
+
+func TestApplyMoveFolders_OutsideReleaseDir(t *testing.T) {
+	tests := []map[string]string{
+		{"../Elsewhere": "MyAddon_Options"},
+		{"MyAddon/Options": "../MyAddon_Options"},
+		{"MyAddon/Options": "."},
+	}
+
+	for _, moveFolders := range tests {
+		p := &PkgMeta{MoveFolders: moveFolders}
+		_, err := p.ApplyMoveFolders(t.TempDir())
+		assert.Error(t, err, "%v", moveFolders)
+	}
+}
+
+func TestMovedPath(t *testing.T) {
+	releaseDir := filepath.Join("release")
+	p := &PkgMeta{MoveFolders: map[string]string{
+		"MyAddon/Modules/Options":      "MyAddon_Options",
+		"MyAddon/Modules/Options/Libs": "MyAddon_Libs",
+	}}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(releaseDir, "MyAddon", "Libs", "LibStub"), filepath.Join(releaseDir, "MyAddon", "Libs", "LibStub")},
+		{filepath.Join(releaseDir, "MyAddon", "Modules", "Options"), filepath.Join(releaseDir, "MyAddon_Options")},
+		{filepath.Join(releaseDir, "MyAddon", "Modules", "Options", "Options.lua"), filepath.Join(releaseDir, "MyAddon_Options", "Options.lua")},
+		{filepath.Join(releaseDir, "MyAddon", "Modules", "Options", "Libs", "AceGUI"), filepath.Join(releaseDir, "MyAddon_Libs", "AceGUI")},
+		{filepath.Join(releaseDir, "MyAddon", "Modules", "OptionsExtra"), filepath.Join(releaseDir, "MyAddon", "Modules", "OptionsExtra")},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, p.MovedPath(releaseDir, tt.path))
+	}
+}
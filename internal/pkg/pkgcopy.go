@@ -85,7 +85,7 @@ func copyFromCacheToPackageDir(destPath string, repoCachePath string, logGroup *
 }
 
 func copyExternal(e *external.ExternalEntry, packageDir string) error {
-	repoCachePath := e.RepoCacheDir
+	repoCachePath := e.CopySource()
 
 	destPath := filepath.Join(packageDir, e.DestPath)
 
@@ -166,25 +166,7 @@ func (p *PkgCopy) CopyToPackageDir(logGroup *logger.LogGroup) error {
 
 		// Check against ignore patterns.
 		for _, ignore := range ignores {
-			pattern := ignore
-			// For directories, trim the "/*" if present.
-			if d.IsDir() && strings.Contains(ignore, "/*") {
-				pattern = strings.TrimSuffix(ignore, "/*")
-			}
-			matched, err := filepath.Match(pattern, relPath)
-			if err != nil {
-				return fmt.Errorf("error matching ignore pattern: %v", err)
-			}
-			if matched {
-				logGroup.Debug("⛔ Ignoring %s", prettyPath)
-				// If it's a directory, skip the whole subtree.
-				if d.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-
-			matched, err = filepath.Match(pattern, d.Name())
+			matched, err := matchPattern(ignore, relPath, d.IsDir())
 			if err != nil {
 				return fmt.Errorf("error matching ignore pattern: %v", err)
 			}
@@ -231,6 +213,21 @@ func (p *PkgCopy) CopyToPackageDir(logGroup *logger.LogGroup) error {
 	return nil
 }
 
+// matchPattern reports whether a pkgmeta path pattern, e.g. from `ignore`, matches the path
+// relative to the top directory or its base name.
+func matchPattern(pattern string, relPath string, isDir bool) (bool, error) {
+	// For directories, trim the "/*" if present.
+	if isDir && strings.Contains(pattern, "/*") {
+		pattern = strings.TrimSuffix(pattern, "/*")
+	}
+	matched, err := filepath.Match(pattern, relPath)
+	if err != nil || matched {
+		return matched, err
+	}
+
+	return filepath.Match(pattern, filepath.Base(relPath))
+}
+
 func tryParsePkgMetaIgnores(pkgDir string, logGroup *logger.LogGroup) ([]string, error) {
 	args := ParseArgs{
 		PkgDir:   pkgDir,
@@ -2,7 +2,9 @@ package pkg
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -20,12 +22,22 @@ type PkgMetaManualChangelog struct {
 	MarkupType string `yaml:"markup-type"`
 }
 
+// PkgMetaLocalization points `@localization@` tokens at locale files in the project instead of
+// the CurseForge localization system.
+type PkgMetaLocalization struct {
+	BaseLocale string   `yaml:"base-locale"`
+	Files      []string `yaml:"files"`
+}
+
 // PkgMeta represents the structure of the .pkgmeta file
 type PkgMeta struct {
 	PackageAs            string                             `yaml:"package-as"`
 	Externals            map[string]*external.ExternalEntry `yaml:"externals"`
+	ExternalsRecursive   bool                               `yaml:"externals-recursive"`
 	MoveFolders          map[string]string                  `yaml:"move-folders"`
 	Ignore               []string                           `yaml:"ignore"`
+	PlainCopy            []string                           `yaml:"plain-copy"`
+	Localization         PkgMetaLocalization                `yaml:"localization"`
 	RequiredDependencies []string                           `yaml:"required-dependencies"`
 	EmbeddedLibraries    []string                           `yaml:"embedded-libraries"`
 	OptionalDependencies []string                           `yaml:"optional-dependencies"`
@@ -39,6 +51,9 @@ type PkgMeta struct {
 	WowiCreateChangelog  bool                               `yaml:"wowi-create-changelog"`
 	WowiConvertChangelog bool                               `yaml:"wowi-convert-changelog"`
 	WowiArchivePrevious  bool                               `yaml:"wowi-archive-previous"`
+	Packages             []string                           `yaml:"packages"`
+	CombinePackages      bool                               `yaml:"combine-packages"`
+	Publish              map[string]bool                    `yaml:"publish"`
 }
 
 type PkgMetaFileNotFound struct{}
@@ -64,15 +79,41 @@ func (i *PkgMeta) IgnoreString(spaces int) string {
 	return StringList(i.Ignore, spaces)
 }
 
+func (i *PkgMeta) PlainCopyString(spaces int) string {
+	return StringList(i.PlainCopy, spaces)
+}
+
+// IsPlainCopy reports whether the file, relative to the top directory, matches a `plain-copy`
+// pattern, either directly or through one of its parent directories.
+func (p *PkgMeta) IsPlainCopy(relPath string) bool {
+	isDir := false
+	for path := relPath; path != "." && path != string(os.PathSeparator); path = filepath.Dir(path) {
+		for _, pattern := range p.PlainCopy {
+			if matched, _ := matchPattern(pattern, filepath.ToSlash(path), isDir); matched {
+				return true
+			}
+		}
+		isDir = true
+	}
+	return false
+}
+
 func (p *PkgMeta) String() string {
 	str := fmt.Sprintf("Package-As: %s\n", p.PackageAs)
 	str += fmt.Sprintf("Enable No Lib Creation: %t\n", p.EnableNoLibCreation)
+	str += fmt.Sprintf("Enable TOC Creation: %t\n", p.EnableTocCreation)
 	str += fmt.Sprintf("Required Dependencies: %v\n", p.RequiredDependenciesString(4))
 	str += "Move Folders:\n"
 	for src, dest := range p.MoveFolders {
 		str += fmt.Sprintf("%s- %s -> %s\n", strings.Repeat(" ", 4), src, dest)
 	}
 	str += fmt.Sprintf("Ignore: %s\n", p.IgnoreString(4))
+	str += fmt.Sprintf("Plain Copy: %s\n", p.PlainCopyString(4))
+	str += fmt.Sprintf("Localization Files: %s\n", StringList(p.Localization.Files, 4))
+	if len(p.Packages) > 0 {
+		str += fmt.Sprintf("Packages: %s\n", StringList(p.Packages, 4))
+		str += fmt.Sprintf("Combine Packages: %t\n", p.CombinePackages)
+	}
 	str += "Externals:\n"
 	for path, entry := range p.Externals {
 		str += fmt.Sprintf("- %s: %s\n", path, entry.String(4))
@@ -80,17 +121,154 @@ func (p *PkgMeta) String() string {
 	return str
 }
 
-func (p *PkgMeta) FetchExternals(packageDir string, forceExternals bool) error {
+// FetchExternals checks out the externals and copies them into the package directory. When a
+// lock is given, externals are checked out at their pinned revisions and the lock records the
+// revisions the others resolved to.
+//
+// With externals-recursive, the externals of the externals' own pkgmeta files are fetched
+// too, into the folder of the external that needs them.
+func (p *PkgMeta) FetchExternals(packageDir string, forceExternals bool, lock *ExternalsLock) error {
 	externalLogger := logger.GetSubLog("EXT")
 	externalLogger.Debug("Fetching external dependencies")
 
+	numOrigEmbeds := len(p.EmbeddedLibraries)
+	start := time.Now()
+
+	requested := make(map[string]LockedExternal)
+	addRequested(requested, p.Externals)
+	missingSlugEncountered, err := p.fetchExternals(p.Externals, packageDir, forceExternals, lock, numOrigEmbeds)
+	if err != nil {
+		return err
+	}
+
+	if p.ExternalsRecursive {
+		level := p.Externals
+		for depth := 1; ; depth++ {
+			nested, err := nestedExternals(level, requested, externalLogger)
+			if err != nil {
+				return err
+			}
+			if len(nested) == 0 {
+				break
+			}
+			if depth > maxExternalsDepth {
+				externalLogger.Warn("Externals are nested more than %d levels deep, skipping %s", maxExternalsDepth, strings.Join(slices.Sorted(maps.Keys(nested)), ", "))
+				break
+			}
+
+			addRequested(requested, nested)
+			missingSlug, err := p.fetchExternals(nested, packageDir, forceExternals, lock, numOrigEmbeds)
+			if err != nil {
+				return err
+			}
+			missingSlugEncountered = missingSlugEncountered || missingSlug
+			maps.Copy(p.Externals, nested)
+			level = nested
+		}
+	}
+
+	externalLogger.Timing("All External dependencies fetched in %s", time.Since(start))
+
+	if missingSlugEncountered && len(p.EmbeddedLibraries) < len(p.Externals) {
+		externalLogger.Warn("CurseSlugs could not be determined for one or more externals above and it may not be specified in your pkgmeta embedded-libraries.")
+		externalLogger.Warn("Please ensure all external libraries have a CurseSlug or add them to the embedded-libraries list to support the hard work of the author(s).")
+	}
+
+	var uniqueEmbeds = make(map[string]bool)
+	for _, embed := range p.EmbeddedLibraries {
+		uniqueEmbeds[embed] = true
+	}
+	p.EmbeddedLibraries = make([]string, 0, len(uniqueEmbeds))
+	for embed := range uniqueEmbeds {
+		p.EmbeddedLibraries = append(p.EmbeddedLibraries, embed)
+	}
+
+	return nil
+}
+
+// addRequested records what the pkgmeta files ask for, before pinning or the checkouts
+// resolve the externals.
+func addRequested(requested map[string]LockedExternal, externals map[string]*external.ExternalEntry) {
+	for path, entry := range externals {
+		requested[path] = LockedExternal{
+			Type: entry.EType.ToString(),
+			URL:  entry.URL,
+			Ref:  entry.Ref(),
+		}
+	}
+}
+
+// maxExternalsDepth is how many levels of nested externals externals-recursive follows.
+const maxExternalsDepth = 5
+
+// nestedExternals returns the externals of the pkgmeta files of the given externals, keyed by
+// their path in the package. Externals whose path is already taken are skipped, with a warning
+// when they ask for another repository or version, as are the ones needing their parent.
+func nestedExternals(parents map[string]*external.ExternalEntry, requested map[string]LockedExternal, externalLogger *logger.Logger) (map[string]*external.ExternalEntry, error) {
+	nested := make(map[string]*external.ExternalEntry)
+	for _, parentPath := range slices.Sorted(maps.Keys(parents)) {
+		parent := parents[parentPath]
+		meta, err := Parse(&ParseArgs{PkgDir: parent.CopySource()})
+		if err != nil {
+			if _, ok := err.(*PkgMetaFileNotFound); ok {
+				continue
+			}
+			return nil, fmt.Errorf("failed to parse the pkgmeta file of %s: %w", parentPath, err)
+		}
+
+		for _, childPath := range slices.Sorted(maps.Keys(meta.Externals)) {
+			child := meta.Externals[childPath]
+			destPath := path.Join(filepath.ToSlash(parentPath), filepath.ToSlash(childPath))
+			want := LockedExternal{Type: child.EType.ToString(), URL: child.URL, Ref: child.Ref()}
+
+			if destPath == ".." || strings.HasPrefix(destPath, "../") {
+				externalLogger.Warn("Skipping %s of %s, it's outside of the package", childPath, parentPath)
+				continue
+			}
+			if child.URL == requested[parentPath].URL {
+				externalLogger.Verbose("Skipping %s, it's %s itself", destPath, parentPath)
+				continue
+			}
+			if existing, ok := requested[destPath]; ok {
+				if existing.URL != want.URL || existing.Ref != want.Ref {
+					externalLogger.Warn("%s needs %s (%s) at %s, which is already %s (%s)", parentPath, want.URL, want.Ref, destPath, existing.URL, existing.Ref)
+				}
+				continue
+			}
+			if existing, ok := nested[destPath]; ok {
+				if existing.URL != want.URL || existing.Ref() != want.Ref {
+					externalLogger.Warn("%s needs %s (%s) at %s, which is already %s (%s)", parentPath, want.URL, want.Ref, destPath, existing.URL, existing.Ref())
+				}
+				continue
+			}
+			for otherPath, other := range requested {
+				if other.URL == want.URL && other.Ref != want.Ref {
+					externalLogger.Warn("%s needs %s (%s) but %s is %s", destPath, want.URL, want.Ref, otherPath, other.Ref)
+				}
+			}
+			for otherPath, other := range nested {
+				if other.URL == want.URL && other.Ref() != want.Ref {
+					externalLogger.Warn("%s needs %s (%s) but %s is %s", destPath, want.URL, want.Ref, otherPath, other.Ref())
+				}
+			}
+
+			child.DestPath = destPath
+			nested[destPath] = child
+		}
+	}
+	return nested, nil
+}
+
+// fetchExternals checks out and copies the externals concurrently, it reports whether one of
+// them has no CurseSlug.
+func (p *PkgMeta) fetchExternals(externals map[string]*external.ExternalEntry, packageDir string, forceExternals bool, lock *ExternalsLock, numOrigEmbeds int) (bool, error) {
+	externalLogger := logger.GetSubLog("EXT")
+
 	var checkoutWg sync.WaitGroup
-	checkoutErrChan := make(chan error, len(p.Externals))
+	checkoutErrChan := make(chan error, len(externals))
 
-	numOrigEmbeds := len(p.EmbeddedLibraries)
 	missingSlugEncountered := false
-	start := time.Now()
-	for path, entry := range p.Externals {
+	for path, entry := range externals {
 		// Capture the current loop variables.
 		currentEntry := entry
 		currentPath := path
@@ -101,7 +279,6 @@ func (p *PkgMeta) FetchExternals(packageDir string, forceExternals bool) error {
 		var err error
 		switch currentEntry.EType {
 		case external.Git:
-			checkoutWg.Add(1)
 			currentEntry.LogGroup.Info("📥 Processing external for %s", currentPath)
 			ext, err = external.NewGitExternal(currentEntry, forceExternals)
 			if err != nil {
@@ -111,7 +288,6 @@ func (p *PkgMeta) FetchExternals(packageDir string, forceExternals bool) error {
 				continue
 			}
 		case external.Svn:
-			checkoutWg.Add(1)
 			currentEntry.LogGroup.Info("📥 Processing external for %s", currentPath)
 			ext, err = external.NewSvnExternal(currentEntry, forceExternals)
 			if err != nil {
@@ -121,8 +297,14 @@ func (p *PkgMeta) FetchExternals(packageDir string, forceExternals bool) error {
 				continue
 			}
 		case external.Hg:
-			externalLogger.Warn("Mercurial externals are not supported yet for %s", currentPath)
-			continue
+			currentEntry.LogGroup.Info("📥 Processing external for %s", currentPath)
+			ext, err = external.NewHgExternal(currentEntry, forceExternals)
+			if err != nil {
+				currentEntry.LogGroup.Error("Failed to create hg external: %v", err)
+				currentEntry.LogGroup.Flush()
+				checkoutErrChan <- fmt.Errorf("failed to create hg external: %w", err)
+				continue
+			}
 		default:
 			externalLogger.Warn("Unknown external type %s for %s", currentEntry.EType.ToString(), currentPath)
 			continue
@@ -132,18 +314,68 @@ func (p *PkgMeta) FetchExternals(packageDir string, forceExternals bool) error {
 			continue
 		}
 
+		// What the pkgmeta file asks for, before pinning or the checkout resolve it
+		locked := LockedExternal{
+			Type: currentEntry.EType.ToString(),
+			URL:  currentEntry.URL,
+			Ref:  currentEntry.Ref(),
+		}
+		if lock != nil {
+			if err := lock.pin(currentPath, currentEntry); err != nil {
+				currentEntry.LogGroup.Error("Lock Error: %v", err)
+				currentEntry.LogGroup.Flush()
+				checkoutErrChan <- fmt.Errorf("failed to pin external: %w", err)
+				continue
+			}
+		}
+
 		// Pass the captured copy of currentEntry into the goroutine.
-		go func(ext external.Vcs, entry *external.ExternalEntry) {
+		checkoutWg.Add(1)
+		go func(ext external.Vcs, entry *external.ExternalEntry, path string, locked LockedExternal) {
 			defer entry.LogGroup.Flush()
 			defer checkoutWg.Done()
-			if err := ext.Checkout(); err != nil {
+
+			// Checkout may move RepoCacheDir to a subdirectory of the cache
+			cacheDir := entry.RepoCacheDir
+			_, cacheErr := os.Stat(cacheDir)
+
+			err := ext.Checkout()
+			// Verifying a cache reads all of it, so only a cache that failed to check out is
+			// verified, and fetched again when it's corrupted
+			if err != nil && cacheErr == nil {
+				if verifyErr := external.VerifyCache(cacheDir); verifyErr != nil {
+					entry.LogGroup.Warn("The cache of %s is corrupted, fetching it again: %v", path, verifyErr)
+					if err := os.RemoveAll(cacheDir); err != nil {
+						checkoutErrChan <- fmt.Errorf("failed to remove corrupted cache: %w", err)
+						return
+					}
+					entry.RepoCacheDir = cacheDir
+					err = ext.Checkout()
+				}
+			}
+			if err != nil {
 				checkoutErrChan <- fmt.Errorf("failed to checkout external: %w", err)
 				return
 			}
+			if lock != nil {
+				revision, err := ext.Revision()
+				if err != nil {
+					checkoutErrChan <- fmt.Errorf("failed to get revision of external: %w", err)
+					return
+				}
+				locked.Revision = revision
+				if err := lock.record(path, locked); err != nil {
+					checkoutErrChan <- fmt.Errorf("failed to lock external: %w", err)
+					return
+				}
+			}
 			if err := copyExternal(entry, packageDir); err != nil {
 				checkoutErrChan <- fmt.Errorf("failed to copy external: %w", err)
 				return
 			}
+			if err := entry.RecordCacheUse(cacheDir, filepath.Base(packageDir)); err != nil {
+				entry.LogGroup.Warn("%v", err)
+			}
 
 			if entry.CurseSlug != "" {
 				p.EmbeddedLibraries = append(p.EmbeddedLibraries, entry.CurseSlug)
@@ -156,7 +388,7 @@ func (p *PkgMeta) FetchExternals(packageDir string, forceExternals bool) error {
 					missingSlugEncountered = true
 				}
 			}
-		}(ext, currentEntry)
+		}(ext, currentEntry, currentPath, locked)
 	}
 
 	checkoutWg.Wait()
@@ -165,27 +397,11 @@ func (p *PkgMeta) FetchExternals(packageDir string, forceExternals bool) error {
 	// Collect errors
 	for err := range checkoutErrChan {
 		if err != nil {
-			return fmt.Errorf("error fetching externals: %v", err)
+			return missingSlugEncountered, fmt.Errorf("error fetching externals: %v", err)
 		}
 	}
 
-	externalLogger.Timing("All External dependencies fetched in %s", time.Since(start))
-
-	if missingSlugEncountered && len(p.EmbeddedLibraries) < len(p.Externals) {
-		externalLogger.Warn("CurseSlugs could not be determined for one or more externals above and it may not be specified in your pkgmeta embedded-libraries.")
-		externalLogger.Warn("Please ensure all external libraries have a CurseSlug or add them to the embedded-libraries list to support the hard work of the author(s).")
-	}
-
-	var uniqueEmbeds = make(map[string]bool)
-	for _, embed := range p.EmbeddedLibraries {
-		uniqueEmbeds[embed] = true
-	}
-	p.EmbeddedLibraries = make([]string, 0, len(uniqueEmbeds))
-	for embed := range uniqueEmbeds {
-		p.EmbeddedLibraries = append(p.EmbeddedLibraries, embed)
-	}
-
-	return nil
+	return missingSlugEncountered, nil
 }
 
 func (p *PkgMeta) GetNoLibDirs(pkgDir string) []string {
@@ -207,6 +423,13 @@ func defaultPkgMeta() *PkgMeta {
 	}
 }
 
+// NewPkgMeta returns the settings of an addon without a pkgmeta file.
+func NewPkgMeta() *PkgMeta {
+	pkgMeta := defaultPkgMeta()
+	pkgMeta.ToolsUsed = []string{"wow-build-tools"}
+	return pkgMeta
+}
+
 // ParsePkgMeta reads and parses the .pkgmeta or pkgmeta.yml file
 func parsePkgMeta(filename string) (*PkgMeta, error) {
 	data, err := os.ReadFile(filename)
@@ -1,23 +1,38 @@
 package pkg
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
+
+	"github.com/McTalian/wow-build-tools/internal/external"
+	"github.com/McTalian/wow-build-tools/internal/logger"
 )
 
 func TestPkgMeta_UnmarshalYAML(t *testing.T) {
 	yamlData := `
 package-as: test-package
 enable-nolib-creation: true
+enable-toc-creation: true
 required-dependencies:
   - dep1
   - dep2
 ignore:
   - ignore1
   - ignore2
+plain-copy:
+  - Libs/Data/*
+localization:
+  base-locale: enGB
+  files:
+    - Locales/*.json
 move-folders:
   src1: dest1
   src2: dest2
@@ -28,6 +43,11 @@ externals:
   ext2:
     type: svn
     url: https://example.com/repo2.svn
+externals-recursive: true
+packages:
+  - Core
+  - Plugins/Extra
+combine-packages: true
 
 wowi-archive-previous: false
 `
@@ -38,18 +58,141 @@ wowi-archive-previous: false
 
 	assert.Equal(t, "test-package", pkgMeta.PackageAs, "PackageAs mismatch")
 	assert.True(t, pkgMeta.EnableNoLibCreation, "Expected EnableNoLibCreation to be true")
+	assert.True(t, pkgMeta.EnableTocCreation, "Expected EnableTocCreation to be true")
 	assert.Len(t, pkgMeta.RequiredDependencies, 2, "Expected 2 RequiredDependencies")
 	assert.Equal(t, "dep1", pkgMeta.RequiredDependencies[0], "RequiredDependencies[0] mismatch")
 	assert.Equal(t, "dep2", pkgMeta.RequiredDependencies[1], "RequiredDependencies[1] mismatch")
 	assert.Len(t, pkgMeta.Ignore, 2, "Expected 2 Ignore")
 	assert.Equal(t, "ignore1", pkgMeta.Ignore[0], "Ignore[0] mismatch")
 	assert.Equal(t, "ignore2", pkgMeta.Ignore[1], "Ignore[1] mismatch")
+	assert.Equal(t, []string{"Libs/Data/*"}, pkgMeta.PlainCopy, "PlainCopy mismatch")
+	assert.Equal(t, PkgMetaLocalization{BaseLocale: "enGB", Files: []string{"Locales/*.json"}}, pkgMeta.Localization, "Localization mismatch")
 	assert.Len(t, pkgMeta.MoveFolders, 2, "Expected 2 MoveFolders")
 	assert.Equal(t, "dest1", pkgMeta.MoveFolders["src1"], "MoveFolders[src1] mismatch")
 	assert.Equal(t, "dest2", pkgMeta.MoveFolders["src2"], "MoveFolders[src2] mismatch")
 	assert.Len(t, pkgMeta.Externals, 2, "Expected 2 Externals")
+	assert.True(t, pkgMeta.ExternalsRecursive, "Expected ExternalsRecursive to be true")
+	assert.Equal(t, []string{"Core", "Plugins/Extra"}, pkgMeta.Packages, "Packages mismatch")
+	assert.True(t, pkgMeta.CombinePackages, "Expected CombinePackages to be true")
 	assert.True(t, pkgMeta.ManualChangelog.MarkupType == "text", "Expected ManualChangelog.MarkupType to be 'text'")
 	assert.False(t, pkgMeta.WowiArchivePrevious, "Expected WowiArchivePrevious to be false")
 	assert.True(t, pkgMeta.WowiConvertChangelog, "Expected WowiConvertChangelog to be true")
 	assert.True(t, pkgMeta.WowiCreateChangelog, "Expected WowiCreateChangelog to be true")
 }
+
+func TestPkgMeta_IsPlainCopy(t *testing.T) {
+	pkgMeta := &PkgMeta{PlainCopy: []string{"Libs/Data/*", "*.dat", "Media/Fonts"}}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"Libs/Data/Strings.lua", true},
+		{"Libs/Data/Nested/Strings.lua", true},
+		{"Libs/LibStub/LibStub.lua", false},
+		{"Locales/enUS.dat", true},
+		{"Media/Fonts/Font.lua", true},
+		{"Media/Textures/Icon.lua", false},
+		{"Project.lua", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, pkgMeta.IsPlainCopy(filepath.FromSlash(tt.path)), tt.path)
+	}
+
+	assert.False(t, (&PkgMeta{}).IsPlainCopy("Project.lua"))
+}
+
+func TestNestedExternals(t *testing.T) {
+	// newParent returns an external whose cache has the given pkgmeta file
+	newParent := func(url string, pkgmeta string) *external.ExternalEntry {
+		dir := t.TempDir()
+		if pkgmeta != "" {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, ".pkgmeta"), []byte(pkgmeta), 0644))
+		}
+		return &external.ExternalEntry{URL: url, EType: external.Git, RepoCacheDir: dir}
+	}
+
+	parents := map[string]*external.ExternalEntry{
+		"Libs/AceGUI-3.0": newParent("https://github.com/example/AceGUI", "externals:\n"+
+			"  LibStub: https://github.com/example/LibStub\n"+
+			"  CallbackHandler:\n"+
+			"    url: https://github.com/example/CallbackHandler\n"+
+			"    tag: 1.0.0\n"+
+			"  Self: https://github.com/example/AceGUI\n"),
+		"Libs/AceConfig-3.0": newParent("https://github.com/example/AceConfig", "externals:\n"+
+			"  ../AceGUI-3.0/LibStub:\n"+
+			"    url: https://github.com/example/LibStub\n"+
+			"    tag: 2.0.0\n"),
+		"Libs/LibFoo": newParent("https://github.com/example/LibFoo", ""),
+	}
+	requested := make(map[string]LockedExternal)
+	addRequested(requested, parents)
+	requested["Libs/AceGUI-3.0/CallbackHandler"] = LockedExternal{Type: "git", URL: "https://github.com/example/CallbackHandler", Ref: "tag:1.0.0"}
+
+	nested, err := nestedExternals(parents, requested, logger.GetSubLog("EXT"))
+	require.NoError(t, err)
+	require.Len(t, nested, 1)
+	// The first external to need Libs/AceGUI-3.0/LibStub gets it, the nested external itself is skipped
+	require.Contains(t, nested, "Libs/AceGUI-3.0/LibStub")
+	assert.Equal(t, "https://github.com/example/LibStub", nested["Libs/AceGUI-3.0/LibStub"].URL)
+	assert.Equal(t, "tag:2.0.0", nested["Libs/AceGUI-3.0/LibStub"].Ref())
+	assert.Equal(t, "Libs/AceGUI-3.0/LibStub", nested["Libs/AceGUI-3.0/LibStub"].DestPath)
+
+	_, err = nestedExternals(map[string]*external.ExternalEntry{"Libs/Broken": newParent("https://github.com/example/Broken", "externals: [")}, requested, logger.GetSubLog("EXT"))
+	assert.Error(t, err)
+}
+
+func TestFetchExternals_MissingHg(t *testing.T) {
+	// No hg on the PATH
+	t.Setenv("PATH", t.TempDir())
+
+	externals := map[string]*external.ExternalEntry{
+		"Libs/LibFoo": {URL: "https://hg.example.com/LibFoo", EType: external.Hg, RepoCacheDir: t.TempDir()},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := (&PkgMeta{}).fetchExternals(externals, t.TempDir(), false, nil, 0)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorContains(t, err, "hg is not installed")
+	case <-time.After(10 * time.Second):
+		t.Fatal("fetching the externals did not return")
+	}
+}
+
+func TestFetchExternals_CorruptedCache(t *testing.T) {
+	upstream := t.TempDir()
+	r, err := git.PlainInit(upstream, false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(upstream, "LibFoo.lua"), []byte("local version = 1\n"), 0644))
+	w, err := r.Worktree()
+	require.NoError(t, err)
+	_, err = w.Add("LibFoo.lua")
+	require.NoError(t, err)
+	_, err = w.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)},
+	})
+	require.NoError(t, err)
+
+	// A cache that isn't a checkout fails to check out, and is fetched again
+	cacheDir := filepath.Join(t.TempDir(), "LibFoo")
+	require.NoError(t, os.MkdirAll(cacheDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "LibFoo.lua"), []byte("garbage"), 0644))
+
+	externals := map[string]*external.ExternalEntry{
+		"Libs/LibFoo": {URL: upstream, EType: external.Git, DestPath: "Libs/LibFoo", RepoCacheDir: cacheDir},
+	}
+	packageDir := t.TempDir()
+	_, err = (&PkgMeta{}).fetchExternals(externals, packageDir, false, nil, 0)
+	require.NoError(t, err)
+
+	contents, err := os.ReadFile(filepath.Join(packageDir, "Libs", "LibFoo", "LibFoo.lua"))
+	require.NoError(t, err)
+	assert.Equal(t, "local version = 1\n", string(contents))
+	assert.DirExists(t, filepath.Join(cacheDir, ".git"))
+}
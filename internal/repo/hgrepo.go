@@ -0,0 +1,398 @@
+package repo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+)
+
+// hgCommitTemplate is read by parseHgCommit.
+const hgCommitTemplate = "{node}\\n{rev}\\n{author|person}\\n{date|hgdate}\\n"
+
+// hgChangelogSeparator separates the commit messages of the changelog.
+const hgChangelogSeparator = "\x1e"
+
+// HgRepo implements VcsRepo for Mercurial repositories by shelling out to `hg`.
+type HgRepo struct {
+	BaseVcsRepo
+	repo         *Repo
+	commit       hgCommit
+	trackedFiles map[string]bool
+	trackedDirs  map[string]bool
+	previousNode string
+}
+
+type hgCommit struct {
+	Node      string
+	Rev       int
+	Author    string
+	Timestamp int64
+}
+
+// parseHgCommit reads the output of `hg log` with hgCommitTemplate.
+func parseHgCommit(output string) (hgCommit, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 4 {
+		return hgCommit{}, fmt.Errorf("unexpected hg log output: %q", output)
+	}
+
+	rev, err := strconv.Atoi(lines[1])
+	if err != nil {
+		return hgCommit{}, fmt.Errorf("invalid revision %s: %v", lines[1], err)
+	}
+	if rev < 0 {
+		return hgCommit{}, fmt.Errorf("the repository has no commits")
+	}
+
+	// hgdate is "<unix timestamp> <timezone offset>"
+	timestamp, err := strconv.ParseInt(strings.Fields(lines[3])[0], 10, 64)
+	if err != nil {
+		return hgCommit{}, fmt.Errorf("invalid date %s: %v", lines[3], err)
+	}
+
+	return hgCommit{
+		Node:      lines[0],
+		Rev:       rev,
+		Author:    lines[2],
+		Timestamp: timestamp,
+	}, nil
+}
+
+func (c hgCommit) AbbrevHash() string {
+	if len(c.Node) >= 7 {
+		return c.Node[:7]
+	}
+	return c.Node
+}
+
+func (hR *HgRepo) hg(args ...string) (string, error) {
+	cmd := exec.Command("hg", args...)
+	cmd.Dir = hR.repo.GetRepoRoot()
+	// Ignore user settings that change the output, e.g. aliases or localization
+	cmd.Env = append(os.Environ(), "HGPLAIN=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("hg %s failed: %w, output: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(output), nil
+}
+
+func (hR *HgRepo) GetRepoRoot() string {
+	return hR.repo.GetRepoRoot()
+}
+
+func (hR *HgRepo) GetCurrentTag() string {
+	return hR.CurrentTag
+}
+
+func (hR *HgRepo) GetPreviousVersion() string {
+	return hR.PreviousVersion
+}
+
+func (hR *HgRepo) GetProjectVersion() string {
+	return hR.ProjectVersion
+}
+
+// getTrackedFiles reads the files Mercurial tracks, anything else is untracked or matches .hgignore.
+func (hR *HgRepo) getTrackedFiles() error {
+	output, err := hR.hg("files", "--template", "{path}\\n")
+	// hg files exits with 1 when there are no files
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return err
+	}
+
+	hR.trackedFiles = make(map[string]bool)
+	hR.trackedDirs = make(map[string]bool)
+	for _, file := range strings.Split(strings.TrimSpace(output), "\n") {
+		if file == "" {
+			continue
+		}
+		hR.trackedFiles[file] = true
+		for dir := filepath.ToSlash(filepath.Dir(file)); dir != "."; dir = filepath.ToSlash(filepath.Dir(dir)) {
+			hR.trackedDirs[dir] = true
+		}
+	}
+
+	logger.Verbose("Found %d tracked files", len(hR.trackedFiles))
+	return nil
+}
+
+// IsIgnored reports whether the path is left out of the package, i.e. Mercurial doesn't track it
+// because it matches .hgignore or hasn't been added. Directories are ignored when they don't
+// hold any tracked files.
+func (hR *HgRepo) IsIgnored(path string, isDir bool) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absRoot, err := filepath.Abs(hR.repo.GetRepoRoot())
+	if err != nil {
+		return false
+	}
+	relPath, err := filepath.Rel(absRoot, absPath)
+	if err != nil || relPath == "." || strings.HasPrefix(relPath, "..") {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	if isDir {
+		return !hR.trackedDirs[relPath]
+	}
+	return !hR.trackedFiles[relPath]
+}
+
+func (hR *HgRepo) populateCommitInfo() error {
+	output, err := hR.hg("log", "--rev", ".", "--template", hgCommitTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to get the current revision: %w", err)
+	}
+
+	hR.commit, err = parseHgCommit(output)
+	return err
+}
+
+// hgTaggedRev is a revision with one or more tags.
+type hgTaggedRev struct {
+	Node string
+	Tags []string
+}
+
+// parseHgTaggedRevs reads lines of "<node> <tags...>", the `tip` pseudo-tag is dropped.
+func parseHgTaggedRevs(output string) []hgTaggedRev {
+	var revs []hgTaggedRev
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		tags := slices.DeleteFunc(fields[1:], func(tag string) bool { return tag == "tip" })
+		if len(tags) == 0 {
+			continue
+		}
+		sortTagNames(tags)
+		revs = append(revs, hgTaggedRev{Node: fields[0], Tags: tags})
+	}
+	return revs
+}
+
+// sortTagNames sorts tags of the same revision, newest version first.
+func sortTagNames(tags []string) {
+	slices.SortFunc(tags, func(a, b string) int {
+		verA, errA := version.NewVersion(strings.TrimPrefix(a, "v"))
+		verB, errB := version.NewVersion(strings.TrimPrefix(b, "v"))
+		if errA == nil && errB == nil {
+			return verB.Compare(verA)
+		}
+		return strings.Compare(b, a)
+	})
+}
+
+func isPreReleaseTag(tag string) bool {
+	return strings.Contains(tag, "alpha") || strings.Contains(tag, "beta")
+}
+
+// latestReleaseTag returns the newest tag that isn't an alpha or beta.
+func latestReleaseTag(revs []hgTaggedRev) (string, string) {
+	for _, rev := range revs {
+		for _, tag := range rev.Tags {
+			if !isPreReleaseTag(tag) {
+				return tag, rev.Node
+			}
+		}
+	}
+	return "", ""
+}
+
+// commitsSince counts the commits between node and the current revision, leaving out the ones
+// that only add tags since `hg tag` commits .hgtags after the tagged revision.
+func (hR *HgRepo) commitsSince(node string) (int, error) {
+	output, err := hR.hg("log", "--rev", fmt.Sprintf("only(., %s)", node), "--template", "{files}\\n")
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits: %w", err)
+	}
+	count := 0
+	for _, files := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		if files != "" && files != ".hgtags" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// getProjectTag returns the version (tag7) and the tag it's based on (tag0), they're the same
+// when the current revision is tagged.
+func (hR *HgRepo) getProjectTag() (tag7 string, tag0 string, err error) {
+	output, err := hR.hg("log", "--rev", "reverse(ancestors(.) and tag())", "--template", "{node} {tags}\\n")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get tags: %w", err)
+	}
+	revs := parseHgTaggedRevs(output)
+
+	if len(revs) > 0 {
+		count, err := hR.commitsSince(revs[0].Node)
+		if err != nil {
+			return "", "", err
+		}
+		if count == 0 {
+			tag7 = revs[0].Tags[0]
+			hR.PreviousVersion, hR.previousNode = latestReleaseTag(revs[1:])
+			return tag7, tag7, nil
+		}
+	}
+
+	latestTag, latestNode := latestReleaseTag(revs)
+	if latestTag == "" {
+		tag0 = hR.commit.AbbrevHash()
+		return tag0, tag0, nil
+	}
+
+	count, err := hR.commitsSince(latestNode)
+	if err != nil {
+		return "", "", err
+	}
+
+	tag7 = fmt.Sprintf("%s-%d-h%s", latestTag, count, hR.commit.AbbrevHash())
+	hR.PreviousVersion = latestTag
+	hR.previousNode = latestNode
+
+	return tag7, latestTag, nil
+}
+
+func (hR *HgRepo) GetInjectionValues(stm *tokens.SimpleTokenMap) error {
+	stm.Add(tokens.ProjectHash, hR.commit.Node)
+	stm.Add(tokens.ProjectAbbrevHash, hR.commit.AbbrevHash())
+	stm.Add(tokens.ProjectAuthor, hR.commit.Author)
+
+	stm.Add(tokens.ProjectTimestamp, strconv.FormatInt(hR.commit.Timestamp, 10))
+	t := time.Unix(hR.commit.Timestamp, 0).UTC()
+	stm.Add(tokens.ProjectDateIso, t.Format("2006-01-02T15:04:05Z"))
+	stm.Add(tokens.ProjectDateInteger, t.Format("20060102150405"))
+
+	// Revision numbers start at 0, count the commits like GitRepo does
+	stm.Add(tokens.ProjectRevision, strconv.Itoa(hR.commit.Rev+1))
+
+	tag7, tag0, err := hR.getProjectTag()
+	if err != nil {
+		return err
+	}
+	stm.Add(tokens.ProjectVersion, tag7)
+	if tag7 == tag0 {
+		hR.CurrentTag = tag0
+	}
+	hR.ProjectVersion = tag7
+
+	return nil
+}
+
+func (hR *HgRepo) GetFileInjectionValues(filePath string) (*tokens.SimpleTokenMap, error) {
+	logger.Verbose("Getting file injection values for %s", filePath)
+	stm := &tokens.SimpleTokenMap{}
+
+	output, err := hR.hg("log", "--limit", "1", "--template", hgCommitTemplate, "--", filepath.ToSlash(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit log: %w", err)
+	}
+
+	if strings.TrimSpace(output) == "" {
+		logger.Warn("No prior commits found for %s, but file tokens were present. Using empty strings", filePath)
+		stm.Add(tokens.FileAuthor, "")
+		stm.Add(tokens.FileTimestamp, "")
+		stm.Add(tokens.FileDateIso, "")
+		stm.Add(tokens.FileDateInteger, "")
+		stm.Add(tokens.FileHash, "")
+		stm.Add(tokens.FileAbbrevHash, "")
+		stm.Add(tokens.FileRevision, "")
+		return stm, nil
+	}
+
+	commit, err := parseHgCommit(output)
+	if err != nil {
+		return nil, err
+	}
+
+	stm.Add(tokens.FileAuthor, commit.Author)
+	stm.Add(tokens.FileTimestamp, strconv.FormatInt(commit.Timestamp, 10))
+	t := time.Unix(commit.Timestamp, 0).UTC()
+	stm.Add(tokens.FileDateIso, t.Format("2006-01-02T15:04:05Z"))
+	stm.Add(tokens.FileDateInteger, t.Format("20060102150405"))
+	stm.Add(tokens.FileHash, commit.Node)
+	stm.Add(tokens.FileAbbrevHash, commit.AbbrevHash())
+	stm.Add(tokens.FileRevision, strconv.Itoa(commit.Rev+1))
+
+	return stm, nil
+}
+
+// formatHgChangelog writes the commit messages, separated by hgChangelogSeparator, as a list.
+func formatHgChangelog(output string) string {
+	var changelog strings.Builder
+	for _, message := range strings.Split(output, hgChangelogSeparator) {
+		message = strings.TrimSpace(message)
+		if message == "" {
+			continue
+		}
+
+		if strings.HasPrefix(message, "Backed out changeset") || strings.HasPrefix(message, "Added tag ") {
+			continue
+		}
+
+		message = strings.ReplaceAll(message, "_", "\\_")
+		message = strings.ReplaceAll(message, "[ci skip]", "")
+		message = strings.ReplaceAll(message, "[skip ci]", "")
+
+		changelog.WriteString(fmt.Sprintf("- %s  \n", strings.TrimSpace(message)))
+	}
+	return changelog.String()
+}
+
+// GetChangelog lists the commits since the previous version, from `hg log`.
+func (hR *HgRepo) GetChangelog(title string) (string, error) {
+	revset := "reverse(::.)"
+	if hR.previousNode != "" {
+		revset = fmt.Sprintf("reverse(only(., %s))", hR.previousNode)
+	}
+
+	output, err := hR.hg("log", "--rev", revset, "--template", "{desc}"+hgChangelogSeparator)
+	if err != nil {
+		return "", fmt.Errorf("failed to get commit log: %w", err)
+	}
+
+	var changelog strings.Builder
+	changelog.WriteString(fmt.Sprintf("# %s\n\n", title))
+	changelogDate := time.Unix(hR.commit.Timestamp, 0).UTC().Format("2006-01-02")
+	changelog.WriteString(fmt.Sprintf("## %s (%s)\n\n", hR.ProjectVersion, changelogDate))
+	changelog.WriteString(formatHgChangelog(output))
+
+	return changelog.String(), nil
+}
+
+func NewHgRepo(r *Repo) (*HgRepo, error) {
+	if _, err := exec.LookPath("hg"); err != nil {
+		return nil, fmt.Errorf("hg is not installed")
+	}
+
+	hR := HgRepo{repo: r}
+
+	if err := hR.populateCommitInfo(); err != nil {
+		return nil, err
+	}
+	if err := hR.getTrackedFiles(); err != nil {
+		return nil, fmt.Errorf("failed to get tracked files: %w", err)
+	}
+
+	return &hR, nil
+}
@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/McTalian/wow-build-tools/internal/external"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHgCommit(t *testing.T) {
+	commit, err := parseHgCommit("0123456789abcdef\n4\nJane Doe\n1735689600 -3600\n")
+	require.NoError(t, err)
+	assert.Equal(t, hgCommit{Node: "0123456789abcdef", Rev: 4, Author: "Jane Doe", Timestamp: 1735689600}, commit)
+	assert.Equal(t, "0123456", commit.AbbrevHash())
+
+	_, err = parseHgCommit("0000000000000000\n-1\n\n0 0\n")
+	assert.Error(t, err, "Expected an error for an empty repository")
+
+	_, err = parseHgCommit("unexpected")
+	assert.Error(t, err)
+}
+
+func TestParseHgTaggedRevs(t *testing.T) {
+	revs := parseHgTaggedRevs("ccc tip\nbbb v1.2.0-beta v1.10.0 v1.9.0\naaa 1.0.0\n")
+	assert.Equal(t, []hgTaggedRev{
+		{Node: "bbb", Tags: []string{"v1.10.0", "v1.9.0", "v1.2.0-beta"}},
+		{Node: "aaa", Tags: []string{"1.0.0"}},
+	}, revs)
+
+	tag, node := latestReleaseTag([]hgTaggedRev{{Node: "ccc", Tags: []string{"2.0.0-alpha"}}, revs[1]})
+	assert.Equal(t, "1.0.0", tag)
+	assert.Equal(t, "aaa", node)
+}
+
+func TestFormatHgChangelog(t *testing.T) {
+	output := "Fix the_thing [ci skip]\x1eAdded tag 1.0.0 for changeset abc\x1e\x1eBacked out changeset def\x1eAdd options\nwith details\x1e"
+	assert.Equal(t, "- Fix the\\_thing  \n- Add options\nwith details  \n", formatHgChangelog(output))
+}
+
+func TestHgRepo_IsIgnored(t *testing.T) {
+	root := t.TempDir()
+	hR := &HgRepo{
+		repo:         &Repo{repoRoot: root, repoVcsType: external.Hg},
+		trackedFiles: map[string]bool{"Project.toc": true, "Libs/LibStub/LibStub.lua": true},
+		trackedDirs:  map[string]bool{"Libs": true, "Libs/LibStub": true},
+	}
+
+	assert.False(t, hR.IsIgnored(filepath.Join(root, "Project.toc"), false))
+	assert.False(t, hR.IsIgnored(filepath.Join(root, "Libs", "LibStub"), true))
+	assert.False(t, hR.IsIgnored(filepath.Join(root, "Libs", "LibStub", "LibStub.lua"), false))
+	assert.True(t, hR.IsIgnored(filepath.Join(root, "Notes.txt"), false))
+	assert.True(t, hR.IsIgnored(filepath.Join(root, "Build"), true))
+}
+
+func hgCommand(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("hg", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "HGPLAIN=1", "HGUSER=Jane Doe <jane@example.com>")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func TestHgRepo(t *testing.T) {
+	if _, err := exec.LookPath("hg"); err != nil {
+		t.Skip("hg is not installed")
+	}
+
+	dir := t.TempDir()
+	hgCommand(t, dir, "init")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hgignore"), []byte("syntax: glob\n*.log\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Project.toc"), []byte("## Title: Project\n"), 0644))
+	hgCommand(t, dir, "add", ".hgignore", "Project.toc")
+	hgCommand(t, dir, "commit", "-m", "Initial commit", "-d", "1735689600 0")
+	hgCommand(t, dir, "tag", "-d", "1735689600 0", "1.0.0")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Project.lua"), []byte("-- @file-author@\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "debug.log"), []byte("log\n"), 0644))
+	hgCommand(t, dir, "add", "Project.lua")
+	hgCommand(t, dir, "commit", "-m", "Add Project.lua", "-d", "1735776000 0")
+
+	r, err := NewRepo(dir)
+	require.NoError(t, err)
+	require.Equal(t, external.Hg, r.GetVcsType())
+
+	hR, err := NewHgRepo(r)
+	require.NoError(t, err)
+
+	assert.False(t, hR.IsIgnored(filepath.Join(dir, "Project.lua"), false))
+	assert.True(t, hR.IsIgnored(filepath.Join(dir, "debug.log"), false))
+
+	stm := &tokens.SimpleTokenMap{}
+	require.NoError(t, hR.GetInjectionValues(stm))
+	assert.Equal(t, "Jane Doe", (*stm)[tokens.ProjectAuthor])
+	assert.Equal(t, "3", (*stm)[tokens.ProjectRevision])
+	assert.Equal(t, "1735776000", (*stm)[tokens.ProjectTimestamp])
+	assert.Regexp(t, `^1\.0\.0-1-h[0-9a-f]{7}$`, (*stm)[tokens.ProjectVersion])
+	assert.Equal(t, "1.0.0", hR.GetPreviousVersion())
+	assert.Empty(t, hR.GetCurrentTag())
+
+	fileStm, err := hR.GetFileInjectionValues("Project.lua")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", (*fileStm)[tokens.FileAuthor])
+	assert.Equal(t, "3", (*fileStm)[tokens.FileRevision])
+
+	changelog, err := hR.GetChangelog("Project")
+	require.NoError(t, err)
+	assert.Contains(t, changelog, "- Add Project.lua")
+	assert.NotContains(t, changelog, "Initial commit")
+
+	hgCommand(t, dir, "tag", "-d", "1735776000 0", "1.1.0")
+	hR, err = NewHgRepo(r)
+	require.NoError(t, err)
+	require.NoError(t, hR.GetInjectionValues(stm))
+	assert.Equal(t, "1.1.0", hR.GetCurrentTag())
+	assert.Equal(t, "1.0.0", hR.GetPreviousVersion())
+}
@@ -1,32 +1,128 @@
 package repo
 
 import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/McTalian/wow-build-tools/internal/logger"
 	"github.com/McTalian/wow-build-tools/internal/tokens"
 )
 
+// SvnRepo implements VcsRepo for SVN working copies by shelling out to `svn`.
 type SvnRepo struct {
 	BaseVcsRepo
-	repo *Repo
+	repo         *Repo
+	info         svnInfoEntry
+	ignoredPaths []string
+	previousRev  int
 }
 
-func (sR *SvnRepo) IsIgnored(path string, isDir bool) bool {
-	return false
+type svnCommit struct {
+	Revision int    `xml:"revision,attr"`
+	Author   string `xml:"author"`
+	Date     string `xml:"date"`
 }
 
-func (sR *SvnRepo) GetInjectionValues(stm *tokens.SimpleTokenMap) error {
-	return nil
+func (c svnCommit) timestamp() (int64, error) {
+	if c.Date == "" {
+		return 0, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, c.Date)
+	if err != nil {
+		return 0, fmt.Errorf("invalid svn date %s: %v", c.Date, err)
+	}
+	return t.Unix(), nil
 }
 
-func (sR *SvnRepo) GetFileInjectionValues(filePath string) (*tokens.SimpleTokenMap, error) {
-	return nil, nil
+type svnInfoEntry struct {
+	Revision    int       `xml:"revision,attr"`
+	Url         string    `xml:"url"`
+	RelativeUrl string    `xml:"relative-url"`
+	RootUrl     string    `xml:"repository>root"`
+	Commit      svnCommit `xml:"commit"`
 }
 
-func (sR *SvnRepo) GetRepoRoot() string {
-	return sR.repo.GetRepoRoot()
+type svnInfo struct {
+	Entries []svnInfoEntry `xml:"entry"`
 }
 
-func (sR *SvnRepo) GetChangelog(title string) (string, error) {
-	return "", nil
+type svnStatus struct {
+	Entries []struct {
+		Path     string `xml:"path,attr"`
+		WcStatus struct {
+			Item string `xml:"item,attr"`
+		} `xml:"wc-status"`
+	} `xml:"target>entry"`
+}
+
+type svnList struct {
+	Entries []struct {
+		Kind   string    `xml:"kind,attr"`
+		Name   string    `xml:"name"`
+		Commit svnCommit `xml:"commit"`
+	} `xml:"list>entry"`
+}
+
+type svnLog struct {
+	Entries []struct {
+		Revision int    `xml:"revision,attr"`
+		Message  string `xml:"msg"`
+	} `xml:"logentry"`
+}
+
+func (sR *SvnRepo) svn(args ...string) ([]byte, error) {
+	cmd := exec.Command("svn", append([]string{"--non-interactive"}, args...)...)
+	cmd.Dir = sR.repo.GetRepoRoot()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("svn %s failed: %w, output: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+func parseSvnInfo(output []byte) (svnInfoEntry, error) {
+	var info svnInfo
+	if err := xml.Unmarshal(output, &info); err != nil {
+		return svnInfoEntry{}, fmt.Errorf("failed to parse svn info: %v", err)
+	}
+	if len(info.Entries) == 0 {
+		return svnInfoEntry{}, fmt.Errorf("svn info returned no entries")
+	}
+	return info.Entries[0], nil
+}
+
+// tagFromUrl returns the tag of a working copy checked out from `.../tags/<tag>`.
+func tagFromUrl(url string) string {
+	_, tag, found := strings.Cut(url, "/tags/")
+	if !found {
+		return ""
+	}
+	tag, _, _ = strings.Cut(tag, "/")
+	return tag
+}
+
+// projectUrl returns the URL of the project the working copy is in, i.e. the parent of trunk,
+// tags and branches, or "" when the repository doesn't use that layout.
+func projectUrl(url string) string {
+	for _, dir := range []string{"/trunk", "/tags/", "/branches/"} {
+		if i := strings.Index(url, dir); i != -1 && (dir != "/trunk" || i+len(dir) == len(url) || url[i+len(dir)] == '/') {
+			return url[:i]
+		}
+	}
+	return ""
+}
+
+func (sR *SvnRepo) GetRepoRoot() string {
+	return sR.repo.GetRepoRoot()
 }
 
 func (sR *SvnRepo) GetCurrentTag() string {
@@ -41,8 +137,237 @@ func (sR *SvnRepo) GetProjectVersion() string {
 	return sR.ProjectVersion
 }
 
+// parseSvnIgnores returns the paths `svn status` reports as unversioned or ignored, which covers
+// `svn:ignore`, `svn:global-ignores` and the `global-ignores` setting of the client.
+func parseSvnIgnores(output []byte) ([]string, error) {
+	var status svnStatus
+	if err := xml.Unmarshal(output, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse svn status: %v", err)
+	}
+
+	var ignored []string
+	for _, entry := range status.Entries {
+		if item := entry.WcStatus.Item; item == "ignored" || item == "unversioned" {
+			ignored = append(ignored, filepath.ToSlash(entry.Path))
+		}
+	}
+	return ignored, nil
+}
+
+func (sR *SvnRepo) getIgnores() error {
+	output, err := sR.svn("status", "--xml", "--no-ignore")
+	if err != nil {
+		return err
+	}
+	sR.ignoredPaths, err = parseSvnIgnores(output)
+	if err != nil {
+		return err
+	}
+	logger.Verbose("Found %d unversioned or ignored paths", len(sR.ignoredPaths))
+	return nil
+}
+
+// IsIgnored reports whether the path, or a directory it's in, is unversioned or ignored.
+func (sR *SvnRepo) IsIgnored(path string, isDir bool) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absRoot, err := filepath.Abs(sR.repo.GetRepoRoot())
+	if err != nil {
+		return false
+	}
+	relPath, err := filepath.Rel(absRoot, absPath)
+	if err != nil || relPath == "." || strings.HasPrefix(relPath, "..") {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	return slices.ContainsFunc(sR.ignoredPaths, func(ignored string) bool {
+		return relPath == ignored || strings.HasPrefix(relPath, ignored+"/")
+	})
+}
+
+// latestSvnTag returns the newest tag, by commit revision, before the revision that isn't an
+// alpha or beta.
+func latestSvnTag(output []byte, before int, exclude string) (string, int, error) {
+	var list svnList
+	if err := xml.Unmarshal(output, &list); err != nil {
+		return "", 0, fmt.Errorf("failed to parse svn list: %v", err)
+	}
+
+	tag, rev := "", 0
+	for _, entry := range list.Entries {
+		if entry.Kind != "dir" || entry.Name == exclude || isPreReleaseTag(entry.Name) {
+			continue
+		}
+		if entry.Commit.Revision < before && entry.Commit.Revision > rev {
+			tag, rev = entry.Name, entry.Commit.Revision
+		}
+	}
+	return tag, rev, nil
+}
+
+func (sR *SvnRepo) getPreviousVersion() error {
+	project := projectUrl(sR.info.Url)
+	if project == "" {
+		logger.Verbose("No trunk, tags or branches in %s, skipping the previous version", sR.info.Url)
+		return nil
+	}
+
+	output, err := sR.svn("list", "--xml", project+"/tags")
+	if err != nil {
+		logger.Verbose("Unable to list tags: %v", err)
+		return nil
+	}
+
+	sR.PreviousVersion, sR.previousRev, err = latestSvnTag(output, sR.info.Commit.Revision, sR.CurrentTag)
+	return err
+}
+
+func (sR *SvnRepo) GetInjectionValues(stm *tokens.SimpleTokenMap) error {
+	commit := sR.info.Commit
+	revision := strconv.Itoa(commit.Revision)
+
+	// SVN has no hashes, the revision takes their place
+	stm.Add(tokens.ProjectHash, revision)
+	stm.Add(tokens.ProjectAbbrevHash, revision)
+	stm.Add(tokens.ProjectAuthor, commit.Author)
+	stm.Add(tokens.ProjectRevision, revision)
+
+	timestamp, err := commit.timestamp()
+	if err != nil {
+		return err
+	}
+	stm.Add(tokens.ProjectTimestamp, strconv.FormatInt(timestamp, 10))
+	t := time.Unix(timestamp, 0).UTC()
+	stm.Add(tokens.ProjectDateIso, t.Format("2006-01-02T15:04:05Z"))
+	stm.Add(tokens.ProjectDateInteger, t.Format("20060102150405"))
+
+	sR.CurrentTag = tagFromUrl(sR.info.Url)
+	if err := sR.getPreviousVersion(); err != nil {
+		return err
+	}
+
+	sR.ProjectVersion = sR.CurrentTag
+	if sR.ProjectVersion == "" {
+		sR.ProjectVersion = "r" + revision
+	}
+	stm.Add(tokens.ProjectVersion, sR.ProjectVersion)
+
+	return nil
+}
+
+func (sR *SvnRepo) GetFileInjectionValues(filePath string) (*tokens.SimpleTokenMap, error) {
+	logger.Verbose("Getting file injection values for %s", filePath)
+	stm := &tokens.SimpleTokenMap{}
+
+	output, err := sR.svn("info", "--xml", filePath)
+	var info svnInfoEntry
+	if err == nil {
+		info, err = parseSvnInfo(output)
+	}
+	if err != nil || info.Commit.Revision == 0 {
+		logger.Warn("No prior commits found for %s, but file tokens were present. Using empty strings", filePath)
+		stm.Add(tokens.FileAuthor, "")
+		stm.Add(tokens.FileTimestamp, "")
+		stm.Add(tokens.FileDateIso, "")
+		stm.Add(tokens.FileDateInteger, "")
+		stm.Add(tokens.FileHash, "")
+		stm.Add(tokens.FileAbbrevHash, "")
+		stm.Add(tokens.FileRevision, "")
+		return stm, nil
+	}
+
+	timestamp, err := info.Commit.timestamp()
+	if err != nil {
+		return nil, err
+	}
+	revision := strconv.Itoa(info.Commit.Revision)
+
+	stm.Add(tokens.FileAuthor, info.Commit.Author)
+	stm.Add(tokens.FileTimestamp, strconv.FormatInt(timestamp, 10))
+	t := time.Unix(timestamp, 0).UTC()
+	stm.Add(tokens.FileDateIso, t.Format("2006-01-02T15:04:05Z"))
+	stm.Add(tokens.FileDateInteger, t.Format("20060102150405"))
+	stm.Add(tokens.FileHash, revision)
+	stm.Add(tokens.FileAbbrevHash, revision)
+	stm.Add(tokens.FileRevision, revision)
+
+	return stm, nil
+}
+
+// formatSvnChangelog lists the messages of `svn log --xml`.
+func formatSvnChangelog(output []byte) (string, error) {
+	var log svnLog
+	if err := xml.Unmarshal(output, &log); err != nil {
+		return "", fmt.Errorf("failed to parse svn log: %v", err)
+	}
+
+	var changelog strings.Builder
+	for _, entry := range log.Entries {
+		message := strings.TrimSpace(entry.Message)
+		if message == "" {
+			continue
+		}
+
+		message = strings.ReplaceAll(message, "_", "\\_")
+		message = strings.ReplaceAll(message, "[ci skip]", "")
+		message = strings.ReplaceAll(message, "[skip ci]", "")
+
+		changelog.WriteString(fmt.Sprintf("- %s  \n", strings.TrimSpace(message)))
+	}
+	return changelog.String(), nil
+}
+
+// GetChangelog lists the commits since the previous version, from `svn log`.
+func (sR *SvnRepo) GetChangelog(title string) (string, error) {
+	revisions := fmt.Sprintf("%d:%d", sR.info.Commit.Revision, sR.previousRev+1)
+	output, err := sR.svn("log", "--xml", "--revision", revisions)
+	if err != nil {
+		return "", fmt.Errorf("failed to get commit log: %w", err)
+	}
+
+	entries, err := formatSvnChangelog(output)
+	if err != nil {
+		return "", err
+	}
+
+	timestamp, err := sR.info.Commit.timestamp()
+	if err != nil {
+		return "", err
+	}
+
+	var changelog strings.Builder
+	changelog.WriteString(fmt.Sprintf("# %s\n\n", title))
+	changelogDate := time.Unix(timestamp, 0).UTC().Format("2006-01-02")
+	changelog.WriteString(fmt.Sprintf("## %s (%s)\n\n", sR.ProjectVersion, changelogDate))
+	changelog.WriteString(entries)
+
+	return changelog.String(), nil
+}
+
 func NewSvnRepo(r *Repo) (*SvnRepo, error) {
+	if _, err := exec.LookPath("svn"); err != nil {
+		return nil, fmt.Errorf("svn is not installed")
+	}
+
 	sR := SvnRepo{repo: r}
 
+	output, err := sR.svn("info", "--xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working copy info: %w", err)
+	}
+	if sR.info, err = parseSvnInfo(output); err != nil {
+		return nil, err
+	}
+	if sR.info.Commit.Revision == 0 {
+		return nil, fmt.Errorf("the repository has no commits")
+	}
+
+	if err := sR.getIgnores(); err != nil {
+		return nil, fmt.Errorf("failed to get ignored files: %w", err)
+	}
+
 	return &sR, nil
 }
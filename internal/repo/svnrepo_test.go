@@ -0,0 +1,189 @@
+package repo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/McTalian/wow-build-tools/internal/external"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSvnInfo(t *testing.T) {
+	output := `<?xml version="1.0" encoding="UTF-8"?>
+<info>
+<entry kind="dir" path="." revision="7">
+<url>file:///repos/project/tags/1.2.0</url>
+<relative-url>^/tags/1.2.0</relative-url>
+<repository><root>file:///repos/project</root><uuid>1234</uuid></repository>
+<commit revision="6"><author>jane</author><date>2025-01-01T00:00:00.000000Z</date></commit>
+</entry>
+</info>`
+
+	info, err := parseSvnInfo([]byte(output))
+	require.NoError(t, err)
+	assert.Equal(t, 7, info.Revision)
+	assert.Equal(t, "file:///repos/project", info.RootUrl)
+	assert.Equal(t, svnCommit{Revision: 6, Author: "jane", Date: "2025-01-01T00:00:00.000000Z"}, info.Commit)
+
+	timestamp, err := info.Commit.timestamp()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1735689600), timestamp)
+
+	_, err = parseSvnInfo([]byte("<info></info>"))
+	assert.Error(t, err)
+}
+
+func TestSvnUrls(t *testing.T) {
+	tests := []struct {
+		url     string
+		tag     string
+		project string
+	}{
+		{url: "https://repos.example.com/project/trunk", project: "https://repos.example.com/project"},
+		{url: "https://repos.example.com/project/trunk/Addon", project: "https://repos.example.com/project"},
+		{url: "https://repos.example.com/project/tags/1.2.0", tag: "1.2.0", project: "https://repos.example.com/project"},
+		{url: "https://repos.example.com/project/branches/classic", project: "https://repos.example.com/project"},
+		{url: "https://repos.example.com/trunkated", project: ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.tag, tagFromUrl(tt.url), tt.url)
+		assert.Equal(t, tt.project, projectUrl(tt.url), tt.url)
+	}
+}
+
+func TestParseSvnIgnores(t *testing.T) {
+	output := `<?xml version="1.0" encoding="UTF-8"?>
+<status>
+<target path=".">
+<entry path="Build"><wc-status item="unversioned" props="none"></wc-status></entry>
+<entry path="debug.log"><wc-status item="ignored" props="none"></wc-status></entry>
+<entry path="Core.lua"><wc-status item="modified" props="none" revision="3"></wc-status></entry>
+</target>
+</status>`
+
+	ignored, err := parseSvnIgnores([]byte(output))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Build", "debug.log"}, ignored)
+
+	root := t.TempDir()
+	sR := &SvnRepo{repo: &Repo{repoRoot: root, repoVcsType: external.Svn}, ignoredPaths: ignored}
+	assert.True(t, sR.IsIgnored(filepath.Join(root, "Build"), true))
+	assert.True(t, sR.IsIgnored(filepath.Join(root, "Build", "Output.lua"), false))
+	assert.True(t, sR.IsIgnored(filepath.Join(root, "debug.log"), false))
+	assert.False(t, sR.IsIgnored(filepath.Join(root, "Core.lua"), false))
+	assert.False(t, sR.IsIgnored(filepath.Join(root, "Builder.lua"), false))
+}
+
+func TestLatestSvnTag(t *testing.T) {
+	output := `<?xml version="1.0" encoding="UTF-8"?>
+<lists>
+<list path="file:///repos/project/tags">
+<entry kind="dir"><name>1.0.0</name><commit revision="3"></commit></entry>
+<entry kind="dir"><name>1.1.0-beta</name><commit revision="5"></commit></entry>
+<entry kind="dir"><name>1.1.0</name><commit revision="6"></commit></entry>
+<entry kind="dir"><name>1.2.0</name><commit revision="9"></commit></entry>
+<entry kind="file"><name>README</name><commit revision="2"></commit></entry>
+</list>
+</lists>`
+
+	tag, rev, err := latestSvnTag([]byte(output), 8, "")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", tag)
+	assert.Equal(t, 6, rev)
+
+	tag, _, err = latestSvnTag([]byte(output), 10, "1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", tag)
+}
+
+func TestFormatSvnChangelog(t *testing.T) {
+	output := `<?xml version="1.0" encoding="UTF-8"?>
+<log>
+<logentry revision="3"><author>jane</author><msg>Fix the_thing [skip ci]</msg></logentry>
+<logentry revision="2"><author>jane</author><msg></msg></logentry>
+<logentry revision="1"><author>jane</author><msg>Initial import</msg></logentry>
+</log>`
+
+	changelog, err := formatSvnChangelog([]byte(output))
+	require.NoError(t, err)
+	assert.Equal(t, "- Fix the\\_thing  \n- Initial import  \n", changelog)
+}
+
+func svnCommand(t *testing.T, dir string, name string, args ...string) {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func TestSvnRepo(t *testing.T) {
+	for _, tool := range []string{"svn", "svnadmin"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is not installed", tool)
+		}
+	}
+
+	reposDir := filepath.Join(t.TempDir(), "repos")
+	svnCommand(t, ".", "svnadmin", "create", reposDir)
+	reposUrl := "file://" + filepath.ToSlash(reposDir)
+	svnCommand(t, ".", "svn", "mkdir", "--parents", "-m", "Create layout", reposUrl+"/trunk", reposUrl+"/tags")
+
+	wc := filepath.Join(t.TempDir(), "wc")
+	svnCommand(t, ".", "svn", "checkout", reposUrl+"/trunk", wc)
+	require.NoError(t, os.WriteFile(filepath.Join(wc, "Project.toc"), []byte("## Title: Project\n"), 0644))
+	svnCommand(t, wc, "svn", "add", "Project.toc")
+	svnCommand(t, wc, "svn", "propset", "svn:ignore", "*.log", ".")
+	svnCommand(t, wc, "svn", "commit", "-m", "Add the_toc")
+	svnCommand(t, ".", "svn", "copy", "-m", "Tag 1.0.0", reposUrl+"/trunk", reposUrl+"/tags/1.0.0")
+
+	require.NoError(t, os.WriteFile(filepath.Join(wc, "Project.lua"), []byte("-- @file-revision@\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(wc, "debug.log"), []byte("log\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(wc, "Notes.txt"), []byte("notes\n"), 0644))
+	svnCommand(t, wc, "svn", "add", "Project.lua")
+	svnCommand(t, wc, "svn", "commit", "-m", "Add Project.lua")
+	svnCommand(t, wc, "svn", "update")
+
+	r, err := NewRepo(wc)
+	require.NoError(t, err)
+	require.Equal(t, external.Svn, r.GetVcsType())
+
+	sR, err := NewSvnRepo(r)
+	require.NoError(t, err)
+
+	assert.False(t, sR.IsIgnored(filepath.Join(wc, "Project.lua"), false))
+	assert.True(t, sR.IsIgnored(filepath.Join(wc, "debug.log"), false))
+	assert.True(t, sR.IsIgnored(filepath.Join(wc, "Notes.txt"), false))
+
+	stm := &tokens.SimpleTokenMap{}
+	require.NoError(t, sR.GetInjectionValues(stm))
+	assert.Equal(t, "4", (*stm)[tokens.ProjectRevision])
+	assert.Equal(t, "r4", (*stm)[tokens.ProjectVersion])
+	assert.Equal(t, "1.0.0", sR.GetPreviousVersion())
+	assert.Empty(t, sR.GetCurrentTag())
+
+	fileStm, err := sR.GetFileInjectionValues("Project.toc")
+	require.NoError(t, err)
+	assert.Equal(t, "2", (*fileStm)[tokens.FileRevision])
+
+	changelog, err := sR.GetChangelog("Project")
+	require.NoError(t, err)
+	assert.Contains(t, changelog, "## r4")
+	assert.Contains(t, changelog, "- Add Project.lua")
+	assert.NotContains(t, changelog, "Add the\\_toc")
+
+	tagWc := filepath.Join(t.TempDir(), "tag")
+	svnCommand(t, ".", "svn", "checkout", reposUrl+"/tags/1.0.0", tagWc)
+	r, err = NewRepo(tagWc)
+	require.NoError(t, err)
+	sR, err = NewSvnRepo(r)
+	require.NoError(t, err)
+	require.NoError(t, sR.GetInjectionValues(stm))
+	assert.Equal(t, "1.0.0", sR.GetCurrentTag())
+	assert.Equal(t, "1.0.0", sR.GetProjectVersion())
+}
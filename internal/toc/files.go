@@ -0,0 +1,91 @@
+package toc
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var loadConditionRegex = regexp.MustCompile(`\s*\[(AllowLoad\w+)\s+([^\]]*)\]`)
+
+// FileEntry is a file line of a TOC file along with its load conditions, e.g.
+// `Classic\[Game].lua [AllowLoadGameType vanilla, tbc]`.
+type FileEntry struct {
+	Path                string
+	AllowLoadGameType   []string
+	AllowLoadTextLocale []string
+}
+
+func parseConditionValues(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, strings.ToLower(v))
+		}
+	}
+	return values
+}
+
+func parseFileEntry(value string) FileEntry {
+	entry := FileEntry{}
+	for _, match := range loadConditionRegex.FindAllStringSubmatch(value, -1) {
+		switch strings.ToLower(match[1]) {
+		case "allowloadgametype":
+			entry.AllowLoadGameType = append(entry.AllowLoadGameType, parseConditionValues(match[2])...)
+		case "allowloadtextlocale":
+			entry.AllowLoadTextLocale = append(entry.AllowLoadTextLocale, parseConditionValues(match[2])...)
+		}
+	}
+	entry.Path = strings.TrimSpace(loadConditionRegex.ReplaceAllString(value, ""))
+	return entry
+}
+
+// allowsGameType reports whether an AllowLoadGameType list lets the given flavor load.
+// An empty list allows every flavor.
+func allowsGameType(gameTypes []string, flavor GameFlavor) bool {
+	if len(gameTypes) == 0 {
+		return true
+	}
+	for _, gameType := range gameTypes {
+		switch gameType {
+		case strings.ToLower(flavor.ToGameType()), strings.ToLower(flavor.ToTocSuffix()):
+			return true
+		case "classic":
+			if flavor != Retail {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LoadsFor reports whether the client for the given flavor loads the file.
+func (f FileEntry) LoadsFor(flavor GameFlavor) bool {
+	return allowsGameType(f.AllowLoadGameType, flavor)
+}
+
+// PathFor returns the path of the file with the `[Family]` and `[Game]` variables resolved for the flavor.
+func (f FileEntry) PathFor(flavor GameFlavor) string {
+	path := strings.ReplaceAll(f.Path, "[Family]", flavor.ToFamily())
+	return strings.ReplaceAll(path, "[Game]", flavor.ToGameType())
+}
+
+// FilesForFlavor returns the files the client for the given flavor loads from the TOC,
+// honoring `## AllowLoadGameType:` and per-file load conditions.
+func (t *Toc) FilesForFlavor(flavor GameFlavor) []string {
+	if gameTypes, ok := t.Get("AllowLoadGameType"); ok && !allowsGameType(parseConditionValues(gameTypes), flavor) {
+		return nil
+	}
+
+	var files []string
+	for _, entry := range t.FileEntries {
+		if !entry.LoadsFor(flavor) {
+			continue
+		}
+		path := entry.PathFor(flavor)
+		if !slices.Contains(files, path) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
@@ -0,0 +1,80 @@
+package toc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFileEntry(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected FileEntry
+	}{
+		{"Project.lua", FileEntry{Path: "Project.lua"}},
+		{"Libs\\[Family]\\Lib.xml", FileEntry{Path: "Libs\\[Family]\\Lib.xml"}},
+		{
+			"Classic.lua [AllowLoadGameType vanilla, TBC]",
+			FileEntry{Path: "Classic.lua", AllowLoadGameType: []string{"vanilla", "tbc"}},
+		},
+		{
+			"Locales\\deDE.lua [AllowLoadTextLocale deDE] [AllowLoadGameType mainline]",
+			FileEntry{Path: "Locales\\deDE.lua", AllowLoadGameType: []string{"mainline"}, AllowLoadTextLocale: []string{"dede"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseFileEntry(tt.line))
+		})
+	}
+}
+
+func TestFileEntry_LoadsFor(t *testing.T) {
+	tests := []struct {
+		gameTypes []string
+		flavor    GameFlavor
+		expected  bool
+	}{
+		{nil, Retail, true},
+		{[]string{"mainline"}, Retail, true},
+		{[]string{"standard"}, Retail, true},
+		{[]string{"mainline"}, ClassicEra, false},
+		{[]string{"classic"}, CataClassic, true},
+		{[]string{"classic"}, Retail, false},
+		{[]string{"vanilla", "mists"}, MopClassic, true},
+		{[]string{"vanilla", "mists"}, CataClassic, false},
+	}
+
+	for _, tt := range tests {
+		entry := FileEntry{Path: "File.lua", AllowLoadGameType: tt.gameTypes}
+		assert.Equal(t, tt.expected, entry.LoadsFor(tt.flavor), "%v for %s", tt.gameTypes, tt.flavor.ToString())
+	}
+}
+
+func TestFileEntry_PathFor(t *testing.T) {
+	entry := FileEntry{Path: "Core\\[Family]\\[Game].lua"}
+	assert.Equal(t, "Core\\Mainline\\Standard.lua", entry.PathFor(Retail))
+	assert.Equal(t, "Core\\Classic\\Vanilla.lua", entry.PathFor(ClassicEra))
+	assert.Equal(t, "Core\\Classic\\Mists.lua", entry.PathFor(MopClassic))
+}
+
+func TestFilesForFlavor(t *testing.T) {
+	contents := "## Interface: 110100, 11506\n" +
+		"Project.lua\n" +
+		"Core\\[Family].lua\n" +
+		"Retail.lua [AllowLoadGameType mainline]\n" +
+		"Classic.lua [AllowLoadGameType classic]\n"
+	toc, err := parse("Project.toc", contents)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Project.lua", "Core\\[Family].lua", "Retail.lua", "Classic.lua"}, toc.Files)
+	assert.Equal(t, []string{"Project.lua", "Core\\Mainline.lua", "Retail.lua"}, toc.FilesForFlavor(Retail))
+	assert.Equal(t, []string{"Project.lua", "Core\\Classic.lua", "Classic.lua"}, toc.FilesForFlavor(ClassicEra))
+
+	toc, err = parse("Project.toc", "## AllowLoadGameType: mainline\nProject.lua\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Project.lua"}, toc.FilesForFlavor(Retail))
+	assert.Empty(t, toc.FilesForFlavor(ClassicEra))
+}
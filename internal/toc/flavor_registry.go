@@ -0,0 +1,159 @@
+package toc
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed flavors.yaml
+var embeddedFlavors []byte
+
+// FlavorInstall is a WoW installation directory used by a flavor, e.g. `_retail_` or `_ptr_`.
+type FlavorInstall struct {
+	Name  string `yaml:"name" mapstructure:"name"`
+	Label string `yaml:"label" mapstructure:"label"`
+	Dir   string `yaml:"dir" mapstructure:"dir"`
+}
+
+// FlavorInfo describes a game flavor, see flavors.yaml for what each field is used for.
+type FlavorInfo struct {
+	Id               GameFlavor      `yaml:"id" mapstructure:"id"`
+	Name             string          `yaml:"name" mapstructure:"name"`
+	InterfaceMajors  []int           `yaml:"interfaceMajors" mapstructure:"interfaceMajors"`
+	TocSuffixes      []string        `yaml:"tocSuffixes" mapstructure:"tocSuffixes"`
+	GameType         string          `yaml:"gameType" mapstructure:"gameType"`
+	Family           string          `yaml:"family" mapstructure:"family"`
+	Aliases          []string        `yaml:"aliases" mapstructure:"aliases"`
+	CurseVersionType int             `yaml:"curseVersionType" mapstructure:"curseVersionType"`
+	WagoKey          string          `yaml:"wagoKey" mapstructure:"wagoKey"`
+	WowiGame         string          `yaml:"wowiGame" mapstructure:"wowiGame"`
+	ReleaseFlavor    string          `yaml:"releaseFlavor" mapstructure:"releaseFlavor"`
+	Installs         []FlavorInstall `yaml:"installs" mapstructure:"installs"`
+	BuildTypeTokens  []string        `yaml:"buildTypeTokens" mapstructure:"buildTypeTokens"`
+}
+
+type flavorRegistry struct {
+	Default GameFlavor   `yaml:"default"`
+	Flavors []FlavorInfo `yaml:"flavors"`
+}
+
+var registry = mustLoadRegistry(embeddedFlavors)
+
+func loadRegistry(data []byte) (*flavorRegistry, error) {
+	r := &flavorRegistry{}
+	if err := yaml.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("error parsing flavor registry: %v", err)
+	}
+	if _, ok := r.lookup(r.Default); !ok {
+		return nil, fmt.Errorf("default flavor %s is not in the flavor registry", r.Default)
+	}
+	return r, nil
+}
+
+func mustLoadRegistry(data []byte) *flavorRegistry {
+	r, err := loadRegistry(data)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *flavorRegistry) lookup(flavor GameFlavor) (FlavorInfo, bool) {
+	i := slices.IndexFunc(r.Flavors, func(f FlavorInfo) bool { return f.Id == flavor })
+	if i == -1 {
+		return FlavorInfo{}, false
+	}
+	return r.Flavors[i], true
+}
+
+// merge overrides the non-empty fields of the flavor with the same id, or adds the flavor.
+func (r *flavorRegistry) merge(override FlavorInfo) {
+	i := slices.IndexFunc(r.Flavors, func(f FlavorInfo) bool { return f.Id == override.Id })
+	if i == -1 {
+		r.Flavors = append(r.Flavors, override)
+		return
+	}
+
+	f := &r.Flavors[i]
+	if override.Name != "" {
+		f.Name = override.Name
+	}
+	if override.InterfaceMajors != nil {
+		f.InterfaceMajors = override.InterfaceMajors
+	}
+	if override.TocSuffixes != nil {
+		f.TocSuffixes = override.TocSuffixes
+	}
+	if override.GameType != "" {
+		f.GameType = override.GameType
+	}
+	if override.Family != "" {
+		f.Family = override.Family
+	}
+	if override.Aliases != nil {
+		f.Aliases = override.Aliases
+	}
+	if override.CurseVersionType != 0 {
+		f.CurseVersionType = override.CurseVersionType
+	}
+	if override.WagoKey != "" {
+		f.WagoKey = override.WagoKey
+	}
+	if override.WowiGame != "" {
+		f.WowiGame = override.WowiGame
+	}
+	if override.ReleaseFlavor != "" {
+		f.ReleaseFlavor = override.ReleaseFlavor
+	}
+	if override.Installs != nil {
+		f.Installs = override.Installs
+	}
+	if override.BuildTypeTokens != nil {
+		f.BuildTypeTokens = override.BuildTypeTokens
+	}
+}
+
+// RegisterFlavors merges flavor definitions, e.g. from the `flavors` key of .wbt.yaml, into
+// the registry. Fields left empty keep the built-in values of the flavor with the same id.
+func RegisterFlavors(overrides []FlavorInfo) error {
+	for _, override := range overrides {
+		if override.Id == Unknown {
+			return fmt.Errorf("flavor definition is missing an id")
+		}
+		registry.merge(override)
+	}
+	return nil
+}
+
+// Flavors returns every registered game flavor.
+func Flavors() []FlavorInfo {
+	return slices.Clone(registry.Flavors)
+}
+
+// LookupFlavor returns the registry entry for the game flavor.
+func LookupFlavor(flavor GameFlavor) (FlavorInfo, bool) {
+	return registry.lookup(flavor)
+}
+
+// info returns the registry entry of the flavor, falling back to the default flavor.
+func (g GameFlavor) info() FlavorInfo {
+	if f, ok := registry.lookup(g); ok {
+		return f
+	}
+	f, _ := registry.lookup(registry.Default)
+	return f
+}
+
+// flavorFromAlias returns the flavor with the given id or alias.
+func flavorFromAlias(alias string) (GameFlavor, bool) {
+	for _, f := range registry.Flavors {
+		if strings.EqualFold(string(f.Id), alias) || slices.ContainsFunc(f.Aliases, func(a string) bool { return strings.EqualFold(a, alias) }) {
+			return f.Id, true
+		}
+	}
+	return Unknown, false
+}
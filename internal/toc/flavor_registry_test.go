@@ -0,0 +1,82 @@
+package toc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedFlavorRegistry(t *testing.T) {
+	seenSuffixes := make(map[string]GameFlavor)
+	seenMajors := make(map[int]GameFlavor)
+	for _, f := range Flavors() {
+		assert.NotEmpty(t, f.Id)
+		assert.NotEmpty(t, f.TocSuffixes, "%s has no TOC suffixes", f.Id)
+		assert.NotEmpty(t, f.BuildTypeTokens, "%s has no build type tokens", f.Id)
+		for _, suffix := range f.TocSuffixes {
+			other, ok := seenSuffixes[suffix]
+			assert.False(t, ok, "TOC suffix %s is used by %s and %s", suffix, f.Id, other)
+			seenSuffixes[suffix] = f.Id
+		}
+		for _, major := range f.InterfaceMajors {
+			other, ok := seenMajors[major]
+			assert.False(t, ok, "Interface major %d is used by %s and %s", major, f.Id, other)
+			seenMajors[major] = f.Id
+		}
+	}
+}
+
+func TestLoadRegistry(t *testing.T) {
+	_, err := loadRegistry([]byte("default: missing\nflavors:\n  - id: retail\n"))
+	assert.Error(t, err)
+
+	_, err = loadRegistry([]byte("flavors: ["))
+	assert.Error(t, err)
+}
+
+func TestGetFlavorFromMajorVersion(t *testing.T) {
+	assert.Equal(t, ClassicEra, getFlavorFromMajorVersion(1))
+	assert.Equal(t, MopClassic, getFlavorFromMajorVersion(5))
+	assert.Equal(t, Retail, getFlavorFromMajorVersion(11))
+}
+
+func TestRegisterFlavors(t *testing.T) {
+	original := registry
+	t.Cleanup(func() { registry = original })
+	registry = mustLoadRegistry(embeddedFlavors)
+
+	err := RegisterFlavors([]FlavorInfo{
+		{Id: MopClassic, WagoKey: "mists", TocSuffixes: []string{"Mists"}},
+		{Id: "anniversary", InterfaceMajors: []int{99}, TocSuffixes: []string{"Anniversary"}, GameType: "Anniversary", Family: "Classic"},
+	})
+	require.NoError(t, err)
+
+	mop, ok := LookupFlavor(MopClassic)
+	require.True(t, ok)
+	assert.Equal(t, "mists", mop.WagoKey)
+	assert.Equal(t, []string{"Mists"}, mop.TocSuffixes)
+	assert.Equal(t, "Mists of Pandaria Classic", mop.Name)
+	assert.Equal(t, Unknown, suffixToGameFlavor("Mop"))
+
+	assert.Equal(t, GameFlavor("anniversary"), getFlavorFromMajorVersion(99))
+	flavor, suffix := TocFileToGameFlavor("Project_Anniversary")
+	assert.Equal(t, GameFlavor("anniversary"), flavor)
+	assert.Equal(t, "Anniversary", suffix)
+	assert.Equal(t, "Classic", flavor.ToFamily())
+
+	assert.Error(t, RegisterFlavors([]FlavorInfo{{Name: "No id"}}))
+}
+
+func TestFlavorFromAlias(t *testing.T) {
+	flavor, ok := flavorFromAlias("Mainline")
+	assert.True(t, ok)
+	assert.Equal(t, Retail, flavor)
+
+	flavor, ok = flavorFromAlias("tbc")
+	assert.True(t, ok)
+	assert.Equal(t, TbcClassic, flavor)
+
+	_, ok = flavorFromAlias("11.1.0")
+	assert.False(t, ok)
+}
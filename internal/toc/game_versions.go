@@ -2,134 +2,148 @@ package toc
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/McTalian/wow-build-tools/internal/logger"
 )
 
-type GameFlavor int
+// GameFlavor is the id of a flavor in the flavor registry.
+type GameFlavor string
 
+// The built-in flavors, see flavors.yaml.
 const (
-	Unknown GameFlavor = iota
-	ClassicEra
-	TbcClassic
-	WotlkClassic
-	CataClassic
-	MopClassic // Just a guess
-	WodClassic
-	LegionClassic
-	BfaClassic
-	SlClassic
-	DfClassic
-	Retail
+	Unknown       GameFlavor = ""
+	ClassicEra    GameFlavor = "classic"
+	TbcClassic    GameFlavor = "bcc"
+	WotlkClassic  GameFlavor = "wrath"
+	CataClassic   GameFlavor = "cata"
+	MopClassic    GameFlavor = "mop"
+	WodClassic    GameFlavor = "wod"
+	LegionClassic GameFlavor = "legion"
+	BfaClassic    GameFlavor = "bfa"
+	SlClassic     GameFlavor = "sl"
+	DfClassic     GameFlavor = "df"
+	Retail        GameFlavor = "retail"
 )
 
 func (g GameFlavor) ToString() string {
-	switch g {
-	case ClassicEra:
-		return "classic"
-	case TbcClassic:
-		return "bcc"
-	case WotlkClassic:
-		return "wrath"
-	case CataClassic:
-		return "cata"
-	case MopClassic: // Just a guess
-		return "mop"
-	case WodClassic:
-		return "wod"
-	case LegionClassic:
-		return "legion"
-	case BfaClassic:
-		return "bfa"
-	case SlClassic:
-		return "sl"
-	case DfClassic:
-		return "df"
-	default:
-		return "retail"
+	return string(g.info().Id)
+}
+
+// ToTocSuffix returns the suffix used for flavor-specific TOC files, e.g. MyAddon_Vanilla.toc.
+func (g GameFlavor) ToTocSuffix() string {
+	if suffixes := g.info().TocSuffixes; len(suffixes) > 0 {
+		return suffixes[0]
 	}
+	return string(g)
+}
+
+// ToFamily returns the client family of the flavor, substituted for `[Family]` in TOC file lines.
+func (g GameFlavor) ToFamily() string {
+	return g.info().Family
+}
+
+// ToGameType returns the game type of the flavor, substituted for `[Game]` in TOC file lines.
+func (g GameFlavor) ToGameType() string {
+	return g.info().GameType
 }
 
 type GameVersions map[GameFlavor][]string
 
-var gameVersions GameVersions = make(GameVersions)
-var gameInterfaces GameVersions = make(GameVersions)
+// GameVersionSet collects the game versions and interfaces targeted by a single build, from the
+// TOC files and the --gameVersion flag.
+type GameVersionSet struct {
+	versions   GameVersions
+	interfaces GameVersions
+}
 
-func AddGameInterface(flavor GameFlavor, version string) {
-	gameInterfaces[flavor] = append(gameInterfaces[flavor], version)
+func NewGameVersionSet() *GameVersionSet {
+	return &GameVersionSet{
+		versions:   make(GameVersions),
+		interfaces: make(GameVersions),
+	}
 }
 
-func AddGameVersion(flavor GameFlavor, version string) {
-	gameVersions[flavor] = append(gameVersions[flavor], version)
+func (s *GameVersionSet) AddInterface(flavor GameFlavor, version string) {
+	if !slices.Contains(s.interfaces[flavor], version) {
+		s.interfaces[flavor] = append(s.interfaces[flavor], version)
+	}
+}
+
+func (s *GameVersionSet) AddVersion(flavor GameFlavor, version string) {
+	if !slices.Contains(s.versions[flavor], version) {
+		s.versions[flavor] = append(s.versions[flavor], version)
+	}
+}
+
+// AddToc adds the interfaces of the TOC file, including flavor-specific Interface lines.
+func (s *GameVersionSet) AddToc(t *Toc) {
+	allInterfaces := slices.Clone(t.Interface)
+	for _, flavor := range slices.Sorted(maps.Keys(t.FlavorInterface)) {
+		allInterfaces = append(allInterfaces, t.FlavorInterface[flavor]...)
+	}
+
+	for _, interfaceVersion := range allInterfaces {
+		// Grab the right-most 2 digits for the patch version
+		patchVersion := interfaceVersion % 100
+		// Grab the middle 2 digits for the minor version
+		minorVersion := (interfaceVersion / 100) % 100
+		// Grab the left-most digits for the major version
+		majorVersion := interfaceVersion / 10000
+
+		flavor := getFlavorFromMajorVersion(majorVersion)
+		s.AddVersion(flavor, fmt.Sprintf("%d.%d.%d", majorVersion, minorVersion, patchVersion))
+		s.AddInterface(flavor, strconv.Itoa(interfaceVersion))
+	}
 }
 
 func getFlavorFromMajorVersion(majorVersion int) GameFlavor {
-	switch majorVersion {
-	case 1:
-		return ClassicEra
-	case 2:
-		return TbcClassic
-	case 3:
-		return WotlkClassic
-	case 4:
-		return CataClassic
-	case 5:
-		return MopClassic // Just a guess
-	case 6:
-		return WodClassic
-	case 7:
-		return LegionClassic
-	case 8:
-		return BfaClassic
-	case 9:
-		return SlClassic
-	case 10:
-		return DfClassic
-	default:
-		return Retail
+	for _, f := range registry.Flavors {
+		if slices.Contains(f.InterfaceMajors, majorVersion) {
+			return f.Id
+		}
 	}
+	return registry.Default
 }
 
-func parseGameVersionSegment(version string) error {
+func (s *GameVersionSet) parseGameVersionSegment(version string) error {
 	orig := strings.ToLower(version)
-	switch strings.ToLower(orig) {
-	case "retail", "classic", "bcc", "wrath", "cata":
-		return nil
-	case "mainline":
+	if _, ok := flavorFromAlias(orig); ok {
 		return nil
-	default:
-		segments := strings.Split(orig, ".")
-		if len(segments) < 3 {
-			return fmt.Errorf("Invalid argument for game version: %s", orig)
-		}
-		major, err := strconv.Atoi(segments[0])
-		if err != nil {
-			logger.Error("%v", err)
-			return fmt.Errorf("Invalid argument for game version: %s", orig)
-		}
-		minor, err := strconv.Atoi(segments[1])
-		if err != nil {
-			logger.Error("%v", err)
-			return fmt.Errorf("Invalid argument for game version: %s", orig)
-		}
-		patch, err := strconv.Atoi(segments[2])
-		if err != nil {
-			logger.Error("%v", err)
-			return fmt.Errorf("Invalid argument for game version: %s", orig)
-		}
-
-		flavor := getFlavorFromMajorVersion(major)
+	}
 
-		AddGameVersion(flavor, fmt.Sprintf("%d.%d.%d", major, minor, patch))
-		AddGameInterface(flavor, fmt.Sprintf("%d%02d%02d", major, minor, patch))
+	segments := strings.Split(orig, ".")
+	if len(segments) < 3 {
+		return fmt.Errorf("Invalid argument for game version: %s", orig)
+	}
+	major, err := strconv.Atoi(segments[0])
+	if err != nil {
+		logger.Error("%v", err)
+		return fmt.Errorf("Invalid argument for game version: %s", orig)
 	}
+	minor, err := strconv.Atoi(segments[1])
+	if err != nil {
+		logger.Error("%v", err)
+		return fmt.Errorf("Invalid argument for game version: %s", orig)
+	}
+	patch, err := strconv.Atoi(segments[2])
+	if err != nil {
+		logger.Error("%v", err)
+		return fmt.Errorf("Invalid argument for game version: %s", orig)
+	}
+
+	flavor := getFlavorFromMajorVersion(major)
+
+	s.AddVersion(flavor, fmt.Sprintf("%d.%d.%d", major, minor, patch))
+	s.AddInterface(flavor, fmt.Sprintf("%d%02d%02d", major, minor, patch))
 
 	return nil
 }
 
-func normalizeGameVersion(gameVersion string) error {
+func (s *GameVersionSet) normalizeGameVersion(gameVersion string) error {
 	if gameVersion == "" {
 		return nil
 	}
@@ -143,14 +157,14 @@ func normalizeGameVersion(gameVersion string) error {
 		}
 
 		for _, version := range versions {
-			err := parseGameVersionSegment(version)
+			err := s.parseGameVersionSegment(version)
 			if err != nil {
 				return err
 			}
 		}
 	} else {
 		// Only one version specified
-		err := parseGameVersionSegment(gameVersion)
+		err := s.parseGameVersionSegment(gameVersion)
 		if err != nil {
 			return err
 		}
@@ -159,40 +173,34 @@ func normalizeGameVersion(gameVersion string) error {
 	return nil
 }
 
-func ParseGameVersionFlag(gameVersionFlag string) error {
-	return normalizeGameVersion(gameVersionFlag)
+func (s *GameVersionSet) ParseGameVersionFlag(gameVersionFlag string) error {
+	return s.normalizeGameVersion(gameVersionFlag)
 }
 
-func GetGameFlavorVersionsMap() map[GameFlavor][]string {
-	return gameVersions
+// FlavorVersions returns the game versions by flavor, e.g. 11.1.0.
+func (s *GameVersionSet) FlavorVersions() GameVersions {
+	return s.versions
 }
 
-func GetGameFlavorInterfacesMap() map[GameFlavor][]string {
-	return gameInterfaces
+// FlavorInterfaces returns the interface versions by flavor, e.g. 110100.
+func (s *GameVersionSet) FlavorInterfaces() GameVersions {
+	return s.interfaces
 }
 
-func GetGameVersions() []string {
+// Versions returns every game version, ordered by flavor and then by the order they were added.
+func (s *GameVersionSet) Versions() []string {
 	var versions []string
-	for _, version := range gameVersions {
-		versions = append(versions, version...)
-	}
-
-	var uniqueVersions []string
-	uniqueMap := make(map[string]bool)
-	for _, version := range versions {
-		if _, ok := uniqueMap[version]; !ok {
-			uniqueMap[version] = true
-			uniqueVersions = append(uniqueVersions, version)
+	for _, flavor := range s.Flavors() {
+		for _, version := range s.versions[flavor] {
+			if !slices.Contains(versions, version) {
+				versions = append(versions, version)
+			}
 		}
 	}
-
-	return uniqueVersions
+	return versions
 }
 
-func GetGameFlavors() []GameFlavor {
-	var flavors []GameFlavor
-	for flavor := range gameVersions {
-		flavors = append(flavors, flavor)
-	}
-	return flavors
+// Flavors returns the flavors with at least one game version, in a stable order.
+func (s *GameVersionSet) Flavors() []GameFlavor {
+	return slices.Sorted(maps.Keys(s.versions))
 }
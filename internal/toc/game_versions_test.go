@@ -0,0 +1,79 @@
+package toc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGameVersionSet_AddToc(t *testing.T) {
+	toc, err := parse("Project.toc", "## Interface: 110100, 11506, 110100\n## Interface-Cata: 40402\n")
+	require.NoError(t, err)
+
+	s := NewGameVersionSet()
+	s.AddToc(toc)
+
+	assert.Equal(t, []GameFlavor{CataClassic, ClassicEra, Retail}, s.Flavors())
+	assert.Equal(t, []string{"4.4.2", "1.15.6", "11.1.0"}, s.Versions())
+	assert.Equal(t, GameVersions{
+		Retail:      {"110100"},
+		ClassicEra:  {"11506"},
+		CataClassic: {"40402"},
+	}, s.FlavorInterfaces())
+}
+
+func TestGameVersionSet_ParseGameVersionFlag(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       string
+		wantErr    bool
+		versions   []string
+		interfaces GameVersions
+	}{
+		{"Empty", "", false, nil, GameVersions{}},
+		{"Single version", "11.1.0", false, []string{"11.1.0"}, GameVersions{Retail: {"110100"}}},
+		{"Multiple versions", "1.15.6, 11.1.0,1.15.6", false, []string{"1.15.6", "11.1.0"}, GameVersions{ClassicEra: {"11506"}, Retail: {"110100"}}},
+		{"Flavor alias", "mainline", false, nil, GameVersions{}},
+		{"Too few segments", "11.1", true, nil, GameVersions{}},
+		{"Not a number", "11.x.0", true, nil, GameVersions{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewGameVersionSet()
+			err := s.ParseGameVersionFlag(tt.flag)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.versions, s.Versions())
+			assert.Equal(t, tt.interfaces, s.FlavorInterfaces())
+		})
+	}
+}
+
+func TestGameVersionSet_Independent(t *testing.T) {
+	contents := "## Interface: 110100\n## Interface-Vanilla: 11506\n"
+
+	build := func() *GameVersionSet {
+		toc, err := parse("Project.toc", contents)
+		require.NoError(t, err)
+		s := NewGameVersionSet()
+		require.NoError(t, s.ParseGameVersionFlag("11.0.7"))
+		s.AddToc(toc)
+		return s
+	}
+
+	first := build()
+	second := build()
+	assert.Equal(t, first, second)
+	assert.Equal(t, []string{"1.15.6", "11.0.7", "11.1.0"}, second.Versions())
+
+	// Parsing a TOC file must not leak into other sets
+	_, err := parse("Other.toc", "## Interface: 40402\n")
+	require.NoError(t, err)
+	assert.Equal(t, first, build())
+	assert.NotContains(t, first.Flavors(), CataClassic)
+}
@@ -0,0 +1,207 @@
+package toc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+const utf8Bom = "\ufeff"
+
+type LineKind int
+
+const (
+	BlankLine LineKind = iota
+	// MetadataLine is a `## Key: Value` directive
+	MetadataLine
+	// CommentLine is any other line starting with `#`, including build type token markers
+	CommentLine
+	// FileLine is a file loaded by the client
+	FileLine
+)
+
+// Line is a single line of a TOC file. Raw and Ending are kept as they were read so the file
+// can be written back out byte-for-byte.
+type Line struct {
+	Kind   LineKind
+	Raw    string
+	Ending string
+	Key    string
+	Value  string
+}
+
+func parseLine(raw, ending string) Line {
+	line := Line{Raw: raw, Ending: ending}
+	trimmed := strings.TrimSpace(raw)
+	switch {
+	case trimmed == "":
+		line.Kind = BlankLine
+	case strings.HasPrefix(trimmed, "##"):
+		key, value, found := strings.Cut(strings.TrimPrefix(trimmed, "##"), ":")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			line.Kind = CommentLine
+			break
+		}
+		line.Kind = MetadataLine
+		line.Key = key
+		line.Value = strings.TrimSpace(value)
+	case strings.HasPrefix(trimmed, "#"):
+		line.Kind = CommentLine
+	default:
+		line.Kind = FileLine
+		line.Value = trimmed
+	}
+	return line
+}
+
+func parseLines(contents string) (lines []Line, bom bool) {
+	if strings.HasPrefix(contents, utf8Bom) {
+		bom = true
+		contents = strings.TrimPrefix(contents, utf8Bom)
+	}
+
+	for contents != "" {
+		raw, rest, found := strings.Cut(contents, "\n")
+		ending := ""
+		if found {
+			ending = "\n"
+			if strings.HasSuffix(raw, "\r") {
+				raw = strings.TrimSuffix(raw, "\r")
+				ending = "\r\n"
+			}
+		}
+		lines = append(lines, parseLine(raw, ending))
+		contents = rest
+	}
+
+	return lines, bom
+}
+
+// lineEnding is the line ending used for lines added to the TOC.
+func (t *Toc) lineEnding() string {
+	for _, line := range t.Lines {
+		if line.Ending != "" {
+			return line.Ending
+		}
+	}
+	return "\n"
+}
+
+// Get returns the value of the first `## Key: Value` line matching key.
+func (t *Toc) Get(key string) (string, bool) {
+	for _, line := range t.Lines {
+		if line.Kind == MetadataLine && line.Key == key {
+			return line.Value, true
+		}
+	}
+	return "", false
+}
+
+// GetForFlavor returns the value of a key for a game flavor, preferring suffixed keys
+// like `## Interface-Vanilla:` or `## Title-Classic:` over the plain key.
+func (t *Toc) GetForFlavor(key string, flavor GameFlavor) (string, bool) {
+	for _, line := range t.Lines {
+		if line.Kind != MetadataLine {
+			continue
+		}
+		suffix, ok := strings.CutPrefix(line.Key, key+"-")
+		if ok && suffixToGameFlavor(suffix) == flavor {
+			return line.Value, true
+		}
+	}
+	return t.Get(key)
+}
+
+// Metadata returns the `## Key: Value` lines of the TOC in the order they appear.
+func (t *Toc) Metadata() []Line {
+	var metadata []Line
+	for _, line := range t.Lines {
+		if line.Kind == MetadataLine {
+			metadata = append(metadata, line)
+		}
+	}
+	return metadata
+}
+
+// Set updates the first `## Key: Value` line matching key, or adds one after the last
+// metadata line if there is none.
+func (t *Toc) Set(key, value string) error {
+	newLine := parseLine(fmt.Sprintf("## %s: %s", key, value), t.lineEnding())
+
+	insertAt := 0
+	for i, line := range t.Lines {
+		if line.Kind != MetadataLine {
+			continue
+		}
+		if line.Key == key {
+			newLine.Ending = line.Ending
+			t.Lines[i] = newLine
+			return t.index()
+		}
+		insertAt = i + 1
+	}
+
+	if insertAt == len(t.Lines) && insertAt > 0 && t.Lines[insertAt-1].Ending == "" {
+		t.Lines[insertAt-1].Ending = newLine.Ending
+		newLine.Ending = ""
+	}
+	t.Lines = append(t.Lines[:insertAt], append([]Line{newLine}, t.Lines[insertAt:]...)...)
+
+	return t.index()
+}
+
+// Delete removes every `## Key: Value` line matching key.
+func (t *Toc) Delete(key string) error {
+	return t.deleteMatching(func(k string) bool { return k == key })
+}
+
+func (t *Toc) deleteMatching(match func(key string) bool) error {
+	lines := t.Lines[:0]
+	for _, line := range t.Lines {
+		if line.Kind == MetadataLine && match(line.Key) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	t.Lines = lines
+
+	return t.index()
+}
+
+// Clone returns a deep copy of the TOC.
+func (t *Toc) Clone() *Toc {
+	clone := *t
+	clone.Lines = slices.Clone(t.Lines)
+	clone.Interface = slices.Clone(t.Interface)
+	clone.Files = slices.Clone(t.Files)
+	clone.FileEntries = slices.Clone(t.FileEntries)
+	clone.FlavorInterface = make(map[GameFlavor][]int, len(t.FlavorInterface))
+	for flavor, interfaces := range t.FlavorInterface {
+		clone.FlavorInterface[flavor] = slices.Clone(interfaces)
+	}
+	return &clone
+}
+
+// Bytes returns the contents of the TOC file.
+func (t *Toc) Bytes() []byte {
+	var buf bytes.Buffer
+	if t.bom {
+		buf.WriteString(utf8Bom)
+	}
+	for _, line := range t.Lines {
+		buf.WriteString(line.Raw)
+		buf.WriteString(line.Ending)
+	}
+	return buf.Bytes()
+}
+
+// WriteFile writes the TOC file to path.
+func (t *Toc) WriteFile(path string) error {
+	if err := os.WriteFile(path, t.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing TOC file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package toc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fullToc = "\ufeff## Interface: 110100, 11506\r\n" +
+	"## Interface-Cata: 40402\r\n" +
+	"## Title: |cff00ff00Project|r\r\n" +
+	"## Title-deDE: Projekt\r\n" +
+	"## Notes: Does things\r\n" +
+	"## Author: Someone\r\n" +
+	"## SavedVariables: ProjectDB, ProjectGlobalDB\r\n" +
+	"## Dependencies: Blizzard_Foo\r\n" +
+	"## OptionalDeps: Ace3\r\n" +
+	"## LoadOnDemand: 0\r\n" +
+	"## IconTexture: Interface\\Icons\\INV_Misc_QuestionMark\r\n" +
+	"## Category-enUS: Bags\r\n" +
+	"## AddonCompartmentFunc: Project_OnAddonCompartmentClick\r\n" +
+	"## X-Curse-Project-ID: 1234\r\n" +
+	"## Version: @project-version@\r\n" +
+	"\r\n" +
+	"# A regular comment\r\n" +
+	"#@no-lib-strip@\r\n" +
+	"Libs\\LibStub\\LibStub.lua\r\n" +
+	"#@end-no-lib-strip@\r\n" +
+	"\r\n" +
+	"  Project.lua  \n" +
+	"Project.xml"
+
+func TestParseLines_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"Empty", ""},
+		{"Unix line endings", "## Interface: 110100\n## Title: Project\n\nProject.lua\n"},
+		{"No trailing newline", "## Interface: 110100\nProject.lua"},
+		{"Everything", fullToc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toc, err := parse("Project.toc", tt.contents)
+			require.NoError(t, err)
+			assert.Equal(t, tt.contents, string(toc.Bytes()))
+		})
+	}
+}
+
+func TestParseLines_Metadata(t *testing.T) {
+	toc, err := parse("Project.toc", fullToc)
+	require.NoError(t, err)
+
+	metadata := toc.Metadata()
+	require.Len(t, metadata, 15)
+	assert.Equal(t, "Interface", metadata[0].Key)
+	assert.Equal(t, "Title-deDE", metadata[3].Key)
+	assert.Equal(t, "Version", metadata[14].Key)
+
+	author, ok := toc.Get("Author")
+	assert.True(t, ok)
+	assert.Equal(t, "Someone", author)
+
+	_, ok = toc.Get("Missing")
+	assert.False(t, ok)
+
+	assert.Equal(t, []int{110100, 11506}, toc.Interface)
+	assert.Equal(t, map[GameFlavor][]int{CataClassic: {40402}}, toc.FlavorInterface)
+	assert.Equal(t, "|cff00ff00Project|r", toc.Title)
+	assert.Equal(t, "1234", toc.CurseId)
+	assert.Equal(t, []string{"Libs\\LibStub\\LibStub.lua", "Project.lua", "Project.xml"}, toc.Files)
+}
+
+func TestGetForFlavor(t *testing.T) {
+	toc, err := parse("Project.toc", fullToc)
+	require.NoError(t, err)
+
+	value, ok := toc.GetForFlavor("Interface", CataClassic)
+	assert.True(t, ok)
+	assert.Equal(t, "40402", value)
+
+	value, ok = toc.GetForFlavor("Interface", ClassicEra)
+	assert.True(t, ok)
+	assert.Equal(t, "110100, 11506", value)
+}
+
+func TestSetAndDelete(t *testing.T) {
+	toc, err := parse("Project.toc", "## Interface: 110100\r\n## Title: Project\r\n\r\nProject.lua")
+	require.NoError(t, err)
+
+	require.NoError(t, toc.Set("Title", "Renamed"))
+	require.NoError(t, toc.Set("Author", "Someone"))
+	assert.Equal(t, "Renamed", toc.Title)
+	assert.Equal(t, "## Interface: 110100\r\n## Title: Renamed\r\n## Author: Someone\r\n\r\nProject.lua", string(toc.Bytes()))
+
+	require.NoError(t, toc.Delete("Title"))
+	assert.Equal(t, "", toc.Title)
+	assert.Equal(t, "## Interface: 110100\r\n## Author: Someone\r\n\r\nProject.lua", string(toc.Bytes()))
+
+	assert.Error(t, toc.Set("Interface", "abc"))
+}
+
+func TestSetAppendsToFileWithoutTrailingNewline(t *testing.T) {
+	toc, err := parse("Project.toc", "## Interface: 110100")
+	require.NoError(t, err)
+
+	require.NoError(t, toc.Set("Title", "Project"))
+	assert.Equal(t, "## Interface: 110100\n## Title: Project", string(toc.Bytes()))
+}
+
+func TestClone(t *testing.T) {
+	toc, err := parse("Project.toc", fullToc)
+	require.NoError(t, err)
+
+	clone := toc.Clone()
+	require.NoError(t, clone.setInterface([]int{40402}))
+
+	assert.Equal(t, fullToc, string(toc.Bytes()))
+	assert.Equal(t, []int{110100, 11506}, toc.Interface)
+	assert.Equal(t, []int{40402}, clone.Interface)
+	assert.Empty(t, clone.FlavorInterface)
+}
@@ -12,78 +12,213 @@ import (
 )
 
 type Toc struct {
-	Filepath  string
-	Interface []int
-	Title     string
-	Notes     string
-	Version   string
-	Files     []string
-	CurseId   string
-	WowiId    string
-	WagoId    string
-	Flavor    GameFlavor
+	// Lines holds every line of the TOC file, the fields below are derived from it
+	Lines []Line
+	bom   bool
+
+	Filepath        string
+	Interface       []int
+	FlavorInterface map[GameFlavor][]int
+	Title           string
+	Notes           string
+	Version         string
+	Files           []string
+	FileEntries     []FileEntry
+	CurseId         string
+	WowiId          string
+	WagoId          string
+	Flavor          GameFlavor
 }
 
-func (t *Toc) addGameVersionsFromToc() map[GameFlavor][]string {
+func TocFileToGameFlavor(noExt string) (flavor GameFlavor, suffix string) {
+	// The client accepts both separators, only the last one can start a flavor suffix
+	if i := strings.LastIndexAny(noExt, "-_"); i != -1 {
+		suffix = noExt[i+1:]
+	}
+
+	flavor = suffixToGameFlavor(suffix)
+
+	return
+}
+
+func suffixToGameFlavor(suffix string) GameFlavor {
+	if suffix == "" {
+		return registry.Default
+	}
+	for _, f := range registry.Flavors {
+		if slices.ContainsFunc(f.TocSuffixes, func(s string) bool { return strings.EqualFold(s, suffix) }) {
+			return f.Id
+		}
+	}
+	return Unknown
+}
+
+// InterfacesByFlavor groups the Interface values of the TOC by the game flavor they belong to.
+// Flavor-specific keys like `## Interface-Classic:` take precedence over the plain Interface line.
+func (t *Toc) InterfacesByFlavor() map[GameFlavor][]int {
+	byFlavor := make(map[GameFlavor][]int)
 	for _, interfaceVersion := range t.Interface {
-		// Grab the right-most 2 digits for the patch version
-		patchVersion := interfaceVersion % 100
-		// Grab the middle 2 digits for the minor version
-		minorVersion := (interfaceVersion / 100) % 100
-		// Grab the left-most digits for the major version
-		majorVersion := interfaceVersion / 10000
+		flavor := getFlavorFromMajorVersion(interfaceVersion / 10000)
+		if _, ok := t.FlavorInterface[flavor]; ok {
+			continue
+		}
+		byFlavor[flavor] = append(byFlavor[flavor], interfaceVersion)
+	}
+	for flavor, interfaces := range t.FlavorInterface {
+		byFlavor[flavor] = slices.Clone(interfaces)
+	}
+	return byFlavor
+}
+
+func parseInterfaceValues(interfaceLine string) ([]int, error) {
+	var interfaces []int
+	for _, interfaceValue := range strings.Split(interfaceLine, ",") {
+		interfaceValue = strings.TrimSpace(interfaceValue)
+		interfaceVersion, err := strconv.Atoi(interfaceValue)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing Interface version: %v", err)
+		}
+		interfaces = append(interfaces, interfaceVersion)
+	}
+	return interfaces, nil
+}
+
+// setInterface sets the Interface line of the TOC to the given interfaces in place of the first
+// Interface or flavor-specific Interface line and drops the others.
+func (t *Toc) setInterface(interfaces []int) error {
+	interfaceStrings := make([]string, len(interfaces))
+	for i, interfaceVersion := range interfaces {
+		interfaceStrings[i] = strconv.Itoa(interfaceVersion)
+	}
+	interfaceLine := parseLine("## Interface: "+strings.Join(interfaceStrings, ", "), "")
+
+	isInterfaceLine := func(line Line) bool {
+		return line.Kind == MetadataLine && (line.Key == "Interface" || strings.HasPrefix(line.Key, "Interface-"))
+	}
 
-		flavor := getFlavorFromMajorVersion(majorVersion)
-		AddGameVersion(flavor, fmt.Sprintf("%d.%d.%d", majorVersion, minorVersion, patchVersion))
-		AddGameInterface(flavor, strconv.Itoa(interfaceVersion))
+	first := slices.IndexFunc(t.Lines, isInterfaceLine)
+	if first == -1 {
+		interfaceLine.Ending = t.lineEnding()
+		t.Lines = slices.Insert(t.Lines, 0, interfaceLine)
+		return t.index()
 	}
 
-	return gameVersions
+	interfaceLine.Ending = t.Lines[first].Ending
+	rest := slices.DeleteFunc(slices.Clone(t.Lines[first+1:]), isInterfaceLine)
+	t.Lines = slices.Concat(t.Lines[:first], []Line{interfaceLine}, rest)
+
+	return t.index()
 }
 
-func TocFileToGameFlavor(noExt string) (flavor GameFlavor, suffix string) {
-	if strings.Contains(noExt, "-") {
-		postDash := strings.Split(noExt, "-")
-		if len(postDash) > 1 {
-			suffix = postDash[len(postDash)-1]
+// Split writes a flavor-specific copy of the TOC file at tocPath for each game flavor
+// found in the Interface line, e.g. MyAddon.toc -> MyAddon_Mainline.toc, MyAddon_Vanilla.toc.
+// Each copy only lists the interface versions of its own flavor. Flavor files that already
+// exist next to tocPath are left untouched. It returns the paths of the files it created.
+func (t *Toc) Split(tocPath string) (map[GameFlavor]string, error) {
+	splitFiles := make(map[GameFlavor]string)
+
+	byFlavor := t.InterfacesByFlavor()
+	if len(byFlavor) < 2 {
+		return splitFiles, nil
+	}
+
+	contents, err := os.ReadFile(tocPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading TOC file: %v", err)
+	}
+
+	pkgToc, err := parse(tocPath, string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing TOC file: %v", err)
+	}
+
+	noExt := strings.TrimSuffix(tocPath, filepath.Ext(tocPath))
+	for flavor, interfaces := range byFlavor {
+		flavorTocPath := fmt.Sprintf("%s_%s.toc", noExt, flavor.ToTocSuffix())
+		if _, err := os.Stat(flavorTocPath); err == nil {
+			logger.Verbose("%s already exists, not splitting %s for %s", flavorTocPath, tocPath, flavor.ToString())
+			continue
+		}
+
+		flavorToc := pkgToc.Clone()
+		if err := flavorToc.setInterface(interfaces); err != nil {
+			return nil, err
 		}
-	} else if strings.Contains(noExt, "_") {
-		postUnderscore := strings.Split(noExt, "_")
-		if len(postUnderscore) > 1 {
-			suffix = postUnderscore[len(postUnderscore)-1]
+		if err := flavorToc.WriteFile(flavorTocPath); err != nil {
+			return nil, err
 		}
+		splitFiles[flavor] = flavorTocPath
 	}
 
-	normalSuffix := strings.ToLower(suffix)
-
-	switch normalSuffix {
-	case "classic", "vanilla":
-		flavor = ClassicEra
-	case "tbc", "bcc":
-		flavor = TbcClassic
-	case "wrath", "wotlk", "wotlkc":
-		flavor = WotlkClassic
-	case "cata":
-		flavor = CataClassic
-	case "mop":
-		flavor = MopClassic // Just a guess
-	case "wod":
-		flavor = WodClassic
-	case "legion":
-		flavor = LegionClassic
-	case "bfa":
-		flavor = BfaClassic
-	case "sl":
-		flavor = SlClassic
-	case "df":
-		flavor = DfClassic
-	case "", "mainline":
-		flavor = Retail
-	default:
-		flavor = Unknown
+	return splitFiles, nil
+}
+
+// GameFlavors returns the game flavors the TOC file is loaded by. A flavor suffix in the
+// filename wins, otherwise the flavors are derived from the Interface line. Suffixes that
+// aren't a flavor are part of the addon name, e.g. My_Addon.toc.
+func (t *Toc) GameFlavors() []GameFlavor {
+	baseFilename := filepath.Base(t.Filepath)
+	if flavor, suffix := TocFileToGameFlavor(strings.TrimSuffix(baseFilename, filepath.Ext(baseFilename))); suffix != "" && flavor != Unknown {
+		return []GameFlavor{flavor}
 	}
 
-	return
+	var flavors []GameFlavor
+	for flavor := range t.InterfacesByFlavor() {
+		flavors = append(flavors, flavor)
+	}
+	if len(flavors) == 0 {
+		return []GameFlavor{t.Flavor}
+	}
+
+	slices.Sort(flavors)
+
+	return flavors
+}
+
+// tocPathToFilepath converts a TOC file line path, which uses backslashes, to an OS path.
+func tocPathToFilepath(file string) string {
+	return filepath.FromSlash(strings.ReplaceAll(file, "\\", "/"))
+}
+
+// FileFlavors maps the TOC files and every file they load (including XML includes) within
+// pkgDir to the game flavors that load them.
+func FileFlavors(pkgDir string, tocs []*Toc) (map[string][]GameFlavor, error) {
+	fileFlavors := make(map[string][]GameFlavor)
+	add := func(path string, flavors []GameFlavor) {
+		path = filepath.Clean(path)
+		for _, flavor := range flavors {
+			if !slices.Contains(fileFlavors[path], flavor) {
+				fileFlavors[path] = append(fileFlavors[path], flavor)
+			}
+		}
+	}
+
+	for _, t := range tocs {
+		add(filepath.Join(pkgDir, filepath.Base(t.Filepath)), t.GameFlavors())
+		for _, flavor := range t.GameFlavors() {
+			flavors := []GameFlavor{flavor}
+			for _, file := range t.FilesForFlavor(flavor) {
+				filePath := filepath.Join(pkgDir, tocPathToFilepath(file))
+				add(filePath, flavors)
+				if filepath.Ext(filePath) != ".xml" {
+					continue
+				}
+				xmlEntries, err := WalkXmlFile(filePath)
+				if err != nil {
+					return nil, fmt.Errorf("error walking XML file %s: %v", filePath, err)
+				}
+				for _, entry := range xmlEntries {
+					add(entry, flavors)
+				}
+			}
+		}
+	}
+
+	for _, flavors := range fileFlavors {
+		slices.Sort(flavors)
+	}
+
+	return fileFlavors, nil
 }
 
 func FindTocFiles(path string) ([]string, error) {
@@ -104,6 +239,28 @@ func FindTocFiles(path string) ([]string, error) {
 	return tocFiles, nil
 }
 
+// FindAddonDirs returns the folders directly inside path that have a TOC file, for repositories
+// that contain several addons. Hidden folders, like the release directory, are skipped.
+func FindAddonDirs(path string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(path, "*", "*.toc"))
+	if err != nil {
+		return nil, fmt.Errorf("error finding TOC files in %s: %v", path, err)
+	}
+
+	dirs := []string{}
+	for _, match := range matches {
+		dir := filepath.Dir(match)
+		if strings.HasPrefix(filepath.Base(dir), ".") || slices.Contains(dirs, dir) {
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+
+	slices.Sort(dirs)
+
+	return dirs, nil
+}
+
 func DetermineProjectName(tocFiles []string) string {
 	projectName := ""
 	for _, tocFile := range tocFiles {
@@ -126,6 +283,17 @@ func DetermineProjectName(tocFiles []string) string {
 
 	}
 
+	// The separators can also be part of the addon name, e.g. My_Addon.toc
+	if projectName == "" {
+		for _, tocFile := range tocFiles {
+			noExt := strings.TrimSuffix(filepath.Base(tocFile), filepath.Ext(tocFile))
+			if flavor, _ := TocFileToGameFlavor(noExt); flavor == Unknown {
+				projectName = noExt
+				break
+			}
+		}
+	}
+
 	return projectName
 }
 
@@ -134,51 +302,76 @@ func parse(filePath, tocContents string) (*Toc, error) {
 	toc.Filepath = filePath
 	baseFilename := filepath.Base(filePath)
 	toc.Flavor, _ = TocFileToGameFlavor(strings.TrimSuffix(baseFilename, filepath.Ext(baseFilename)))
-	lines := strings.Split(tocContents, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "## Interface:") {
-			interfaceLine := strings.TrimPrefix(line, "## Interface:")
-			interfaceLine = strings.TrimSpace(interfaceLine)
-			interfaceValues := strings.Split(interfaceLine, ",")
-			for _, interfaceValue := range interfaceValues {
-				interfaceValue = strings.TrimSpace(interfaceValue)
-				interfaceVersion, err := strconv.Atoi(interfaceValue)
-				if err != nil {
-					return nil, fmt.Errorf("error parsing Interface version: %v", err)
-				}
-				toc.Interface = append(toc.Interface, interfaceVersion)
+	toc.Lines, toc.bom = parseLines(tocContents)
+
+	if err := toc.index(); err != nil {
+		return nil, err
+	}
+
+	return toc, nil
+}
+
+// index populates the well-known fields of the TOC from its lines.
+func (t *Toc) index() error {
+	t.Interface = nil
+	t.FlavorInterface = nil
+	t.Title, t.Notes, t.Version = "", "", ""
+	t.CurseId, t.WowiId, t.WagoId = "", "", ""
+	t.Files = nil
+	t.FileEntries = nil
+
+	for _, line := range t.Lines {
+		if line.Kind == FileLine {
+			entry := parseFileEntry(line.Value)
+			t.Files = append(t.Files, entry.Path)
+			t.FileEntries = append(t.FileEntries, entry)
+			continue
+		}
+		if line.Kind != MetadataLine {
+			continue
+		}
+
+		switch line.Key {
+		case "Interface":
+			interfaces, err := parseInterfaceValues(line.Value)
+			if err != nil {
+				return err
+			}
+			t.Interface = append(t.Interface, interfaces...)
+		case "Title":
+			t.Title = line.Value
+		case "Notes":
+			t.Notes = line.Value
+		case "Version":
+			t.Version = line.Value
+		case "X-Curse-Project-ID":
+			t.CurseId = line.Value
+		case "X-WoWI-ID":
+			t.WowiId = line.Value
+		case "X-Wago-ID":
+			t.WagoId = line.Value
+		default:
+			suffix, ok := strings.CutPrefix(line.Key, "Interface-")
+			if !ok {
+				continue
 			}
-		} else if strings.HasPrefix(line, "## Title:") {
-			toc.Title = strings.TrimPrefix(line, "## Title:")
-			toc.Title = strings.TrimSpace(toc.Title)
-		} else if strings.HasPrefix(line, "## Notes:") {
-			toc.Notes = strings.TrimPrefix(line, "## Notes:")
-			toc.Notes = strings.TrimSpace(toc.Notes)
-		} else if strings.HasPrefix(line, "## Version:") {
-			toc.Version = strings.TrimPrefix(line, "## Version:")
-			toc.Version = strings.TrimSpace(toc.Version)
-		} else if !strings.HasPrefix(line, "#") {
-			file := strings.TrimSpace(line)
-			if file == "" {
+			flavor := suffixToGameFlavor(suffix)
+			if flavor == Unknown {
+				logger.Warn("Unknown flavor in %s: %s", filepath.Base(t.Filepath), line.Raw)
 				continue
 			}
-			toc.Files = append(toc.Files, file)
-		} else if strings.HasPrefix(line, "## X-Curse-Project-ID:") {
-			toc.CurseId = strings.TrimPrefix(line, "## X-Curse-Project-ID:")
-			toc.CurseId = strings.TrimSpace(toc.CurseId)
-		} else if strings.HasPrefix(line, "## X-WoWI-ID:") {
-			toc.WowiId = strings.TrimPrefix(line, "## X-WoWI-ID:")
-			toc.WowiId = strings.TrimSpace(toc.WowiId)
-		} else if strings.HasPrefix(line, "## X-Wago-ID:") {
-			toc.WagoId = strings.TrimPrefix(line, "## X-Wago-ID:")
-			toc.WagoId = strings.TrimSpace(toc.WagoId)
+			interfaces, err := parseInterfaceValues(line.Value)
+			if err != nil {
+				return err
+			}
+			if t.FlavorInterface == nil {
+				t.FlavorInterface = make(map[GameFlavor][]int)
+			}
+			t.FlavorInterface[flavor] = append(t.FlavorInterface[flavor], interfaces...)
 		}
 	}
 
-	toc.addGameVersionsFromToc()
-
-	return toc, nil
+	return nil
 }
 
 func GetTocFileTree(path string) ([]string, error) {
@@ -193,8 +386,10 @@ func GetTocFileTree(path string) ([]string, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error creating TOC object: %v", err)
 		}
-		for _, file := range toc.Files {
-			coveredFilesSet[filepath.Join(path, file)] = true
+		for _, flavor := range toc.GameFlavors() {
+			for _, file := range toc.FilesForFlavor(flavor) {
+				coveredFilesSet[filepath.Join(path, tocPathToFilepath(file))] = true
+			}
 		}
 	}
 
@@ -1,6 +1,10 @@
 package toc
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -17,6 +21,11 @@ func TestTocFileToGameFlavor(t *testing.T) {
 		{"TestAddon_Wotlk", WotlkClassic},
 		{"TestAddon_Cata", CataClassic},
 		{"TestAddon_Mop", MopClassic},
+		{"TestAddon_Mists", MopClassic},
+		{"TestAddon-TBC", TbcClassic},
+		{"TestAddon_mainline", Retail},
+		{"Test-Addon_Vanilla", ClassicEra},
+		{"Test_Addon-Cata", CataClassic},
 		{"TestAddon_Wod", WodClassic},
 		{"TestAddon_Legion", LegionClassic},
 		{"TestAddon_Bfa", BfaClassic},
@@ -30,7 +39,7 @@ func TestTocFileToGameFlavor(t *testing.T) {
 	for _, test := range tests {
 		result, _ := TocFileToGameFlavor(test.suffix)
 		if result != test.expected {
-			t.Errorf("For suffix %s, expected %d, but got %d", test.suffix, test.expected, result)
+			t.Errorf("For suffix %s, expected %q, but got %q", test.suffix, test.expected, result)
 		}
 	}
 }
@@ -51,6 +60,35 @@ func TestFindTocFiles(t *testing.T) {
 	}
 }
 
+func TestFindAddonDirs(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{
+		"Core/Core.toc",
+		"Core/Core_Vanilla.toc",
+		"Plugin/Plugin.toc",
+		"Media/Logo.tga",
+		".release/Core/Core.toc",
+	} {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := FindAddonDirs(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{filepath.Join(dir, "Core"), filepath.Join(dir, "Plugin")}
+	if !slices.Equal(result, expected) {
+		t.Errorf("Expected %v, but got %v", expected, result)
+	}
+}
+
 func TestDetermineProjectName(t *testing.T) {
 	tests := []struct {
 		tocFiles []string
@@ -60,7 +98,10 @@ func TestDetermineProjectName(t *testing.T) {
 		{[]string{"./testdata/Project-BCC.toc"}, "Project"},
 		{[]string{"./testdata/Project-WotLK.toc"}, "Project"},
 		{[]string{"./testdata/Project.toc"}, "Project"},
-		{[]string{"./testdata/Project-Unknown.toc"}, ""},
+		// A suffix that isn't a flavor is part of the addon name
+		{[]string{"./testdata/Project-Unknown.toc"}, "Project-Unknown"},
+		{[]string{"My_Addon_Vanilla.toc", "My_Addon.toc"}, "My_Addon"},
+		{[]string{"Project_Options.toc", "Project.toc"}, "Project"},
 	}
 
 	for _, test := range tests {
@@ -70,3 +111,204 @@ func TestDetermineProjectName(t *testing.T) {
 		}
 	}
 }
+
+func TestSplit(t *testing.T) {
+	dir := t.TempDir()
+	tocPath := filepath.Join(dir, "Project.toc")
+	contents := "## Interface: 11506, 50500, 110100\n## Title: Project\n\nProject.lua\n"
+	if err := os.WriteFile(tocPath, []byte(contents), 0644); err != nil {
+		t.Fatalf("Failed to write TOC file: %v", err)
+	}
+	// An existing flavor file should be left alone
+	existing := filepath.Join(dir, "Project_Mists.toc")
+	if err := os.WriteFile(existing, []byte("## Interface: 50500\n"), 0644); err != nil {
+		t.Fatalf("Failed to write TOC file: %v", err)
+	}
+
+	toc := &Toc{Filepath: tocPath, Interface: []int{11506, 50500, 110100}}
+	splitFiles, err := toc.Split(tocPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[GameFlavor]string{
+		ClassicEra: "## Interface: 11506\n",
+		Retail:     "## Interface: 110100\n",
+	}
+	if len(splitFiles) != len(expected) {
+		t.Fatalf("Expected %d split files, but got %d", len(expected), len(splitFiles))
+	}
+
+	for flavor, interfaceLine := range expected {
+		path, ok := splitFiles[flavor]
+		if !ok {
+			t.Errorf("Expected split file for %s", flavor.ToString())
+			continue
+		}
+		result, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("Failed to read split file %s: %v", path, err)
+			continue
+		}
+		if !strings.HasPrefix(string(result), interfaceLine) {
+			t.Errorf("For %s, expected %q, but got %q", path, interfaceLine, string(result))
+		}
+		if !strings.Contains(string(result), "Project.lua") {
+			t.Errorf("For %s, expected file list to be preserved", path)
+		}
+	}
+
+	result, _ := os.ReadFile(existing)
+	if string(result) != "## Interface: 50500\n" {
+		t.Errorf("Expected existing flavor file to be untouched, but got %q", string(result))
+	}
+}
+
+func TestSplitSingleFlavor(t *testing.T) {
+	toc := &Toc{Interface: []int{110100, 110105}}
+	splitFiles, err := toc.Split(filepath.Join(t.TempDir(), "Project.toc"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(splitFiles) != 0 {
+		t.Errorf("Expected no split files, but got %d", len(splitFiles))
+	}
+}
+
+func TestGameFlavors(t *testing.T) {
+	tests := []struct {
+		toc      *Toc
+		expected []GameFlavor
+	}{
+		{&Toc{Filepath: "Project_Vanilla.toc", Interface: []int{110100}}, []GameFlavor{ClassicEra}},
+		{&Toc{Filepath: "Project.toc", Interface: []int{110100, 11506}}, []GameFlavor{ClassicEra, Retail}},
+		{&Toc{Filepath: "Project.toc", Flavor: Retail}, []GameFlavor{Retail}},
+		{&Toc{Filepath: "My_Addon.toc", Interface: []int{110100, 11506}}, []GameFlavor{ClassicEra, Retail}},
+		{&Toc{Filepath: "Foo-Bar.toc", Interface: []int{110100}}, []GameFlavor{Retail}},
+		{&Toc{Filepath: "My_Addon_Vanilla.toc", Interface: []int{110100}}, []GameFlavor{ClassicEra}},
+	}
+
+	for _, test := range tests {
+		result := test.toc.GameFlavors()
+		if !slices.Equal(result, test.expected) {
+			t.Errorf("For TOC %s, expected %v, but got %v", test.toc.Filepath, test.expected, result)
+		}
+	}
+}
+
+func TestFileFlavors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Shared.xml"), []byte("<Ui>\n<Script file=\"Included.lua\"/>\n</Ui>\n"), 0644); err != nil {
+		t.Fatalf("Failed to write XML file: %v", err)
+	}
+
+	mainline, err := parse("Project_Mainline.toc", "Shared.xml\nRetail.lua\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	vanilla, err := parse("Project_Vanilla.toc", "Shared.xml\nClassic\\Classic.lua\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tocs := []*Toc{mainline, vanilla}
+
+	result, err := FileFlavors(dir, tocs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string][]GameFlavor{
+		"Project_Mainline.toc":                  {Retail},
+		"Project_Vanilla.toc":                   {ClassicEra},
+		"Shared.xml":                            {ClassicEra, Retail},
+		"Included.lua":                          {ClassicEra, Retail},
+		"Retail.lua":                            {Retail},
+		filepath.Join("Classic", "Classic.lua"): {ClassicEra},
+	}
+	if len(result) != len(expected) {
+		t.Errorf("Expected %d files, but got %d: %v", len(expected), len(result), result)
+	}
+	for file, flavors := range expected {
+		if !slices.Equal(result[filepath.Join(dir, file)], flavors) {
+			t.Errorf("For %s, expected %v, but got %v", file, flavors, result[filepath.Join(dir, file)])
+		}
+	}
+}
+
+func TestFileFlavors_SeparatorInName(t *testing.T) {
+	dir := t.TempDir()
+	base, err := parse("My_Addon.toc", "## Interface: 110100\n\nRetail.lua\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	vanilla, err := parse("My_Addon_Vanilla.toc", "## Interface: 11506\n\nClassic.lua\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := FileFlavors(dir, []*Toc{base, vanilla})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string][]GameFlavor{
+		"Retail.lua":  {Retail},
+		"Classic.lua": {ClassicEra},
+	}
+	for file, flavors := range expected {
+		if !slices.Equal(result[filepath.Join(dir, file)], flavors) {
+			t.Errorf("For %s, expected %v, but got %v", file, flavors, result[filepath.Join(dir, file)])
+		}
+	}
+}
+
+func TestParseFlavorInterface(t *testing.T) {
+	contents := "## Interface: 110100\n## Interface-Classic: 11506\n## Interface-Cata: 40402, 40401\n## Title: Project\n"
+	toc, err := parse("Project.toc", contents)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[GameFlavor][]int{
+		Retail:      {110100},
+		ClassicEra:  {11506},
+		CataClassic: {40402, 40401},
+	}
+	result := toc.InterfacesByFlavor()
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d flavors, but got %d: %v", len(expected), len(result), result)
+	}
+	for flavor, interfaces := range expected {
+		if !slices.Equal(result[flavor], interfaces) {
+			t.Errorf("For %s, expected %v, but got %v", flavor.ToString(), interfaces, result[flavor])
+		}
+	}
+
+	if _, err := parse("Project.toc", "## Interface-Classic: abc\n"); err == nil {
+		t.Errorf("Expected error for invalid flavor interface, but got nil")
+	}
+}
+
+func TestSplitFlavorInterface(t *testing.T) {
+	dir := t.TempDir()
+	tocPath := filepath.Join(dir, "Project.toc")
+	contents := "## Interface-Classic: 11506\r\n## Interface-Mainline: 110100\r\n## Title: Project\r\n"
+	if err := os.WriteFile(tocPath, []byte(contents), 0644); err != nil {
+		t.Fatalf("Failed to write TOC file: %v", err)
+	}
+
+	toc := &Toc{FlavorInterface: map[GameFlavor][]int{ClassicEra: {11506}, Retail: {110100}}}
+	splitFiles, err := toc.Split(tocPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := os.ReadFile(splitFiles[ClassicEra])
+	if err != nil {
+		t.Fatalf("Failed to read split file: %v", err)
+	}
+	expected := "## Interface: 11506\r\n## Title: Project\r\n"
+	if string(result) != expected {
+		t.Errorf("Expected %q, but got %q", expected, string(result))
+	}
+}
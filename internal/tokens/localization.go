@@ -0,0 +1,191 @@
+package tokens
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LocalizationRegex matches `@localization(...)@` tokens, optionally written as a Lua comment
+// (`--@localization(...)@` or `--@localization(...)@--`).
+var LocalizationRegex = regexp.MustCompile(`(?:--)?@localization\(([^)]*)\)@(?:--)?`)
+
+type LocalizationFormat string
+
+const (
+	LuaAdditiveTable LocalizationFormat = "lua_additive_table"
+	LuaTable         LocalizationFormat = "lua_table"
+)
+
+type UnlocalizedHandling string
+
+const (
+	UnlocalizedEnglish UnlocalizedHandling = "english"
+	UnlocalizedComment UnlocalizedHandling = "comment"
+	UnlocalizedBlank   UnlocalizedHandling = "blank"
+	UnlocalizedIgnore  UnlocalizedHandling = "ignore"
+)
+
+type SubnamespaceHandling string
+
+const (
+	SubnamespacesNone     SubnamespaceHandling = "none"
+	SubnamespacesConcat   SubnamespaceHandling = "concat"
+	SubnamespacesSubtable SubnamespaceHandling = "subtable"
+)
+
+// LocalizationToken holds the keyword parameters of an `@localization(...)@` token.
+type LocalizationToken struct {
+	Locale              string
+	Format              LocalizationFormat
+	HandleUnlocalized   UnlocalizedHandling
+	HandleSubnamespaces SubnamespaceHandling
+	EscapeNonAscii      bool
+	SameKeyIsTrue       bool
+	Namespace           string
+	TableName           string
+	// Key exports a single string instead of a table
+	Key string
+}
+
+// splitLocalizationParams splits the parameters on commas outside of quotes.
+func splitLocalizationParams(params string) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+	inQuotes := false
+	for _, r := range params {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case r == ',' && !inQuotes:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in localization parameters: %s", params)
+	}
+	parts = append(parts, current.String())
+	return parts, nil
+}
+
+// ParseLocalizationToken parses the parameters of a localization token, e.g.
+// `locale="enUS", format="lua_additive_table", same-key-is-true=true`.
+func ParseLocalizationToken(params string) (*LocalizationToken, error) {
+	t := &LocalizationToken{
+		Format:            LuaAdditiveTable,
+		HandleUnlocalized: UnlocalizedEnglish,
+		TableName:         "L",
+	}
+
+	parts, err := splitLocalizationParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid localization parameter: %s", part)
+		}
+		name = strings.TrimSpace(name)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch name {
+		case "locale":
+			t.Locale = value
+		case "format":
+			switch LocalizationFormat(value) {
+			case LuaAdditiveTable, LuaTable:
+				t.Format = LocalizationFormat(value)
+			default:
+				return nil, fmt.Errorf("unknown localization format: %s", value)
+			}
+		case "handle-unlocalized":
+			switch UnlocalizedHandling(value) {
+			case UnlocalizedEnglish, UnlocalizedComment, UnlocalizedBlank, UnlocalizedIgnore:
+				t.HandleUnlocalized = UnlocalizedHandling(value)
+			default:
+				return nil, fmt.Errorf("unknown handle-unlocalized value: %s", value)
+			}
+		case "handle-subnamespaces":
+			switch SubnamespaceHandling(value) {
+			case SubnamespacesNone, SubnamespacesConcat, SubnamespacesSubtable:
+				t.HandleSubnamespaces = SubnamespaceHandling(value)
+			default:
+				return nil, fmt.Errorf("unknown handle-subnamespaces value: %s", value)
+			}
+		case "escape-non-ascii":
+			if t.EscapeNonAscii, err = strconv.ParseBool(value); err != nil {
+				return nil, fmt.Errorf("invalid escape-non-ascii value: %s", value)
+			}
+		case "same-key-is-true":
+			if t.SameKeyIsTrue, err = strconv.ParseBool(value); err != nil {
+				return nil, fmt.Errorf("invalid same-key-is-true value: %s", value)
+			}
+		case "namespace":
+			t.Namespace = value
+		case "table-name":
+			t.TableName = value
+		case "key":
+			t.Key = value
+		default:
+			return nil, fmt.Errorf("unknown localization parameter: %s", name)
+		}
+	}
+
+	return t, nil
+}
+
+// Query returns the parameters of the CurseForge localization export endpoint for the token.
+func (t *LocalizationToken) Query() url.Values {
+	q := url.Values{}
+	if t.Locale != "" {
+		q.Set("lang", t.Locale)
+	}
+
+	if t.Format == LuaTable {
+		q.Set("export-type", "Table")
+	} else {
+		q.Set("export-type", "TableAdditions")
+	}
+
+	switch t.HandleUnlocalized {
+	case UnlocalizedComment:
+		q.Set("unlocalized", "ShowPrimaryAsComment")
+	case UnlocalizedBlank:
+		q.Set("unlocalized", "ShowBlankAsComment")
+	case UnlocalizedIgnore:
+		q.Set("unlocalized", "Ignore")
+	default:
+		q.Set("unlocalized", "ShowPrimary")
+	}
+
+	if t.HandleSubnamespaces == SubnamespacesConcat {
+		q.Set("concatenante-subnamespaces", "true")
+	}
+	if t.EscapeNonAscii {
+		q.Set("escape-non-ascii-characters", "true")
+	}
+	if t.SameKeyIsTrue {
+		q.Set("true-if-value-equals-key", "true")
+	}
+	if t.Namespace != "" {
+		q.Set("namespaces", t.Namespace)
+	}
+	if t.TableName != "" && t.TableName != "L" {
+		q.Set("table-name", t.TableName)
+	}
+
+	return q
+}
@@ -0,0 +1,88 @@
+package tokens
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocalizationToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      string
+		expected    *LocalizationToken
+		expectedUrl string
+		expectError bool
+	}{
+		{
+			name:   "Defaults",
+			params: `locale="enUS"`,
+			expected: &LocalizationToken{
+				Locale:            "enUS",
+				Format:            LuaAdditiveTable,
+				HandleUnlocalized: UnlocalizedEnglish,
+				TableName:         "L",
+			},
+			expectedUrl: "export-type=TableAdditions&lang=enUS&unlocalized=ShowPrimary",
+		},
+		{
+			name:   "Every parameter",
+			params: `locale="deDE", format="lua_table", handle-unlocalized="comment", handle-subnamespaces="concat", escape-non-ascii=true, same-key-is-true=true, namespace="Options/General", table-name="Locale"`,
+			expected: &LocalizationToken{
+				Locale:              "deDE",
+				Format:              LuaTable,
+				HandleUnlocalized:   UnlocalizedComment,
+				HandleSubnamespaces: SubnamespacesConcat,
+				EscapeNonAscii:      true,
+				SameKeyIsTrue:       true,
+				Namespace:           "Options/General",
+				TableName:           "Locale",
+			},
+			expectedUrl: "concatenante-subnamespaces=true&escape-non-ascii-characters=true&export-type=Table&lang=deDE&namespaces=Options%2FGeneral&table-name=Locale&true-if-value-equals-key=true&unlocalized=ShowPrimaryAsComment",
+		},
+		{
+			name:   "Quoted comma and single key",
+			params: `locale="frFR",namespace="A,B", key="Hello", handle-unlocalized="ignore"`,
+			expected: &LocalizationToken{
+				Locale:            "frFR",
+				Format:            LuaAdditiveTable,
+				HandleUnlocalized: UnlocalizedIgnore,
+				Namespace:         "A,B",
+				TableName:         "L",
+				Key:               "Hello",
+			},
+			expectedUrl: "export-type=TableAdditions&lang=frFR&namespaces=A%2CB&unlocalized=Ignore",
+		},
+		{name: "Unknown parameter", params: `locale="enUS", colour="red"`, expectError: true},
+		{name: "Unknown format", params: `format="json"`, expectError: true},
+		{name: "Invalid boolean", params: `same-key-is-true=maybe`, expectError: true},
+		{name: "Missing value", params: `locale`, expectError: true},
+		{name: "Unterminated quote", params: `locale="enUS`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := ParseLocalizationToken(tt.params)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, token)
+			assert.Equal(t, tt.expectedUrl, token.Query().Encode())
+		})
+	}
+}
+
+func TestLocalizationRegex(t *testing.T) {
+	line := `--@localization(locale="enUS", format="lua_additive_table")@--`
+	m := LocalizationRegex.FindStringSubmatch(line)
+	require.NotNil(t, m)
+	assert.Equal(t, line, m[0])
+	assert.Equal(t, `locale="enUS", format="lua_additive_table"`, m[1])
+
+	m = LocalizationRegex.FindStringSubmatch(`L["Hello"] = @localization(key="Hello")@`)
+	require.NotNil(t, m)
+	assert.Equal(t, `@localization(key="Hello")@`, m[0])
+}
@@ -12,9 +12,9 @@ import (
 	"slices"
 	"time"
 
+	"github.com/McTalian/wow-build-tools/internal/api"
 	"github.com/McTalian/wow-build-tools/internal/changelog"
 	"github.com/McTalian/wow-build-tools/internal/logger"
-	"github.com/McTalian/wow-build-tools/internal/pkg"
 	"github.com/McTalian/wow-build-tools/internal/toc"
 )
 
@@ -23,18 +23,9 @@ var ErrMultipleCurseIds = fmt.Errorf("multiple Curse IDs found")
 var ErrNoCurseUpload = fmt.Errorf("CurseForge upload is disabled")
 var ErrNoCurseApiKey = fmt.Errorf("CF_API_KEY not set")
 
-var curseApiUrl = "https://wow.curseforge.com/api/"
-var curseGameVersionsUrl = fmt.Sprintf("%sgame/wow/versions", curseApiUrl)
-
-// type curseGameVersionTypeId int
-
-// const (
-// 	classic curseGameVersionTypeId = 67408
-// 	bcc     curseGameVersionTypeId = 73246
-// 	wrath   curseGameVersionTypeId = 73713
-// 	cata    curseGameVersionTypeId = 77522
-// 	retail  curseGameVersionTypeId = 517
-// )
+func curseGameVersionsUrl() string {
+	return fmt.Sprintf("%sgame/wow/versions", api.Current().CurseApiUrl)
+}
 
 type curseReleaseType string
 
@@ -109,6 +100,7 @@ type curseUpload struct {
 	gameVersions []int
 	releaseType  curseReleaseType
 	changelog    *changelog.Changelog
+	plan         *UploadPlan
 	logGroup     *logger.LogGroup
 }
 
@@ -154,30 +146,30 @@ func (c *curseUpload) lookupCurseToken() (err error) {
 	return
 }
 
-func (c *curseUpload) preparePayload(pkgMeta *pkg.PkgMeta) (err error) {
+func (c *curseUpload) preparePayload(relations Relations) (err error) {
 	projects := make([]curseProjectRelationship, 0)
-	for _, embed := range pkgMeta.EmbeddedLibraries {
+	for _, embed := range relations.EmbeddedLibraries {
 		projects = append(projects, curseProjectRelationship{
 			Slug: embed,
 			Type: EmbeddedLibrary,
 		})
 	}
 
-	for _, tool := range pkgMeta.ToolsUsed {
+	for _, tool := range relations.ToolsUsed {
 		projects = append(projects, curseProjectRelationship{
 			Slug: tool,
 			Type: Tool,
 		})
 	}
 
-	for _, reqDep := range pkgMeta.RequiredDependencies {
+	for _, reqDep := range relations.RequiredDependencies {
 		projects = append(projects, curseProjectRelationship{
 			Slug: reqDep,
 			Type: RequiredDependency,
 		})
 	}
 
-	for _, optDep := range pkgMeta.OptionalDependencies {
+	for _, optDep := range relations.OptionalDependencies {
 		projects = append(projects, curseProjectRelationship{
 			Slug: optDep,
 			Type: OptionalDependency,
@@ -214,8 +206,25 @@ func (c *curseUpload) preparePayload(pkgMeta *pkg.PkgMeta) (err error) {
 	return
 }
 
-func (c *curseUpload) validateGameVersions(gameVersions []string) (err error) {
-	req, err := http.NewRequest("GET", curseGameVersionsUrl, nil)
+// curseVersionTypes maps game versions to the CurseForge game version types of the flavors they belong to.
+func curseVersionTypes(flavorVersions toc.GameVersions) map[string][]int {
+	versionTypes := make(map[string][]int)
+	for flavor, versions := range flavorVersions {
+		f, ok := toc.LookupFlavor(flavor)
+		if !ok || f.CurseVersionType == 0 {
+			continue
+		}
+		for _, version := range versions {
+			versionTypes[version] = append(versionTypes[version], f.CurseVersionType)
+		}
+	}
+	return versionTypes
+}
+
+func (c *curseUpload) validateGameVersions(gameVersionSet *toc.GameVersionSet) (err error) {
+	gameVersions := gameVersionSet.Versions()
+
+	req, err := http.NewRequest("GET", curseGameVersionsUrl(), nil)
 	if err != nil {
 		c.logGroup.Error("Could not fetch game versions: %v", err)
 		return
@@ -224,7 +233,7 @@ func (c *curseUpload) validateGameVersions(gameVersions []string) (err error) {
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("x-api-token", c.token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := api.Client().Do(req)
 	if err != nil {
 		c.logGroup.Error("Could not fetch game versions: %v", err)
 		return
@@ -249,7 +258,11 @@ func (c *curseUpload) validateGameVersions(gameVersions []string) (err error) {
 		missingVersions[version] = true
 	}
 
+	versionTypes := curseVersionTypes(gameVersionSet.FlavorVersions())
 	for _, version := range versions {
+		if types, ok := versionTypes[version.Name]; ok && !slices.Contains(types, version.GameVersionTypeID) {
+			continue
+		}
 		if slices.Contains(gameVersions, version.Name) {
 			c.gameVersions = append(c.gameVersions, version.ID)
 			missingVersions[version.Name] = false
@@ -275,6 +288,18 @@ func (c *curseUpload) validateGameVersions(gameVersions []string) (err error) {
 }
 
 func (c *curseUpload) upload() (err error) {
+	if c.plan != nil {
+		c.plan.Add(PlannedRequest{
+			Publisher: PublisherCurse,
+			Method:    "POST",
+			URL:       c.uploadUrl,
+			Fields:    map[string]any{"metadata": json.RawMessage(c.metadataPart)},
+			Files:     map[string]string{"file": c.zipFile},
+		})
+		c.logGroup.Info("Planned the CurseForge upload (dry run)")
+		return nil
+	}
+
 	c.logGroup.Info("Uploading to CurseForge")
 
 	// Open the zip file to upload
@@ -320,7 +345,7 @@ func (c *curseUpload) upload() (err error) {
 	req.Header.Set("x-api-token", c.token) // Adjust this header key if needed
 
 	// Prepare the HTTP client and exponential backoff parameters
-	client := &http.Client{}
+	client := api.Client()
 	maxAttempts := 5
 	delay := 2 * time.Second
 
@@ -391,14 +416,17 @@ func getCurseId(tocFiles []*toc.Toc, curseIdFlag string) (curseId string, err er
 
 type UploadCurseArgs struct {
 	TocFiles         []*toc.Toc
+	GameVersions     *toc.GameVersionSet
 	CurseId          string
 	ZipPath          string
 	FileLabel        string
-	PkgMeta          *pkg.PkgMeta
+	Relations        Relations
 	Changelog        *changelog.Changelog
 	ReleaseType      string
 	SkipUpload       bool
 	OnlyLocalization bool
+	// Plan records the upload instead of sending it when set
+	Plan *UploadPlan
 }
 
 func UploadToCurse(args UploadCurseArgs) error {
@@ -411,7 +439,6 @@ func UploadToCurse(args UploadCurseArgs) error {
 	}
 
 	tocFiles := args.TocFiles
-	pkgMeta := args.PkgMeta
 
 	curseId, err := getCurseId(tocFiles, args.CurseId)
 	if err != nil {
@@ -437,27 +464,28 @@ func UploadToCurse(args UploadCurseArgs) error {
 
 	curseUpload := curseUpload{
 		projectId:   curseId,
-		uploadUrl:   fmt.Sprintf("%sprojects/%s/upload-file", curseApiUrl, curseId),
+		uploadUrl:   fmt.Sprintf("%sprojects/%s/upload-file", api.Current().CurseApiUrl, curseId),
 		zipFile:     args.ZipPath,
 		displayName: args.FileLabel,
 		changelog:   args.Changelog,
 		releaseType: releaseType,
+		plan:        args.Plan,
 		logGroup:    logGroup,
 	}
 
 	if err := curseUpload.lookupCurseToken(); err != nil {
-		logGroup.Info("Skipping CurseForge upload: %s", err)
-		return nil
-	}
-
-	gameVersions := toc.GetGameVersions()
-
-	if err := curseUpload.validateGameVersions(gameVersions); err != nil {
+		if args.Plan == nil {
+			logGroup.Info("Skipping CurseForge upload: %s", err)
+			return nil
+		}
+		// The game versions can only be looked up with a token
+		logGroup.Warn("%s, planning the CurseForge upload without game versions", err)
+	} else if err := curseUpload.validateGameVersions(args.GameVersions); err != nil {
 		logGroup.Error("Could not validate game versions: %v", err)
 		return err
 	}
 
-	if err := curseUpload.preparePayload(pkgMeta); err != nil {
+	if err := curseUpload.preparePayload(args.Relations); err != nil {
 		return err
 	}
 
@@ -467,3 +495,40 @@ func UploadToCurse(args UploadCurseArgs) error {
 
 	return nil
 }
+
+// CursePublisher publishes releases to CurseForge.
+type CursePublisher struct{}
+
+func (p *CursePublisher) Name() string { return PublisherCurse }
+
+func (p *CursePublisher) Enabled(a *ReleaseArtifact) bool {
+	if _, err := getCurseId(a.TocFiles, a.ProjectIds[PublisherCurse]); err == ErrNoCurseId {
+		return false
+	}
+	if _, found := os.LookupEnv("CF_API_KEY"); !found && !a.DryRun() {
+		logger.Info("Skipping CurseForge upload: %s", ErrNoCurseApiKey)
+		return false
+	}
+	return true
+}
+
+func (p *CursePublisher) Validate(a *ReleaseArtifact) error {
+	if _, err := getCurseId(a.TocFiles, a.ProjectIds[PublisherCurse]); err != nil {
+		return err
+	}
+	return a.validateZips()
+}
+
+func (p *CursePublisher) Publish(a *ReleaseArtifact) error {
+	return UploadToCurse(UploadCurseArgs{
+		TocFiles:     a.TocFiles,
+		GameVersions: a.GameVersions,
+		CurseId:      a.ProjectIds[PublisherCurse],
+		ZipPath:      a.ZipPath,
+		FileLabel:    a.FileLabel,
+		Relations:    a.Relations,
+		Changelog:    a.Changelog,
+		ReleaseType:  a.ReleaseType,
+		Plan:         a.Plan,
+	})
+}
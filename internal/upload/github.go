@@ -1,6 +1,7 @@
 package upload
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,27 +14,27 @@ import (
 	"github.com/McTalian/wow-build-tools/internal/toc"
 )
 
-func shouldSkip(repo repo.VcsRepo, logGroup *logger.LogGroup) bool {
-	if !repo.IsGitHubHosted() {
-		logGroup.Verbose("Repository is not hosted on GitHub, skipping")
-		return true
-	}
-
-	if repo.GetCurrentTag() == "" {
-		logGroup.Verbose("No current tag found, skipping")
-		return true
-	}
-
-	if repo.GetGitHubSlug() == "" {
-		logGroup.Verbose("No GitHub slug found, skipping")
-		return true
+// skipReason returns why no GitHub release can be created for the repository, or an empty
+// string when one can. The token is only needed when the release is actually created.
+func skipReason(repo repo.VcsRepo, requireToken bool) string {
+	switch {
+	case repo == nil || !repo.IsGitHubHosted():
+		return "Repository is not hosted on GitHub"
+	case repo.GetCurrentTag() == "":
+		return "No current tag found"
+	case repo.GetGitHubSlug() == "":
+		return "No GitHub slug found"
+	case requireToken && !github.IsTokenSet():
+		return "GITHUB_OAUTH not set"
 	}
+	return ""
+}
 
-	if !github.IsTokenSet() {
-		logGroup.Verbose("GITHUB_OAUTH not set, skipping")
+func shouldSkip(repo repo.VcsRepo, requireToken bool, logGroup *logger.LogGroup) bool {
+	if reason := skipReason(repo, requireToken); reason != "" {
+		logGroup.Verbose("%s, skipping", reason)
 		return true
 	}
-
 	return false
 }
 
@@ -76,11 +77,66 @@ func GetOrCreateRelease(repo repo.VcsRepo, prerelease bool, changelogContents st
 
 type UploadGitHubArgs struct {
 	ProjectName    string
+	GameVersions   *toc.GameVersionSet
 	ProjectVersion string
 	Repo           repo.VcsRepo
 	ZipPaths       []string
 	Changelog      *changelog.Changelog
 	ReleaseType    string
+	// Plan records the uploads instead of sending them when set
+	Plan *UploadPlan
+}
+
+// planGitHubUpload records the requests that create or update the release and upload its
+// assets. The release is only looked up when GITHUB_OAUTH is set, and the id of a release that
+// would be created isn't known yet.
+func planGitHubUpload(args UploadGitHubArgs, payload github.GitHubReleasePayload, releaseFileContents string, logGroup *logger.LogGroup) {
+	slug := args.Repo.GetGitHubSlug()
+	releaseId := 0
+	releaseRequest := PlannedRequest{
+		Publisher: PublisherGitHub,
+		Method:    "POST",
+		URL:       github.ReleasesUrl(slug),
+		Payload:   payload,
+	}
+
+	if github.IsTokenSet() {
+		release, err := github.GetRelease(slug, payload.TagName)
+		if err != nil && err != github.ErrReleaseNotFound {
+			logGroup.Warn("Could not get the release, planning to create it: %v", err)
+		} else if release != nil {
+			releaseId = release.Id
+			releaseRequest.Method = "PATCH"
+			releaseRequest.URL = github.ReleaseUrl(slug, releaseId)
+		}
+	} else {
+		logGroup.Warn("GITHUB_OAUTH not set, planning to create the release")
+	}
+	args.Plan.Add(releaseRequest)
+
+	note := ""
+	if releaseId == 0 {
+		note = "the release id is only known once the release is created"
+	}
+
+	args.Plan.Add(PlannedRequest{
+		Publisher: PublisherGitHub,
+		Method:    "POST",
+		URL:       github.AssetUploadUrl(slug, releaseId, "release.json"),
+		Payload:   json.RawMessage(releaseFileContents),
+		Note:      note,
+	})
+	for _, zipPath := range args.ZipPaths {
+		args.Plan.Add(PlannedRequest{
+			Publisher: PublisherGitHub,
+			Method:    "POST",
+			URL:       github.AssetUploadUrl(slug, releaseId, filepath.Base(zipPath)),
+			Files:     map[string]string{"body": zipPath},
+			Note:      note,
+		})
+	}
+
+	logGroup.Info("Planned the GitHub release of %s (dry run)", payload.TagName)
 }
 
 func UploadToGitHub(args UploadGitHubArgs) error {
@@ -90,7 +146,7 @@ func UploadToGitHub(args UploadGitHubArgs) error {
 
 	repo := args.Repo
 
-	if shouldSkip(repo, logGroup) {
+	if shouldSkip(repo, args.Plan == nil, logGroup) {
 		return nil
 	}
 
@@ -112,13 +168,7 @@ func UploadToGitHub(args UploadGitHubArgs) error {
 		return err
 	}
 
-	release, err := GetOrCreateRelease(repo, prerelease, string(changelogContents), logGroup)
-	if err != nil {
-		logGroup.Error("Could not get or create the release: %v", err)
-		return err
-	}
-
-	gameVersions := toc.GetGameFlavorInterfacesMap()
+	gameVersions := args.GameVersions.FlavorInterfaces()
 
 	releaseFileContents, err := github.GetReleaseMetadataContents(
 		args.ProjectName,
@@ -127,6 +177,26 @@ func UploadToGitHub(args UploadGitHubArgs) error {
 		args.ZipPaths...,
 	)
 
+	if args.Plan != nil {
+		if err != nil {
+			return fmt.Errorf("could not create the release metadata: %w", err)
+		}
+		planGitHubUpload(args, github.GitHubReleasePayload{
+			TagName:    repo.GetCurrentTag(),
+			Name:       repo.GetCurrentTag(),
+			Prerelease: prerelease,
+			Body:       string(changelogContents),
+			Draft:      false,
+		}, releaseFileContents, logGroup)
+		return nil
+	}
+
+	release, err := GetOrCreateRelease(repo, prerelease, string(changelogContents), logGroup)
+	if err != nil {
+		logGroup.Error("Could not get or create the release: %v", err)
+		return err
+	}
+
 	tmpDir := os.TempDir()
 	releaseFile, err := os.CreateTemp(tmpDir, "release-metadata-*.json")
 	if err != nil {
@@ -191,3 +261,33 @@ func UploadToGitHub(args UploadGitHubArgs) error {
 
 	return nil
 }
+
+// GitHubPublisher attaches releases to the GitHub release of the current tag.
+type GitHubPublisher struct{}
+
+func (p *GitHubPublisher) Name() string { return PublisherGitHub }
+
+func (p *GitHubPublisher) Enabled(a *ReleaseArtifact) bool {
+	if reason := skipReason(a.Repo, !a.DryRun()); reason != "" {
+		logger.Verbose("Skipping GitHub upload: %s", reason)
+		return false
+	}
+	return true
+}
+
+func (p *GitHubPublisher) Validate(a *ReleaseArtifact) error {
+	return a.validateZips()
+}
+
+func (p *GitHubPublisher) Publish(a *ReleaseArtifact) error {
+	return UploadToGitHub(UploadGitHubArgs{
+		ProjectName:    a.ProjectName,
+		GameVersions:   a.GameVersions,
+		ProjectVersion: a.ProjectVersion,
+		Repo:           a.Repo,
+		ZipPaths:       a.ZipPaths(),
+		Changelog:      a.Changelog,
+		ReleaseType:    a.ReleaseType,
+		Plan:           a.Plan,
+	})
+}
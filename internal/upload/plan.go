@@ -0,0 +1,72 @@
+package upload
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+)
+
+// UploadPlanFileName is the file in the release directory a dry run writes its plan to.
+const UploadPlanFileName = "upload-plan.json"
+
+// PlannedRequest is a request an upload would send, recorded instead of sent by a dry run.
+type PlannedRequest struct {
+	Publisher string `json:"publisher"`
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	// Fields and Files are the parts of multipart requests, Files maps the field name to the
+	// path of the file. Payload is the body of JSON requests.
+	Fields  map[string]any    `json:"fields,omitempty"`
+	Files   map[string]string `json:"files,omitempty"`
+	Payload any               `json:"payload,omitempty"`
+	Note    string            `json:"note,omitempty"`
+}
+
+// UploadPlan collects the requests of a dry run, it's safe for concurrent use.
+type UploadPlan struct {
+	Requests []PlannedRequest `json:"requests"`
+
+	mu sync.Mutex
+}
+
+func NewUploadPlan() *UploadPlan {
+	return &UploadPlan{Requests: []PlannedRequest{}}
+}
+
+// Add records a request.
+func (p *UploadPlan) Add(r PlannedRequest) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Requests = append(p.Requests, r)
+}
+
+// JSON returns the plan as indented JSON, with the requests grouped by publisher in the order
+// they were added.
+func (p *UploadPlan) JSON() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	slices.SortStableFunc(p.Requests, func(a, b PlannedRequest) int {
+		return cmp.Compare(a.Publisher, b.Publisher)
+	})
+	contents, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the upload plan: %w", err)
+	}
+	return contents, nil
+}
+
+// Write saves the plan as JSON.
+func (p *UploadPlan) Write(path string) error {
+	contents, err := p.JSON()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, contents, 0644); err != nil {
+		return fmt.Errorf("failed to write the upload plan: %w", err)
+	}
+	return nil
+}
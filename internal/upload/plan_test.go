@@ -0,0 +1,73 @@
+package upload
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/McTalian/wow-build-tools/internal/changelog"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+)
+
+func TestWowiUpload_Plan(t *testing.T) {
+	changelogPath := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	require.NoError(t, os.WriteFile(changelogPath, []byte("# Changes"), 0644))
+
+	plan := NewUploadPlan()
+	w := &wowiUpload{
+		projectId:  "1234",
+		zipFile:    "Project.zip",
+		compatible: []string{"11.0.7", "1.15.5"},
+		version:    "v1.0.0",
+		changelog:  &changelog.Changelog{PreExistingFilePath: changelogPath},
+		plan:       plan,
+		logGroup:   logger.NewLogGroup("test"),
+	}
+	require.NoError(t, w.upload())
+
+	require.Len(t, plan.Requests, 1)
+	r := plan.Requests[0]
+	assert.Equal(t, PublisherWowi, r.Publisher)
+	assert.Equal(t, wowiUploadUrl(), r.URL)
+	assert.Equal(t, map[string]any{
+		"id":         "1234",
+		"version":    "v1.0.0",
+		"compatible": "11.0.7,1.15.5",
+		"archive":    "No",
+		"changelog":  "# Changes",
+	}, r.Fields)
+	assert.Equal(t, map[string]string{"updatefile": "Project.zip"}, r.Files)
+}
+
+func TestUploadPlan_Write(t *testing.T) {
+	plan := NewUploadPlan()
+	plan.Add(PlannedRequest{Publisher: PublisherWowi, Method: "POST", URL: "wowi"})
+	plan.Add(PlannedRequest{Publisher: PublisherGitHub, Method: "POST", URL: "release"})
+	plan.Add(PlannedRequest{Publisher: PublisherCurse, Method: "POST", URL: "curse", Fields: map[string]any{"metadata": json.RawMessage(`{"releaseType":"alpha"}`)}})
+	plan.Add(PlannedRequest{Publisher: PublisherGitHub, Method: "POST", URL: "asset"})
+
+	path := filepath.Join(t.TempDir(), UploadPlanFileName)
+	require.NoError(t, plan.Write(path))
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var written struct {
+		Requests []struct {
+			Publisher string
+			URL       string
+			Fields    map[string]map[string]string
+		}
+	}
+	require.NoError(t, json.Unmarshal(contents, &written))
+
+	var urls []string
+	for _, r := range written.Requests {
+		urls = append(urls, r.URL)
+	}
+	assert.Equal(t, []string{"curse", "release", "asset", "wowi"}, urls)
+	assert.Equal(t, "alpha", written.Requests[0].Fields["metadata"]["releaseType"])
+}
@@ -0,0 +1,165 @@
+package upload
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/McTalian/wow-build-tools/internal/changelog"
+	"github.com/McTalian/wow-build-tools/internal/pkg"
+	"github.com/McTalian/wow-build-tools/internal/repo"
+	"github.com/McTalian/wow-build-tools/internal/toc"
+)
+
+// The names of the built-in publishers, also used as keys of the `publish` pkgmeta field.
+const (
+	PublisherCurse  = "curse"
+	PublisherWowi   = "wowi"
+	PublisherWago   = "wago"
+	PublisherGitHub = "github"
+)
+
+// Relations are the slugs of the projects a release depends on, embeds or was built with.
+type Relations struct {
+	EmbeddedLibraries    []string
+	ToolsUsed            []string
+	RequiredDependencies []string
+	OptionalDependencies []string
+}
+
+// RelationsFromPkgMeta returns the relations declared in the pkgmeta file.
+func RelationsFromPkgMeta(pkgMeta *pkg.PkgMeta) Relations {
+	return Relations{
+		EmbeddedLibraries:    pkgMeta.EmbeddedLibraries,
+		ToolsUsed:            pkgMeta.ToolsUsed,
+		RequiredDependencies: pkgMeta.RequiredDependencies,
+		OptionalDependencies: pkgMeta.OptionalDependencies,
+	}
+}
+
+// ReleaseArtifact describes a packaged release the same way for every publisher.
+type ReleaseArtifact struct {
+	ProjectName    string
+	ProjectVersion string
+	// NoLibZipPath is empty when there is no no-lib package
+	ZipPath      string
+	NoLibZipPath string
+	FileLabel    string
+	Changelog    *changelog.Changelog
+	ReleaseType  string
+	GameVersions *toc.GameVersionSet
+	Relations    Relations
+	// WowiArchivePrevious archives the earlier files on WoWInterface, `wowi-archive-previous`
+	// in the pkgmeta file
+	WowiArchivePrevious bool
+
+	// TocFiles are where the project ids of the sites are read from, unless ProjectIds has
+	// one for the publisher. An id of "0" unsets the one of the TOC files.
+	TocFiles   []*toc.Toc
+	ProjectIds map[string]string
+	Repo       repo.VcsRepo
+
+	// Plan makes the publishers record their requests instead of sending them
+	Plan *UploadPlan
+}
+
+// DryRun reports whether the requests are only recorded in the plan. Publishers don't need
+// credentials for a dry run.
+func (a *ReleaseArtifact) DryRun() bool {
+	return a.Plan != nil
+}
+
+// ZipPaths returns the zips of the release.
+func (a *ReleaseArtifact) ZipPaths() []string {
+	zipPaths := []string{a.ZipPath}
+	if a.NoLibZipPath != "" {
+		zipPaths = append(zipPaths, a.NoLibZipPath)
+	}
+	return zipPaths
+}
+
+// validateZips checks that the zips of the release exist.
+func (a *ReleaseArtifact) validateZips() error {
+	for _, zipPath := range a.ZipPaths() {
+		if _, err := os.Stat(zipPath); err != nil {
+			return fmt.Errorf("could not find the package: %w", err)
+		}
+	}
+	return nil
+}
+
+// Publisher uploads releases to a distribution site.
+type Publisher interface {
+	// Name identifies the publisher within a registry.
+	Name() string
+	// Enabled reports whether the release should be published to the site, i.e. whether the
+	// project has an id on the site and its credentials are set.
+	Enabled(a *ReleaseArtifact) bool
+	// Validate checks that the release can be published before anything is uploaded.
+	Validate(a *ReleaseArtifact) error
+	Publish(a *ReleaseArtifact) error
+}
+
+// Registry holds the publishers releases are published with.
+type Registry struct {
+	publishers []Publisher
+	disabled   map[string]bool
+}
+
+func NewRegistry(publishers ...Publisher) *Registry {
+	r := &Registry{disabled: make(map[string]bool)}
+	for _, p := range publishers {
+		r.Register(p)
+	}
+	return r
+}
+
+// DefaultRegistry returns a registry with the publishers for CurseForge, WoWInterface, Wago
+// and GitHub.
+func DefaultRegistry() *Registry {
+	return NewRegistry(
+		&CursePublisher{},
+		&WowiPublisher{},
+		&WagoPublisher{},
+		&GitHubPublisher{},
+	)
+}
+
+// Register adds a publisher, replacing the one with the same name.
+func (r *Registry) Register(p Publisher) {
+	i := slices.IndexFunc(r.publishers, func(other Publisher) bool { return other.Name() == p.Name() })
+	if i == -1 {
+		r.publishers = append(r.publishers, p)
+		return
+	}
+	r.publishers[i] = p
+}
+
+// Get returns the publisher with the given name.
+func (r *Registry) Get(name string) (Publisher, bool) {
+	i := slices.IndexFunc(r.publishers, func(p Publisher) bool { return p.Name() == name })
+	if i == -1 {
+		return nil, false
+	}
+	return r.publishers[i], true
+}
+
+// SetEnabled turns publishing with the publisher with the given name on or off.
+func (r *Registry) SetEnabled(name string, enabled bool) error {
+	if _, ok := r.Get(name); !ok {
+		return fmt.Errorf("no publisher named %s", name)
+	}
+	r.disabled[name] = !enabled
+	return nil
+}
+
+// Publishers returns the publishers that aren't turned off, in the order they were registered.
+func (r *Registry) Publishers() []Publisher {
+	var publishers []Publisher
+	for _, p := range r.publishers {
+		if !r.disabled[p.Name()] {
+			publishers = append(publishers, p)
+		}
+	}
+	return publishers
+}
@@ -0,0 +1,56 @@
+package upload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type namedPublisher struct {
+	name string
+	tag  string
+}
+
+func (p *namedPublisher) Name() string                      { return p.name }
+func (p *namedPublisher) Enabled(a *ReleaseArtifact) bool   { return true }
+func (p *namedPublisher) Validate(a *ReleaseArtifact) error { return nil }
+func (p *namedPublisher) Publish(a *ReleaseArtifact) error  { return nil }
+
+func names(publishers []Publisher) []string {
+	var names []string
+	for _, p := range publishers {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+func TestRegistry(t *testing.T) {
+	assert.Equal(t, []string{PublisherCurse, PublisherWowi, PublisherWago, PublisherGitHub}, names(DefaultRegistry().Publishers()))
+
+	r := NewRegistry(&namedPublisher{name: "a"}, &namedPublisher{name: "b"})
+	r.Register(&namedPublisher{name: "c"})
+	r.Register(&namedPublisher{name: "a", tag: "replaced"})
+	assert.Equal(t, []string{"a", "b", "c"}, names(r.Publishers()))
+
+	p, ok := r.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, "replaced", p.(*namedPublisher).tag)
+	_, ok = r.Get("d")
+	assert.False(t, ok)
+
+	require.NoError(t, r.SetEnabled("b", false))
+	assert.Equal(t, []string{"a", "c"}, names(r.Publishers()))
+	require.NoError(t, r.SetEnabled("b", true))
+	assert.Equal(t, []string{"a", "b", "c"}, names(r.Publishers()))
+	assert.Error(t, r.SetEnabled("d", false))
+}
+
+func TestReleaseArtifact_ZipPaths(t *testing.T) {
+	a := &ReleaseArtifact{ZipPath: "Project.zip"}
+	assert.Equal(t, []string{"Project.zip"}, a.ZipPaths())
+	assert.Error(t, a.validateZips())
+
+	a.NoLibZipPath = "Project-nolib.zip"
+	assert.Equal(t, []string{"Project.zip", "Project-nolib.zip"}, a.ZipPaths())
+}
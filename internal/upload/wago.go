@@ -12,6 +12,7 @@ import (
 	"slices"
 	"time"
 
+	"github.com/McTalian/wow-build-tools/internal/api"
 	"github.com/McTalian/wow-build-tools/internal/changelog"
 	"github.com/McTalian/wow-build-tools/internal/logger"
 	"github.com/McTalian/wow-build-tools/internal/toc"
@@ -22,8 +23,9 @@ var ErrMultipleWagoIds = fmt.Errorf("multiple Wago IDs found")
 var ErrNoWagoUpload = fmt.Errorf("Wago upload is disabled")
 var ErrNoWagoApiKey = fmt.Errorf("WAGO_API_TOKEN not set")
 
-var wagoApiUrl = "https://addons.wago.io/api/"
-var wagoGameVersionsUrl = fmt.Sprintf("%sdata/game", wagoApiUrl)
+func wagoGameVersionsUrl() string {
+	return fmt.Sprintf("%sdata/game", api.Current().WagoApiUrl)
+}
 
 type wagoPayload struct {
 	Label            string              `json:"label"`
@@ -75,6 +77,7 @@ type wagoUpload struct {
 	changelog      *changelog.Changelog
 	stabilityValue string
 	metadataPart   string
+	plan           *UploadPlan
 	logGroup       *logger.LogGroup
 }
 
@@ -132,8 +135,10 @@ func (w *wagoUpload) lookupWagoToken() (err error) {
 	return
 }
 
-func (w *wagoUpload) validateGameVersions(gameVersions []string) (err error) {
-	req, err := http.NewRequest("GET", wagoGameVersionsUrl, nil)
+func (w *wagoUpload) validateGameVersions(gameVersionSet *toc.GameVersionSet) (err error) {
+	gameVersions := gameVersionSet.Versions()
+
+	req, err := http.NewRequest("GET", wagoGameVersionsUrl(), nil)
 	if err != nil {
 		w.logGroup.Error("Could not fetch game versions: %v", err)
 		return
@@ -141,7 +146,7 @@ func (w *wagoUpload) validateGameVersions(gameVersions []string) (err error) {
 
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := api.Client().Do(req)
 	if err != nil {
 		w.logGroup.Error("Could not fetch game versions: %v", err)
 		return
@@ -173,17 +178,13 @@ func (w *wagoUpload) validateGameVersions(gameVersions []string) (err error) {
 		missingVersions[version] = true
 	}
 
-	flavorVersionMap := toc.GetGameFlavorVersionsMap()
+	flavorVersionMap := gameVersionSet.FlavorVersions()
 
-	for flavor, versions := range flavorVersionMap {
-		var wago_type string
-		switch flavor {
-		case toc.TbcClassic:
-			wago_type = "bc"
-		case toc.WotlkClassic:
-			wago_type = "wotlk"
-		default:
-			wago_type = flavor.ToString()
+	for _, flavor := range gameVersionSet.Flavors() {
+		versions := flavorVersionMap[flavor]
+		wago_type := flavor.ToString()
+		if f, ok := toc.LookupFlavor(flavor); ok && f.WagoKey != "" {
+			wago_type = f.WagoKey
 		}
 		for _, version := range versions {
 			if slices.Contains(versionResp.Patches[wago_type], version) {
@@ -238,6 +239,18 @@ func (w *wagoUpload) preparePayload() error {
 }
 
 func (w *wagoUpload) upload() error {
+	if w.plan != nil {
+		w.plan.Add(PlannedRequest{
+			Publisher: PublisherWago,
+			Method:    "POST",
+			URL:       w.uploadUrl,
+			Fields:    map[string]any{"metadata": json.RawMessage(w.metadataPart)},
+			Files:     map[string]string{"file": w.zipFile},
+		})
+		w.logGroup.Info("Planned the Wago.io upload (dry run)")
+		return nil
+	}
+
 	w.logGroup.Info("Uploading to Wago.io")
 
 	file, err := os.Open(w.zipFile)
@@ -276,7 +289,7 @@ func (w *wagoUpload) upload() error {
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", w.token))
 
-	client := &http.Client{}
+	client := api.Client()
 	maxAttempts := 5
 	delay := 2 * time.Second
 
@@ -321,13 +334,16 @@ func (w *wagoUpload) upload() error {
 }
 
 type UploadWagoArgs struct {
-	TocFiles    []*toc.Toc
-	ZipPath     string
-	FileLabel   string
-	Changelog   *changelog.Changelog
-	ReleaseType string
-	SkipUpload  bool
-	WagoId      string
+	TocFiles     []*toc.Toc
+	GameVersions *toc.GameVersionSet
+	ZipPath      string
+	FileLabel    string
+	Changelog    *changelog.Changelog
+	ReleaseType  string
+	SkipUpload   bool
+	WagoId       string
+	// Plan records the upload instead of sending it when set
+	Plan *UploadPlan
 }
 
 func UploadToWago(args UploadWagoArgs) error {
@@ -365,23 +381,25 @@ func UploadToWago(args UploadWagoArgs) error {
 
 	wagoUpload := wagoUpload{
 		projectId:      wagoId,
-		uploadUrl:      fmt.Sprintf("%sprojects/%s/version", wagoApiUrl, wagoId),
+		uploadUrl:      fmt.Sprintf("%sprojects/%s/version", api.Current().WagoApiUrl, wagoId),
 		zipFile:        args.ZipPath,
 		displayName:    args.FileLabel,
 		changelog:      args.Changelog,
 		stabilityValue: stabilityValue,
 		supportMap:     make(map[string][]string),
+		plan:           args.Plan,
 		logGroup:       logGroup,
 	}
 
 	if err := wagoUpload.lookupWagoToken(); err != nil {
-		logGroup.Info("Skipping Wago upload: %s", err)
-		return nil
+		if args.Plan == nil {
+			logGroup.Info("Skipping Wago upload: %s", err)
+			return nil
+		}
+		logGroup.Warn("%s, planning the Wago upload anyway", err)
 	}
 
-	gameVersions := toc.GetGameVersions()
-
-	if err := wagoUpload.validateGameVersions(gameVersions); err != nil {
+	if err := wagoUpload.validateGameVersions(args.GameVersions); err != nil {
 		logGroup.Error("Could not validate game versions: %v", err)
 		return err
 	}
@@ -396,3 +414,39 @@ func UploadToWago(args UploadWagoArgs) error {
 
 	return nil
 }
+
+// WagoPublisher publishes releases to Wago.
+type WagoPublisher struct{}
+
+func (p *WagoPublisher) Name() string { return PublisherWago }
+
+func (p *WagoPublisher) Enabled(a *ReleaseArtifact) bool {
+	if _, err := getWagoId(a.TocFiles, a.ProjectIds[PublisherWago]); err == ErrNoWagoId {
+		return false
+	}
+	if _, found := os.LookupEnv("WAGO_API_TOKEN"); !found && !a.DryRun() {
+		logger.Info("Skipping Wago upload: %s", ErrNoWagoApiKey)
+		return false
+	}
+	return true
+}
+
+func (p *WagoPublisher) Validate(a *ReleaseArtifact) error {
+	if _, err := getWagoId(a.TocFiles, a.ProjectIds[PublisherWago]); err != nil {
+		return err
+	}
+	return a.validateZips()
+}
+
+func (p *WagoPublisher) Publish(a *ReleaseArtifact) error {
+	return UploadToWago(UploadWagoArgs{
+		TocFiles:     a.TocFiles,
+		GameVersions: a.GameVersions,
+		WagoId:       a.ProjectIds[PublisherWago],
+		ZipPath:      a.ZipPath,
+		FileLabel:    a.FileLabel,
+		Changelog:    a.Changelog,
+		ReleaseType:  a.ReleaseType,
+		Plan:         a.Plan,
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/McTalian/wow-build-tools/internal/api"
 	"github.com/McTalian/wow-build-tools/internal/changelog"
 	"github.com/McTalian/wow-build-tools/internal/logger"
 	"github.com/McTalian/wow-build-tools/internal/toc"
@@ -22,9 +23,13 @@ var ErrMultipleWowiIds = fmt.Errorf("multiple WoW Interface IDs found")
 var ErrNoWowiUpload = fmt.Errorf("WoW Interface upload is disabled")
 var ErrNoWowiApiKey = fmt.Errorf("WOWI_API_TOKEN not set")
 
-var wowiApiUrl = "https://api.wowinterface.com/addons/"
-var wowiGameVersionsUrl = fmt.Sprintf("%scompatible.json", wowiApiUrl)
-var wowiUploadUrl = fmt.Sprintf("%supdate", wowiApiUrl)
+func wowiGameVersionsUrl() string {
+	return fmt.Sprintf("%scompatible.json", api.Current().WowiApiUrl)
+}
+
+func wowiUploadUrl() string {
+	return fmt.Sprintf("%supdate", api.Current().WowiApiUrl)
+}
 
 type wowiGameVersionsEntry struct {
 	Id        string `json:"id"`
@@ -85,6 +90,7 @@ type wowiUpload struct {
 	compatible  []string
 	version     string
 	archiveOld  bool
+	plan        *UploadPlan
 	logGroup    *logger.LogGroup
 }
 
@@ -109,8 +115,10 @@ func stringInSlice(str string, list []string) bool {
 	return false
 }
 
-func (w *wowiUpload) validateGameVersions(gameVersions []string) error {
-	resp, err := http.Get(wowiGameVersionsUrl)
+func (w *wowiUpload) validateGameVersions(gameVersionSet *toc.GameVersionSet) error {
+	gameVersions := gameVersionSet.Versions()
+
+	resp, err := api.Client().Get(wowiGameVersionsUrl())
 	if err != nil {
 		w.logGroup.Error("Could not fetch game versions: %v", err)
 		return err
@@ -129,9 +137,21 @@ func (w *wowiUpload) validateGameVersions(gameVersions []string) error {
 		return err
 	}
 
-	versionIdList := make([]string, len(versionResp))
-	for i, version := range versionResp {
-		versionIdList[i] = version.Id
+	versionGames := make(map[string]string)
+	for flavor, versions := range gameVersionSet.FlavorVersions() {
+		if f, ok := toc.LookupFlavor(flavor); ok && f.WowiGame != "" {
+			for _, version := range versions {
+				versionGames[version] = f.WowiGame
+			}
+		}
+	}
+
+	var versionIdList []string
+	for _, version := range versionResp {
+		if game, ok := versionGames[version.Id]; ok && !strings.EqualFold(game, version.Game) {
+			continue
+		}
+		versionIdList = append(versionIdList, version.Id)
 	}
 
 	for _, gameVersion := range gameVersions {
@@ -145,46 +165,68 @@ func (w *wowiUpload) validateGameVersions(gameVersions []string) error {
 	return nil
 }
 
-func (w *wowiUpload) upload() error {
-	w.logGroup.Info("Uploading to WoW Interface")
-
-	file, err := os.Open(w.zipFile)
-	if err != nil {
-		return fmt.Errorf("could not open zip file: %v", err)
+// formFields returns the fields of the upload form besides the file, in the order they are
+// written.
+func (w *wowiUpload) formFields() ([][2]string, error) {
+	fields := [][2]string{
+		{"id", w.projectId},
+		{"version", w.version},
+		{"compatible", strings.Join(w.compatible, ",")},
 	}
-	defer file.Close()
 
-	var body bytes.Buffer
-	writer := multipart.NewWriter(&body)
-
-	if err = writer.WriteField("id", w.projectId); err != nil {
-		return fmt.Errorf("could not write id: %v", err)
+	if !w.archiveOld {
+		fields = append(fields, [2]string{"archive", "No"})
 	}
 
-	if err = writer.WriteField("version", w.version); err != nil {
-		return fmt.Errorf("could not write version: %v", err)
+	if w.changelog != nil {
+		if w.changelog.PreExistingFilePath != "" {
+			changelogContents, err := os.ReadFile(w.changelog.PreExistingFilePath)
+			if err != nil {
+				return nil, fmt.Errorf("could not read changelog: %v", err)
+			}
+			fields = append(fields, [2]string{"changelog", string(changelogContents)})
+		}
 	}
 
-	if err = writer.WriteField("compatible", strings.Join(w.compatible, ",")); err != nil {
-		return fmt.Errorf("could not write compatible: %v", err)
+	return fields, nil
+}
+
+func (w *wowiUpload) upload() error {
+	fields, err := w.formFields()
+	if err != nil {
+		return err
 	}
 
-	if !w.archiveOld {
-		if err = writer.WriteField("archive", "No"); err != nil {
-			return fmt.Errorf("could not write archive: %v", err)
+	if w.plan != nil {
+		planned := PlannedRequest{
+			Publisher: PublisherWowi,
+			Method:    "POST",
+			URL:       wowiUploadUrl(),
+			Fields:    make(map[string]any),
+			Files:     map[string]string{"updatefile": w.zipFile},
+		}
+		for _, field := range fields {
+			planned.Fields[field[0]] = field[1]
 		}
+		w.plan.Add(planned)
+		w.logGroup.Info("Planned the WoW Interface upload (dry run)")
+		return nil
 	}
 
-	if w.changelog != nil {
-		if w.changelog.PreExistingFilePath != "" {
-			changelogContents, err := os.ReadFile(w.changelog.PreExistingFilePath)
-			if err != nil {
-				return fmt.Errorf("could not read changelog: %v", err)
-			}
+	w.logGroup.Info("Uploading to WoW Interface")
 
-			if err = writer.WriteField("changelog", string(changelogContents)); err != nil {
-				return fmt.Errorf("could not write changelog: %v", err)
-			}
+	file, err := os.Open(w.zipFile)
+	if err != nil {
+		return fmt.Errorf("could not open zip file: %v", err)
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for _, field := range fields {
+		if err = writer.WriteField(field[0], field[1]); err != nil {
+			return fmt.Errorf("could not write %s: %v", field[0], err)
 		}
 	}
 
@@ -203,7 +245,7 @@ func (w *wowiUpload) upload() error {
 	}
 
 	// Create the POST request
-	req, err := http.NewRequest("POST", wowiUploadUrl, &body)
+	req, err := http.NewRequest("POST", wowiUploadUrl(), &body)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -213,7 +255,7 @@ func (w *wowiUpload) upload() error {
 	req.Header.Set("x-api-token", w.token)
 
 	// Prepare the HTTP client and exponential backoff parameters
-	client := &http.Client{}
+	client := api.Client()
 	maxAttempts := 5
 	delay := 2 * time.Second
 
@@ -263,6 +305,7 @@ func (w *wowiUpload) upload() error {
 
 type UploadWowiArgs struct {
 	TocFiles       []*toc.Toc
+	GameVersions   *toc.GameVersionSet
 	ProjectVersion string
 	ZipPath        string
 	FileLabel      string
@@ -271,6 +314,8 @@ type UploadWowiArgs struct {
 	WowiArchiveOld bool
 	SkipUpload     bool
 	WowiId         string
+	// Plan records the upload instead of sending it when set
+	Plan *UploadPlan
 }
 
 func UploadToWowi(args UploadWowiArgs) error {
@@ -300,17 +345,19 @@ func UploadToWowi(args UploadWowiArgs) error {
 		changelog:   args.Changelog,
 		version:     args.ProjectVersion,
 		archiveOld:  args.WowiArchiveOld,
+		plan:        args.Plan,
 		logGroup:    logGroup,
 	}
 
 	if err := wowiUpload.lookupWowiToken(); err != nil {
-		logGroup.Info("Skipping WoW Interface upload: %s", err)
-		return nil
+		if args.Plan == nil {
+			logGroup.Info("Skipping WoW Interface upload: %s", err)
+			return nil
+		}
+		logGroup.Warn("%s, planning the WoW Interface upload anyway", err)
 	}
 
-	gameVersions := toc.GetGameVersions()
-
-	if err := wowiUpload.validateGameVersions(gameVersions); err != nil {
+	if err := wowiUpload.validateGameVersions(args.GameVersions); err != nil {
 		logGroup.Error("Could not validate game versions: %v", err)
 		return err
 	}
@@ -321,3 +368,41 @@ func UploadToWowi(args UploadWowiArgs) error {
 
 	return nil
 }
+
+// WowiPublisher publishes releases to WoWInterface.
+type WowiPublisher struct{}
+
+func (p *WowiPublisher) Name() string { return PublisherWowi }
+
+func (p *WowiPublisher) Enabled(a *ReleaseArtifact) bool {
+	if _, err := getWowiId(a.TocFiles, a.ProjectIds[PublisherWowi]); err == ErrNoWowiId {
+		return false
+	}
+	if _, found := os.LookupEnv("WOWI_API_TOKEN"); !found && !a.DryRun() {
+		logger.Info("Skipping WoW Interface upload: %s", ErrNoWowiApiKey)
+		return false
+	}
+	return true
+}
+
+func (p *WowiPublisher) Validate(a *ReleaseArtifact) error {
+	if _, err := getWowiId(a.TocFiles, a.ProjectIds[PublisherWowi]); err != nil {
+		return err
+	}
+	return a.validateZips()
+}
+
+func (p *WowiPublisher) Publish(a *ReleaseArtifact) error {
+	return UploadToWowi(UploadWowiArgs{
+		TocFiles:       a.TocFiles,
+		GameVersions:   a.GameVersions,
+		ProjectVersion: a.ProjectVersion,
+		WowiId:         a.ProjectIds[PublisherWowi],
+		ZipPath:        a.ZipPath,
+		FileLabel:      a.FileLabel,
+		Changelog:      a.Changelog,
+		ReleaseType:    a.ReleaseType,
+		WowiArchiveOld: a.WowiArchivePrevious,
+		Plan:           a.Plan,
+	})
+}
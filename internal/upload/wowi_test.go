@@ -0,0 +1,77 @@
+package upload
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/McTalian/wow-build-tools/internal/api"
+	"github.com/McTalian/wow-build-tools/internal/changelog"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/toc"
+)
+
+func TestWowiUpload_ValidateGameVersions(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		if r.URL.Path != "/addons/compatible.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"id":"11.0.7","name":"The War Within","game":"WoW Retail"},{"id":"1.15.5","name":"Classic","game":"WoW Classic"}]`))
+	}))
+	defer server.Close()
+
+	require.NoError(t, api.SetEndpoints(api.Endpoints{WowiApiUrl: server.URL + "/addons"}))
+	defer api.ResetEndpoints()
+	client, err := api.NewClient(api.ClientOptions{UserAgent: "wbt-test"})
+	require.NoError(t, err)
+	api.SetClient(client)
+	defer api.SetClient(nil)
+
+	gameVersions := toc.NewGameVersionSet()
+	gameVersions.AddVersion(toc.Retail, "11.0.7")
+	gameVersions.AddVersion(toc.Retail, "11.1.0")
+
+	w := &wowiUpload{logGroup: logger.NewLogGroup("test")}
+	require.NoError(t, w.validateGameVersions(gameVersions))
+	assert.Equal(t, []string{"11.0.7"}, w.compatible)
+	assert.Equal(t, "wbt-test", userAgent)
+	assert.Equal(t, server.URL+"/addons/update", wowiUploadUrl())
+}
+
+func TestWowiPublisher_ArchivePrevious(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"11.0.7","name":"The War Within","game":"WoW Retail"}]`))
+	}))
+	defer server.Close()
+	require.NoError(t, api.SetEndpoints(api.Endpoints{WowiApiUrl: server.URL + "/addons"}))
+	defer api.ResetEndpoints()
+
+	changelogPath := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	require.NoError(t, os.WriteFile(changelogPath, []byte("# Changes"), 0644))
+	gameVersions := toc.NewGameVersionSet()
+	gameVersions.AddVersion(toc.Retail, "11.0.7")
+
+	for _, archive := range []bool{true, false} {
+		a := &ReleaseArtifact{
+			ProjectVersion:      "v1.0.0",
+			ZipPath:             "Project.zip",
+			Changelog:           &changelog.Changelog{PreExistingFilePath: changelogPath},
+			GameVersions:        gameVersions,
+			WowiArchivePrevious: archive,
+			ProjectIds:          map[string]string{PublisherWowi: "1234"},
+			Plan:                NewUploadPlan(),
+		}
+		require.NoError(t, (&WowiPublisher{}).Publish(a))
+		require.Len(t, a.Plan.Requests, 1)
+		_, sent := a.Plan.Requests[0].Fields["archive"]
+		assert.Equal(t, !archive, sent, "archive=No is sent only when the previous files are kept")
+	}
+}
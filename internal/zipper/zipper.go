@@ -25,6 +25,12 @@ func (z *Zipper) Complete() {
 }
 
 func (z *Zipper) ZipFiles(srcPath string, destPath string, noLibArgs ...[]string) error {
+	return z.ZipFolders([]string{srcPath}, destPath, noLibArgs...)
+}
+
+// ZipFolders creates a zip with each of the source folders at its top level, e.g. an addon
+// and the folders moved out of it by `move-folders`.
+func (z *Zipper) ZipFolders(srcPaths []string, destPath string, noLibArgs ...[]string) error {
 	z.logGroup.Info("📦 Creating %s", destPath)
 	dirsToExclude := []string{}
 	noLibStripPaths := []string{}
@@ -55,6 +61,16 @@ func (z *Zipper) ZipFiles(srcPath string, destPath string, noLibArgs ...[]string
 	zipWriter := zip.NewWriter(zipFile)
 	defer zipWriter.Close()
 
+	for _, srcPath := range srcPaths {
+		if err := z.addFolder(zipWriter, srcPath, destPath, dirsToExclude, noLibStripPaths); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (z *Zipper) addFolder(zipWriter *zip.Writer, srcPath string, destPath string, dirsToExclude []string, noLibStripPaths []string) error {
 	// Walk the source directory
 	return filepath.Walk(srcPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
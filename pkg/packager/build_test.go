@@ -0,0 +1,565 @@
+package packager
+
+import (
+	"archive/zip"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/McTalian/wow-build-tools/internal/external"
+	"github.com/McTalian/wow-build-tools/internal/pkg"
+	"github.com/McTalian/wow-build-tools/internal/toc"
+	"github.com/McTalian/wow-build-tools/internal/upload"
+)
+
+// newTestAddon creates a committed git repository with an origin remote containing a small addon.
+func newTestAddon(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	r, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	_, err = r.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"https://github.com/example/Project.git"},
+	})
+	require.NoError(t, err)
+
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+	}
+
+	w, err := r.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, w.AddGlob("."))
+	_, err = w.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)},
+	})
+	require.NoError(t, err)
+
+	return dir
+}
+
+// uploadRecorder replaces the uploaders and records the game versions each one was given.
+type uploadRecorder struct {
+	mu       sync.Mutex
+	payloads map[string]uploadPayload
+}
+
+type uploadPayload struct {
+	Versions   []string
+	Interfaces toc.GameVersions
+	FileLabel  string
+}
+
+func (r *uploadRecorder) record(name string, gameVersions *toc.GameVersionSet, fileLabel string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payloads[name] = uploadPayload{
+		Versions:   gameVersions.Versions(),
+		Interfaces: gameVersions.FlavorInterfaces(),
+		FileLabel:  fileLabel,
+	}
+}
+
+// recordingPublisher only records the release it was given.
+type recordingPublisher struct {
+	name string
+	rec  *uploadRecorder
+}
+
+func (p *recordingPublisher) Name() string                             { return p.name }
+func (p *recordingPublisher) Enabled(a *upload.ReleaseArtifact) bool   { return true }
+func (p *recordingPublisher) Validate(a *upload.ReleaseArtifact) error { return nil }
+func (p *recordingPublisher) Publish(a *upload.ReleaseArtifact) error {
+	p.rec.record(p.name, a.GameVersions, a.FileLabel)
+	return nil
+}
+
+// recordingPublishStage returns a publish stage whose publishers only record their arguments.
+func recordingPublishStage() (*PublishStage, *uploadRecorder) {
+	rec := &uploadRecorder{payloads: make(map[string]uploadPayload)}
+	registry := upload.NewRegistry()
+	for _, name := range []string{upload.PublisherCurse, upload.PublisherWowi, upload.PublisherWago, upload.PublisherGitHub} {
+		registry.Register(&recordingPublisher{name: name, rec: rec})
+	}
+	return &PublishStage{Registry: registry}, rec
+}
+
+func TestBuild_RepeatedUploadPayloads(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	topDir := newTestAddon(t, map[string]string{
+		"Project.toc":      "## Interface: 110100, 11506\n## Title: Project\n## Version: @project-version@\n\nProject.lua\n",
+		"Project_Cata.toc": "## Interface: 40402\n## Title: Project\n\nProject.lua\n",
+		"Project.lua":      "local _, ns = ...\n",
+		".pkgmeta":         "package-as: Project\n",
+	})
+
+	build := func() map[string]uploadPayload {
+		stage, rec := recordingPublishStage()
+		ctx, err := NewBuildContext(&Options{
+			TopDir:        topDir,
+			ReleaseDir:    filepath.Join(t.TempDir(), ".release"),
+			PkgmetaFile:   filepath.Join(topDir, ".pkgmeta"),
+			GameVersion:   "11.0.7",
+			SkipChangelog: true,
+		})
+		require.NoError(t, err)
+
+		p := DefaultPipeline()
+		require.NoError(t, p.Replace(StagePublish, stage))
+		require.NoError(t, p.Run(ctx))
+		return rec.payloads
+	}
+
+	first := build()
+	second := build()
+
+	require.Len(t, first, 4)
+	assert.Equal(t, first, second)
+
+	want := []string{"4.4.2", "1.15.6", "11.0.7", "11.1.0"}
+	for name, payload := range first {
+		assert.Equal(t, want, payload.Versions, name)
+	}
+	assert.Equal(t, toc.GameVersions{
+		toc.ClassicEra:  {"11506"},
+		toc.CataClassic: {"40402"},
+		toc.Retail:      {"110007", "110100"},
+	}, first["github"].Interfaces)
+}
+
+func zipEntries(t *testing.T, path string) []string {
+	t.Helper()
+	r, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.File {
+		names = append(names, filepath.ToSlash(f.Name))
+	}
+	return names
+}
+
+func TestBuild_MoveFolders(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	topDir := newTestAddon(t, map[string]string{
+		"Project.toc":                            "## Interface: 110100\n## Title: Project\n\nProject.lua\n",
+		"Project.lua":                            "local _, ns = ...\n",
+		"Modules/Options/Project_Options.toc":    "## Interface: 110100\n## Title: Project Options\n\nOptions.lua\n",
+		"Modules/Options/Options.lua":            "--@debug@\nprint(\"debug\")\n--@end-debug@\n--@version-classic@\nprint(\"classic\")\n--@end-version-classic@\n--@version-retail@\nprint(\"retail\")\n--@end-version-retail@\n",
+		"Modules/Options/Libs/Widget/Widget.lua": "local Widget = {}\n",
+		".pkgmeta": "package-as: Project\n" +
+			"enable-nolib-creation: true\n" +
+			"move-folders:\n" +
+			"  Project/Modules/Options: Project_Options\n",
+	})
+	releaseDir := filepath.Join(t.TempDir(), ".release")
+
+	ctx, err := NewBuildContext(&Options{
+		TopDir:        topDir,
+		ReleaseDir:    releaseDir,
+		SkipChangelog: true,
+		SkipUpload:    true,
+		NameTemplate:  "{package-name}{nolib}",
+	})
+	require.NoError(t, err)
+
+	// Treat the widget library as an external so it's left out of the no-lib zip
+	p := DefaultPipeline()
+	require.NoError(t, p.InsertBefore(StageMoveFolders, &funcStage{name: "fake-external", run: func(ctx *BuildContext) error {
+		ctx.PkgMeta.Externals = map[string]*external.ExternalEntry{"Modules/Options/Libs/Widget": {}}
+		return nil
+	}}))
+	require.NoError(t, p.Run(ctx))
+
+	assert.Equal(t, []string{filepath.Join(releaseDir, "Project"), filepath.Join(releaseDir, "Project_Options")}, ctx.AddonDirs)
+	assert.NoDirExists(t, filepath.Join(releaseDir, "Project", "Modules", "Options"))
+
+	contents, err := os.ReadFile(filepath.Join(releaseDir, "Project_Options", "Options.lua"))
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), "--[===[@debug@")
+	// The folder was moved after its tokens were replaced in the package directory
+	assert.Contains(t, string(contents), "--[===[@version-classic@")
+	assert.NotContains(t, string(contents), "--[===[@version-retail@")
+
+	entries := zipEntries(t, ctx.ZipPath)
+	assert.Contains(t, entries, "Project/Project.toc")
+	assert.Contains(t, entries, "Project_Options/Project_Options.toc")
+	assert.Contains(t, entries, "Project_Options/Libs/Widget/Widget.lua")
+
+	noLibEntries := zipEntries(t, ctx.NoLibZipPath)
+	assert.Contains(t, noLibEntries, "Project_Options/Options.lua")
+	assert.NotContains(t, noLibEntries, "Project_Options/Libs/Widget/Widget.lua")
+}
+
+func TestBuild_LocalLocalization(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("CF_API_KEY", "")
+
+	topDir := newTestAddon(t, map[string]string{
+		"Project.toc":       "## Interface: 110100\n## Title: Project\n\nLocales/deDE.lua\n",
+		"Locales/deDE.lua":  "local L = {}\n--@localization(locale=\"deDE\", handle-unlocalized=\"comment\")@\n",
+		"Locales/enUS.json": `{"Hello": "Hello", "Goodbye": "Goodbye"}`,
+		"Locales/deDE.json": `{"Hello": "Hallo"}`,
+		".pkgmeta": "package-as: Project\n" +
+			"localization:\n" +
+			"  files:\n" +
+			"    - Locales/*.json\n",
+	})
+	releaseDir := filepath.Join(t.TempDir(), ".release")
+
+	ctx, err := NewBuildContext(&Options{
+		TopDir:          topDir,
+		ReleaseDir:      releaseDir,
+		SkipChangelog:   true,
+		SkipUpload:      true,
+		SkipZip:         true,
+		UnixLineEndings: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, DefaultPipeline().Run(ctx))
+
+	contents, err := os.ReadFile(filepath.Join(releaseDir, "Project", "Locales", "deDE.lua"))
+	require.NoError(t, err)
+	assert.Equal(t, "local L = {}\n-- L[\"Goodbye\"] = \"Goodbye\"\nL[\"Hello\"] = \"Hallo\"\n", string(contents))
+}
+
+func TestBuild_ExternalsLock(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	lib := newTestAddon(t, map[string]string{"LibFoo.lua": "local version = 1\n"})
+	topDir := newTestAddon(t, map[string]string{
+		"Project.toc": "## Interface: 110100\n## Title: Project\n\nLibs/LibFoo/LibFoo.lua\n",
+		".pkgmeta": "package-as: Project\n" +
+			"externals:\n" +
+			"  Libs/LibFoo:\n" +
+			"    url: " + lib + "\n" +
+			"    type: git\n",
+	})
+	releaseDir := filepath.Join(t.TempDir(), ".release")
+
+	build := func(t *testing.T, frozen bool) (string, error) {
+		ctx, err := NewBuildContext(&Options{
+			TopDir:         topDir,
+			ReleaseDir:     releaseDir,
+			SkipChangelog:  true,
+			SkipUpload:     true,
+			SkipZip:        true,
+			ForceExternals: true,
+			Frozen:         frozen,
+		})
+		require.NoError(t, err)
+		if err = DefaultPipeline().Run(ctx); err != nil {
+			return "", err
+		}
+		contents, err := os.ReadFile(filepath.Join(releaseDir, "Project", "Libs", "LibFoo", "LibFoo.lua"))
+		require.NoError(t, err)
+		return string(contents), nil
+	}
+	update := func(t *testing.T) {
+		ctx, err := NewBuildContext(&Options{TopDir: topDir, ReleaseDir: releaseDir})
+		require.NoError(t, err)
+		require.NoError(t, NewPipeline(&ResolveStage{}, &CopyStage{}, &ExternalsStage{Update: true}).Run(ctx))
+	}
+	commitLib := func(t *testing.T, contents string) string {
+		r, err := git.PlainOpen(lib)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(lib, "LibFoo.lua"), []byte(contents), 0644))
+		w, err := r.Worktree()
+		require.NoError(t, err)
+		_, err = w.Add("LibFoo.lua")
+		require.NoError(t, err)
+		hash, err := w.Commit("Update", &git.CommitOptions{
+			Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000100, 0)},
+		})
+		require.NoError(t, err)
+		return hash.String()
+	}
+
+	// Frozen builds need the lock file
+	_, err := build(t, true)
+	require.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(topDir, pkg.LockFileName))
+
+	r, err := git.PlainOpen(lib)
+	require.NoError(t, err)
+	head, err := r.Head()
+	require.NoError(t, err)
+
+	update(t)
+	lock, err := pkg.ReadExternalsLock(topDir)
+	require.NoError(t, err)
+	require.Contains(t, lock.Externals, "Libs/LibFoo")
+	assert.Equal(t, head.Hash().String(), lock.Externals["Libs/LibFoo"].Revision)
+	assert.Equal(t, "default", lock.Externals["Libs/LibFoo"].Ref)
+
+	// New commits are left out until the pin is updated
+	newHead := commitLib(t, "local version = 2\n")
+	contents, err := build(t, true)
+	require.NoError(t, err)
+	assert.Equal(t, "local version = 1\n", contents)
+	contents, err = build(t, false)
+	require.NoError(t, err)
+	assert.Equal(t, "local version = 1\n", contents)
+
+	update(t)
+	lock, err = pkg.ReadExternalsLock(topDir)
+	require.NoError(t, err)
+	assert.Equal(t, newHead, lock.Externals["Libs/LibFoo"].Revision)
+	contents, err = build(t, true)
+	require.NoError(t, err)
+	assert.Equal(t, "local version = 2\n", contents)
+}
+
+func TestBuild_RecursiveExternals(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	stub := newTestAddon(t, map[string]string{"LibStub.lua": "local LibStub = {}\n"})
+	lib := newTestAddon(t, map[string]string{
+		"LibFoo.lua": "local LibStub = _G.LibStub\n",
+		".pkgmeta": "externals:\n" +
+			"  LibStub:\n" +
+			"    url: " + stub + "\n" +
+			"    type: git\n",
+	})
+	topDir := newTestAddon(t, map[string]string{
+		"Project.toc": "## Interface: 110100\n## Title: Project\n\nLibs/LibFoo/LibFoo.lua\n",
+		".pkgmeta": "package-as: Project\n" +
+			"externals-recursive: true\n" +
+			"externals:\n" +
+			"  Libs/LibFoo:\n" +
+			"    url: " + lib + "\n" +
+			"    type: git\n",
+	})
+	releaseDir := filepath.Join(t.TempDir(), ".release")
+
+	ctx, err := NewBuildContext(&Options{
+		TopDir:        topDir,
+		ReleaseDir:    releaseDir,
+		SkipChangelog: true,
+		SkipUpload:    true,
+		SkipZip:       true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, DefaultPipeline().Run(ctx))
+
+	assert.FileExists(t, filepath.Join(releaseDir, "Project", "Libs", "LibFoo", "LibFoo.lua"))
+	assert.FileExists(t, filepath.Join(releaseDir, "Project", "Libs", "LibFoo", "LibStub", "LibStub.lua"))
+	assert.Contains(t, ctx.PkgMeta.Externals, "Libs/LibFoo/LibStub")
+}
+
+func TestBuild_Workspace(t *testing.T) {
+	tests := []struct {
+		name     string
+		pkgmeta  string
+		wantZips map[string][]string
+	}{
+		{
+			name: "discovered",
+			wantZips: map[string][]string{
+				"Core.zip":   {"Core/Core.toc", "Core/Core.lua"},
+				"Plugin.zip": {"Plugin/Plugin.toc", "Plugin/Plugin.lua"},
+			},
+		},
+		{
+			name:    "listed",
+			pkgmeta: "packages:\n  - Plugin\n",
+			wantZips: map[string][]string{
+				"Plugin.zip": {"Plugin/Plugin.toc", "Plugin/Plugin.lua"},
+			},
+		},
+		{
+			name:    "combined",
+			pkgmeta: "package-as: Suite\ncombine-packages: true\npackages:\n  - Core\n  - Plugin\n",
+			wantZips: map[string][]string{
+				"Suite.zip": {"Core/Core.toc", "Core/Core.lua", "Plugin/Plugin.toc", "Plugin/Plugin.lua"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("GITHUB_ACTIONS", "")
+
+			files := map[string]string{
+				"Core/Core.toc":     "## Interface: 110100\n## Title: Core\n## X-Curse-Project-ID: 1\n\nCore.lua\n",
+				"Core/Core.lua":     "local _, ns = ...\n",
+				"Core/.pkgmeta":     "package-as: Core\n",
+				"Plugin/Plugin.toc": "## Interface: 110100\n## Title: Plugin\n## X-Curse-Project-ID: 2\n\nPlugin.lua\n",
+				"Plugin/Plugin.lua": "local _, ns = ...\n",
+			}
+			if tt.pkgmeta != "" {
+				files[".pkgmeta"] = tt.pkgmeta
+			}
+			topDir := newTestAddon(t, files)
+			releaseDir := filepath.Join(t.TempDir(), ".release")
+
+			ws, err := FindWorkspace(&Options{
+				TopDir:        topDir,
+				ReleaseDir:    releaseDir,
+				SkipChangelog: true,
+				NameTemplate:  "{package-name}",
+			})
+			require.NoError(t, err)
+			require.NotNil(t, ws)
+
+			stage, rec := recordingPublishStage()
+			p := DefaultPipeline()
+			require.NoError(t, p.Replace(StagePublish, stage))
+			_, err = ws.Run(p)
+			require.NoError(t, err)
+
+			zips, err := filepath.Glob(filepath.Join(releaseDir, "*.zip"))
+			require.NoError(t, err)
+			var zipNames []string
+			for _, zip := range zips {
+				zipNames = append(zipNames, filepath.Base(zip))
+			}
+			assert.ElementsMatch(t, slices.Collect(maps.Keys(tt.wantZips)), zipNames)
+
+			for name, want := range tt.wantZips {
+				entries := zipEntries(t, filepath.Join(releaseDir, name))
+				for _, entry := range want {
+					assert.Contains(t, entries, entry, name)
+				}
+			}
+			assert.Contains(t, rec.payloads, "curse")
+		})
+	}
+}
+
+func TestFindWorkspace_SingleAddon(t *testing.T) {
+	topDir := newTestAddon(t, map[string]string{
+		"Project.toc":                         "## Interface: 110100\n## Title: Project\n",
+		"Modules/Options/Project_Options.toc": "## Interface: 110100\n## Title: Project Options\n",
+	})
+
+	ws, err := FindWorkspace(&Options{TopDir: topDir})
+	require.NoError(t, err)
+	assert.Nil(t, ws)
+
+	_, err = FindWorkspace(&Options{TopDir: topDir, PkgmetaFile: "missing.yml"})
+	assert.Error(t, err)
+}
+
+func TestUploadPipeline(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	topDir := newTestAddon(t, map[string]string{
+		"Project.toc": "## Interface: 110100\n## Title: Project\n\nProject.lua\n",
+		"Project.lua": "local _, ns = ...\n",
+		".pkgmeta":    "package-as: Project\nenable-nolib-creation: true\n",
+	})
+	releaseDir := filepath.Join(t.TempDir(), ".release")
+	opts := func() *Options {
+		return &Options{
+			TopDir:       topDir,
+			ReleaseDir:   releaseDir,
+			NameTemplate: "{package-name}{nolib}",
+		}
+	}
+
+	ctx, err := NewBuildContext(opts())
+	require.NoError(t, err)
+	p := UploadPipeline(&PrebuiltPackageStage{})
+	assert.ErrorContains(t, p.Run(ctx), "could not find the package to upload")
+
+	built, err := NewBuildContext(opts())
+	require.NoError(t, err)
+	built.Options.SkipUpload = true
+	require.NoError(t, DefaultPipeline().Run(built))
+
+	stage, rec := recordingPublishStage()
+	ctx, err = NewBuildContext(opts())
+	require.NoError(t, err)
+	ctx.FileLabel = "Retry"
+	p = UploadPipeline(&PrebuiltPackageStage{})
+	require.NoError(t, p.Replace(StagePublish, stage))
+	require.NoError(t, p.Run(ctx))
+
+	assert.Equal(t, built.ZipPath, ctx.ZipPath)
+	assert.Equal(t, built.NoLibZipPath, ctx.NoLibZipPath)
+	assert.NotEmpty(t, ctx.NoLibZipPath)
+	assert.Equal(t, "Retry", rec.payloads["curse"].FileLabel)
+	// The changelog was generated in a temporary directory, outside of the package
+	assert.NotContains(t, ctx.Changelog.PreExistingFilePath, releaseDir)
+	assert.NoFileExists(t, ctx.Changelog.PreExistingFilePath)
+}
+
+func TestPublishStage_TurnedOff(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	topDir := newTestAddon(t, map[string]string{
+		"Project.toc": "## Interface: 110100\n## Title: Project\n\nProject.lua\n",
+		"Project.lua": "local _, ns = ...\n",
+		".pkgmeta":    "package-as: Project\npublish:\n  wowi: false\n  github: true\n",
+	})
+
+	stage, rec := recordingPublishStage()
+	ctx, err := NewBuildContext(&Options{
+		TopDir:           topDir,
+		ReleaseDir:       filepath.Join(t.TempDir(), ".release"),
+		SkipChangelog:    true,
+		OnlyLocalization: true,
+	})
+	require.NoError(t, err)
+
+	p := DefaultPipeline()
+	require.NoError(t, p.Replace(StagePublish, stage))
+	require.NoError(t, p.Run(ctx))
+
+	assert.ElementsMatch(t, []string{"wago", "github"}, slices.Collect(maps.Keys(rec.payloads)))
+}
+
+func TestBuild_SplitTocSeparatorInName(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	for _, name := range []string{"My_Addon", "Foo-Bar"} {
+		t.Run(name, func(t *testing.T) {
+			topDir := newTestAddon(t, map[string]string{
+				name + ".toc": "## Interface: 11507, 110100\n## Title: " + name + "\n\nAddon.lua\n",
+				"Addon.lua":   "local _, ns = ...\n",
+				".pkgmeta":    "package-as: " + name + "\n",
+			})
+			releaseDir := filepath.Join(t.TempDir(), ".release")
+
+			ctx, err := Build(&Options{
+				TopDir:        topDir,
+				ReleaseDir:    releaseDir,
+				SkipChangelog: true,
+				SkipUpload:    true,
+				SkipZip:       true,
+				SplitToc:      true,
+			})
+			require.NoError(t, err)
+
+			assert.FileExists(t, filepath.Join(ctx.PackageDir, name+"_Vanilla.toc"))
+			assert.FileExists(t, filepath.Join(ctx.PackageDir, name+"_Mainline.toc"))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package packager
+
+import (
+	"os"
+
+	"github.com/McTalian/wow-build-tools/internal/changelog"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+)
+
+// ChangelogStage generates the changelog, or uses the one configured in the pkgmeta file.
+type ChangelogStage struct{}
+
+func (s *ChangelogStage) Name() string { return StageChangelog }
+
+func (s *ChangelogStage) Run(ctx *BuildContext) error {
+	if ctx.Options.SkipChangelog {
+		return nil
+	}
+
+	l := logger.DefaultLogger
+
+	changelogTitle := ctx.ProjectName
+	if ctx.PkgMeta.ChangelogTitle != "" {
+		changelogTitle = ctx.PkgMeta.ChangelogTitle
+	}
+
+	// The changelog is written to the package directory, or a temporary one when nothing was
+	// copied, e.g. when uploading the zips of an earlier build
+	pkgDir := ctx.PackageDir
+	if pkgDir == "" {
+		tmpDir, err := os.MkdirTemp("", "wbtChangelog*")
+		if err != nil {
+			l.Error("Changelog Error: %v", err)
+			return err
+		}
+		ctx.AddCleanup(func() { os.RemoveAll(tmpDir) })
+		pkgDir = tmpDir
+	}
+
+	cl, err := changelog.NewChangelog(ctx.Repo, ctx.PkgMeta, changelogTitle, pkgDir, ctx.TopDir)
+	if err != nil {
+		l.Error("Changelog Error: %v", err)
+		return err
+	}
+	err = cl.GetChangelog()
+	if err != nil {
+		l.Error("GetChangelog Error: %v", err)
+		return err
+	}
+	ctx.AddCleanup(cl.Cleanup)
+	ctx.Changelog = cl
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package packager
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/McTalian/wow-build-tools/internal/changelog"
+	"github.com/McTalian/wow-build-tools/internal/injector"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/pkg"
+	"github.com/McTalian/wow-build-tools/internal/repo"
+	"github.com/McTalian/wow-build-tools/internal/toc"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+)
+
+// BuildContext is the state of a single build, filled in by the stages as they run.
+type BuildContext struct {
+	Options *Options
+	Start   time.Time
+
+	// Set by NewBuildContext
+	GameVersions *toc.GameVersionSet
+	NameTemplate *tokens.NameTemplate
+	TopDir       string
+	// Workspace is set when the addon is one of the packages of a workspace
+	Workspace *Workspace
+
+	// Set by the resolve stage
+	ProjectName     string
+	TocFiles        []*toc.Toc
+	Repo            repo.VcsRepo
+	PkgMeta         *pkg.PkgMeta
+	TokenMap        tokens.SimpleTokenMap
+	Flags           tokens.FlagMap
+	BuildTypeTokens tokens.BuildTypeTokenMap
+	ReleaseType     string
+
+	// Set by the copy stage, AddonDirs gets the folders created by the move-folders stage appended
+	PackageDir string
+	AddonDirs  []string
+
+	// Set by the changelog stage, empty when the changelog is skipped
+	Changelog *changelog.Changelog
+
+	// Set by the inject stage
+	Injector *injector.Injector
+
+	// Set by the package stage, NoLibZipPath is empty when no no-lib package was created
+	ZipPath      string
+	NoLibZipPath string
+	// FileLabel is the label of the uploaded files, the name template's label when empty
+	FileLabel string
+
+	// Set for the combined package of a workspace, the addons that are zipped together
+	Packages []*BuildContext
+
+	cleanups []func()
+}
+
+// NewBuildContext validates the options and creates the context for a build.
+func NewBuildContext(opts *Options) (*BuildContext, error) {
+	ctx := &BuildContext{
+		Options:      opts,
+		Start:        time.Now(),
+		GameVersions: toc.NewGameVersionSet(),
+		TopDir:       opts.TopDir,
+		Changelog:    &changelog.Changelog{},
+	}
+
+	err := ctx.GameVersions.ParseGameVersionFlag(opts.GameVersion)
+	if err != nil {
+		logger.Error("Error validating game version input argument: %v", err)
+		return nil, err
+	}
+
+	ctx.NameTemplate, err = tokens.NewNameTemplate(opts.NameTemplate)
+	if err != nil {
+		logger.Error("Error parsing name template: %v", err)
+		return nil, err
+	}
+
+	timeNowUtc := ctx.Start.UTC()
+	ctx.TokenMap = tokens.SimpleTokenMap{
+		tokens.BuildTimestamp:   strconv.FormatInt(ctx.Start.Unix(), 10),
+		tokens.BuildDate:        timeNowUtc.Format("2006-01-02"),
+		tokens.BuildDateIso:     timeNowUtc.Format("2006-01-02T15:04:05Z"),
+		tokens.BuildDateInteger: timeNowUtc.Format("20060102150405"),
+		tokens.BuildYear:        timeNowUtc.Format("2006"),
+	}
+	ctx.Flags = tokens.FlagMap{
+		tokens.NoLibFlag:   "",
+		tokens.AlphaFlag:   "",
+		tokens.BetaFlag:    "",
+		tokens.ClassicFlag: "",
+	}
+
+	return ctx, nil
+}
+
+// CurseId returns the CurseForge project id from the options, or else from the TOC files once
+// they have been resolved.
+func (ctx *BuildContext) CurseId() string {
+	projectId := ctx.Options.CurseId
+	for _, t := range ctx.TocFiles {
+		if projectId == "" {
+			projectId = t.CurseId
+		}
+	}
+	return projectId
+}
+
+// AddCleanup registers a function to run once the pipeline has finished, whether it failed or not.
+func (ctx *BuildContext) AddCleanup(cleanup func()) {
+	ctx.cleanups = append(ctx.cleanups, cleanup)
+}
+
+// packages returns the contexts of the addons in the zip, which is only this one outside of
+// combined workspace packages.
+func (ctx *BuildContext) packages() []*BuildContext {
+	if len(ctx.Packages) > 0 {
+		return ctx.Packages
+	}
+	return []*BuildContext{ctx}
+}
+
+func (ctx *BuildContext) cleanup() {
+	for i := len(ctx.cleanups) - 1; i >= 0; i-- {
+		ctx.cleanups[i]()
+	}
+	ctx.cleanups = nil
+}
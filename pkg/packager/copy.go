@@ -0,0 +1,48 @@
+package packager
+
+import (
+	"github.com/McTalian/wow-build-tools/internal/license"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/pkg"
+)
+
+// CopyStage prepares the package directory and copies the project files into it.
+type CopyStage struct{}
+
+func (s *CopyStage) Name() string { return StageCopy }
+
+func (s *CopyStage) Run(ctx *BuildContext) error {
+	l := logger.DefaultLogger
+	opts := ctx.Options
+
+	copyLogGroup := logger.NewLogGroup("🗃️  Preparing Package Directory")
+	l.Debug("Top Directory: %s", ctx.TopDir)
+	l.Debug("Release Directory: %s", opts.ReleaseDir)
+	l.Debug("Project Name: %s", ctx.ProjectName)
+	packageDir, err := pkg.PreparePkgDir(ctx.ProjectName, opts.ReleaseDir, opts.KeepPackageDir)
+	l.Debug("Package Directory: %s", packageDir)
+	if err != nil {
+		l.Error("Error preparing package directory: %v", err)
+		return err
+	}
+	ctx.PackageDir = packageDir
+	ctx.AddonDirs = []string{packageDir}
+
+	err = license.EnsureLicensePresent(ctx.PkgMeta.License, ctx.TopDir, packageDir, opts.CurseId)
+	if err != nil {
+		l.Error("License Error: %v", err)
+		return err
+	}
+
+	if !opts.SkipCopy {
+		projCopy := pkg.NewPkgCopy(ctx.TopDir, packageDir, ctx.PkgMeta.Ignore, ctx.Repo)
+		err = projCopy.CopyToPackageDir(copyLogGroup)
+		if err != nil {
+			l.Error("Copy Error: %v", err)
+			return err
+		}
+	}
+	copyLogGroup.Flush(true)
+
+	return nil
+}
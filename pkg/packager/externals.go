@@ -0,0 +1,80 @@
+package packager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/pkg"
+)
+
+// ExternalsStage fetches the externals of the pkgmeta file into the package directory.
+//
+// When the project has a lock file, externals are checked out at their pinned revisions and
+// new externals are added to it. A frozen build fails instead of changing the lock file.
+type ExternalsStage struct {
+	// Update resolves the externals again instead of using their pins, and creates the lock
+	// file when there is none.
+	Update bool
+	// UpdatePaths limits Update to the externals with these paths, all of them when empty.
+	UpdatePaths []string
+}
+
+func (s *ExternalsStage) Name() string { return StageExternals }
+
+func (s *ExternalsStage) readLock(ctx *BuildContext) (*pkg.ExternalsLock, error) {
+	for _, path := range s.UpdatePaths {
+		if _, ok := ctx.PkgMeta.Externals[path]; !ok {
+			return nil, fmt.Errorf("no external with the path %s in the pkgmeta file", path)
+		}
+	}
+
+	lock, err := pkg.ReadExternalsLock(ctx.TopDir)
+	if errors.Is(err, os.ErrNotExist) {
+		if ctx.Options.Frozen {
+			return nil, fmt.Errorf("frozen builds need a %s file, run `wow-build-tools externals update` to create one", pkg.LockFileName)
+		}
+		if !s.Update {
+			return nil, nil
+		}
+		return pkg.NewExternalsLock(ctx.TopDir), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	lock.Frozen = ctx.Options.Frozen
+	if s.Update {
+		lock.Unpin(s.UpdatePaths...)
+	}
+	return lock, nil
+}
+
+func (s *ExternalsStage) Run(ctx *BuildContext) error {
+	if ctx.Options.SkipExternals {
+		return nil
+	}
+
+	lock, err := s.readLock(ctx)
+	if err != nil {
+		logger.Error("Lock File Error: %v", err)
+		return err
+	}
+
+	// Updated externals skip the cache so branches resolve to their latest commit
+	err = ctx.PkgMeta.FetchExternals(ctx.PackageDir, ctx.Options.ForceExternals || s.Update, lock)
+	if err != nil {
+		logger.Error("Fetch Externals Error: %v", err)
+		return err
+	}
+
+	if lock != nil && !lock.Frozen {
+		lock.Prune(ctx.PkgMeta.Externals)
+		if err = lock.Write(); err != nil {
+			logger.Error("Lock File Error: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
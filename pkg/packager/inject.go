@@ -0,0 +1,152 @@
+package packager
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/McTalian/wow-build-tools/internal/injector"
+	"github.com/McTalian/wow-build-tools/internal/locale"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/toc"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+)
+
+// InjectStage splits TOC files when requested and replaces the tokens in the package directory.
+type InjectStage struct{}
+
+func (s *InjectStage) Name() string { return StageInject }
+
+func (s *InjectStage) Run(ctx *BuildContext) error {
+	l := logger.DefaultLogger
+	bTTM := ctx.BuildTypeTokens
+
+	i, err := injector.NewInjector(ctx.TokenMap, ctx.Repo, ctx.PackageDir, bTTM, ctx.Options.UnixLineEndings)
+	if err != nil {
+		l.Error("Injector Error: %v", err)
+		return err
+	}
+	ctx.Injector = i
+
+	if len(ctx.PkgMeta.PlainCopy) > 0 {
+		i.SetPlainCopy(ctx.PkgMeta.IsPlainCopy)
+	}
+
+	if !ctx.Options.SkipLocalization {
+		if err := s.setLocalization(ctx, i); err != nil {
+			l.Error("Localization Error: %v", err)
+			return err
+		}
+	}
+
+	if len(ctx.GameVersions.Flavors()) > 1 {
+		fileFlavors, err := toc.FileFlavors(ctx.PackageDir, ctx.TocFiles)
+		if err != nil {
+			l.Error("TOC Error: %v", err)
+			return err
+		}
+
+		for path, fileFlavor := range fileFlavors {
+			if len(fileFlavor) == 1 {
+				i.SetFileBuildTypeTokens(path, flavorBuildTypeTokens(bTTM, fileFlavor[0]))
+			}
+		}
+	}
+
+	// enable-toc-creation only kicks in when the project ships a single base TOC
+	createTocs := ctx.PkgMeta.EnableTocCreation && len(ctx.TocFiles) == 1
+	if ctx.Options.SplitToc || createTocs {
+		for _, t := range ctx.TocFiles {
+			// Addon names can contain the separators too, only a known flavor suffix makes it a
+			// flavor TOC
+			if flavor, suffix := toc.TocFileToGameFlavor(strings.TrimSuffix(filepath.Base(t.Filepath), ".toc")); suffix != "" && flavor != toc.Unknown {
+				continue
+			}
+
+			pkgTocPath := filepath.Join(ctx.PackageDir, filepath.Base(t.Filepath))
+			if _, err := os.Stat(pkgTocPath); err != nil {
+				l.Warn("Unable to split %s: %v", filepath.Base(t.Filepath), err)
+				continue
+			}
+
+			splitTocs, err := t.Split(pkgTocPath)
+			if err != nil {
+				l.Error("Split TOC Error: %v", err)
+				return err
+			}
+
+			for flavor, splitTocPath := range splitTocs {
+				l.Verbose("Created %s", splitTocPath)
+				i.SetFileBuildTypeTokens(splitTocPath, flavorBuildTypeTokens(bTTM, flavor))
+			}
+		}
+	}
+
+	err = i.Execute()
+	if err != nil {
+		l.Error("Injector Execute Error: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// setLocalization enables @localization@ replacement from the pkgmeta locale files, or from
+// CurseForge when the project and API key are known.
+func (s *InjectStage) setLocalization(ctx *BuildContext, i *injector.Injector) error {
+	if localization := ctx.PkgMeta.Localization; len(localization.Files) > 0 {
+		catalog, err := locale.Load(ctx.TopDir, localization.BaseLocale, localization.Files)
+		if err != nil {
+			return err
+		}
+		logger.Verbose("Loaded localization for %s", strings.Join(catalog.Locales(), ", "))
+		i.SetLocalization(catalog)
+		return nil
+	}
+
+	projectId := ctx.CurseId()
+	apiKey, found := os.LookupEnv("CF_API_KEY")
+	switch {
+	case projectId == "":
+		logger.Verbose("No CurseForge project id, skipping @localization@ replacement")
+	case !found:
+		logger.Verbose("CF_API_KEY not set, skipping @localization@ replacement")
+	default:
+		i.SetLocalization(injector.NewCurseLocalization(projectId, apiKey))
+	}
+	return nil
+}
+
+// flavorTokens returns the build type tokens whose value depends on the game flavor being built.
+func flavorTokens() []tokens.BuildTypeToken {
+	var flavorTokens []tokens.BuildTypeToken
+	for _, f := range toc.Flavors() {
+		for _, token := range f.BuildTypeTokens {
+			if !slices.Contains(flavorTokens, tokens.BuildTypeToken(token)) {
+				flavorTokens = append(flavorTokens, tokens.BuildTypeToken(token))
+			}
+		}
+	}
+	return flavorTokens
+}
+
+// flavorBuildTypeTokens returns a copy of base with the build type tokens for the given flavor enabled
+// and the ones for every other flavor disabled.
+func flavorBuildTypeTokens(base tokens.BuildTypeTokenMap, flavor toc.GameFlavor) tokens.BuildTypeTokenMap {
+	bTTM := make(tokens.BuildTypeTokenMap, len(base))
+	for token, value := range base {
+		bTTM[token] = value
+	}
+	for _, token := range flavorTokens() {
+		bTTM[token] = false
+	}
+
+	if f, ok := toc.LookupFlavor(flavor); ok {
+		for _, token := range f.BuildTypeTokens {
+			bTTM[tokens.BuildTypeToken(token)] = true
+		}
+	}
+
+	return bTTM
+}
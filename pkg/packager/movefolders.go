@@ -0,0 +1,31 @@
+package packager
+
+import (
+	"github.com/McTalian/wow-build-tools/internal/logger"
+)
+
+// MoveFoldersStage applies the `move-folders` entries of the pkgmeta file, turning folders of
+// the package directory into addons of their own.
+type MoveFoldersStage struct{}
+
+func (s *MoveFoldersStage) Name() string { return StageMoveFolders }
+
+func (s *MoveFoldersStage) Run(ctx *BuildContext) error {
+	if len(ctx.PkgMeta.MoveFolders) == 0 {
+		return nil
+	}
+
+	addonDirs, err := ctx.PkgMeta.ApplyMoveFolders(ctx.Options.ReleaseDir)
+	if err != nil {
+		logger.Error("Move Folders Error: %v", err)
+		return err
+	}
+
+	for _, dir := range addonDirs {
+		if dir != ctx.PackageDir {
+			ctx.AddonDirs = append(ctx.AddonDirs, dir)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package packager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/McTalian/wow-build-tools/internal/github"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+	"github.com/McTalian/wow-build-tools/internal/zipper"
+)
+
+// PackageStage zips the addon folders, plus a no-lib zip when requested.
+type PackageStage struct{}
+
+func (s *PackageStage) Name() string { return StagePackage }
+
+func (s *PackageStage) Run(ctx *BuildContext) error {
+	l := logger.DefaultLogger
+	opts := ctx.Options
+
+	err := github.Output(string(tokens.ProjectVersion), ctx.TokenMap[tokens.ProjectVersion])
+	if err != nil {
+		l.Error("Output Error: %v", err)
+		return err
+	}
+
+	if opts.SkipZip {
+		return nil
+	}
+
+	isNoLib := (opts.CreateNoLib || ctx.PkgMeta.EnableNoLibCreation) && !opts.WatchMode
+	if isNoLib && !ctx.NameTemplate.HasNoLib {
+		l.Warn("Provided file and/or label template did not contain %s, but no-lib package requested. Skipping no-lib package since the zip name will not be unique.", tokens.NoLibFlag.NormalizeTemplateToken())
+		isNoLib = false
+	}
+
+	zipsToCreate := 1
+	if isNoLib {
+		zipsToCreate++
+	}
+	var zipWGroup sync.WaitGroup
+	zipErrChan := make(chan error, zipsToCreate)
+
+	ctx.ZipPath = zipPath(ctx, false)
+	if isNoLib {
+		ctx.NoLibZipPath = zipPath(ctx, true)
+	}
+
+	z := zipper.NewZipper(ctx.PackageDir, opts.ReleaseDir, ctx.TopDir, opts.UnixLineEndings)
+	zipWGroup.Add(1)
+	go func() {
+		defer zipWGroup.Done()
+		if err := z.ZipFolders(ctx.AddonDirs, ctx.ZipPath); err != nil {
+			zipErrChan <- err
+			return
+		}
+		if err := github.Output("main-zip-path", ctx.ZipPath); err != nil {
+			zipErrChan <- err
+			return
+		}
+	}()
+
+	if isNoLib {
+		var dirsToExclude, noLibStripFiles []string
+		for _, p := range ctx.packages() {
+			for _, dir := range p.PkgMeta.GetNoLibDirs(p.PackageDir) {
+				dirsToExclude = append(dirsToExclude, p.PkgMeta.MovedPath(opts.ReleaseDir, dir))
+			}
+			if p.Injector != nil {
+				for _, file := range p.Injector.NoLibStripFiles {
+					noLibStripFiles = append(noLibStripFiles, p.PkgMeta.MovedPath(opts.ReleaseDir, file))
+				}
+			}
+		}
+		zipWGroup.Add(1)
+		go func() {
+			defer zipWGroup.Done()
+			if err := z.ZipFolders(ctx.AddonDirs, ctx.NoLibZipPath, dirsToExclude, noLibStripFiles); err != nil {
+				zipErrChan <- err
+				return
+			}
+			if err := github.Output("nolib-zip-path", ctx.NoLibZipPath); err != nil {
+				zipErrChan <- err
+				return
+			}
+		}()
+	}
+
+	zipWGroup.Wait()
+	close(zipErrChan)
+	z.Complete()
+
+	// Collect errors
+	for err := range zipErrChan {
+		if err != nil {
+			l.Error("Zip Error: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// zipPath returns the path of the zip in the release directory, named by the name template.
+func zipPath(ctx *BuildContext, noLib bool) string {
+	if noLib {
+		ctx.Flags[tokens.NoLibFlag] = "-nolib"
+		defer func() { ctx.Flags[tokens.NoLibFlag] = "" }()
+	}
+	return filepath.Join(ctx.Options.ReleaseDir, ctx.NameTemplate.GetFileName(&ctx.TokenMap, ctx.Flags)+".zip")
+}
+
+// PrebuiltPackageStage uses the zips of an earlier build in place of the package stage, e.g.
+// to retry uploading them. The zips are the ones named by the name template in the release
+// directory, unless their paths are given.
+type PrebuiltPackageStage struct {
+	ZipPath      string
+	NoLibZipPath string
+}
+
+func (s *PrebuiltPackageStage) Name() string { return StagePackage }
+
+func (s *PrebuiltPackageStage) Run(ctx *BuildContext) error {
+	ctx.ZipPath = s.ZipPath
+	if ctx.ZipPath == "" {
+		ctx.ZipPath = zipPath(ctx, false)
+	}
+	if _, err := os.Stat(ctx.ZipPath); err != nil {
+		return fmt.Errorf("could not find the package to upload: %w", err)
+	}
+
+	ctx.NoLibZipPath = s.NoLibZipPath
+	if ctx.NoLibZipPath != "" {
+		if _, err := os.Stat(ctx.NoLibZipPath); err != nil {
+			return fmt.Errorf("could not find the no-lib package to upload: %w", err)
+		}
+	} else if ctx.NameTemplate.HasNoLib {
+		if noLibZipPath := zipPath(ctx, true); noLibZipPath != ctx.ZipPath {
+			if _, err := os.Stat(noLibZipPath); err == nil {
+				ctx.NoLibZipPath = noLibZipPath
+			}
+		}
+	}
+
+	logger.Verbose("Using the packages %s %s", ctx.ZipPath, ctx.NoLibZipPath)
+	return nil
+}
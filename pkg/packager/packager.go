@@ -0,0 +1,66 @@
+// Package packager builds World of Warcraft addons the same way the `wow-build-tools build`
+// command does, for tools that want to embed the packager.
+//
+// A build is a BuildContext run through a Pipeline of stages. DefaultPipeline returns the
+// stages used by the build command, which can be skipped, replaced or extended with custom
+// stages:
+//
+//	ctx, err := packager.NewBuildContext(&packager.Options{TopDir: ".", ReleaseDir: ".release"})
+//	if err != nil {
+//		return err
+//	}
+//	p := packager.DefaultPipeline()
+//	p.Skip(packager.StagePublish)
+//	err = p.InsertAfter(packager.StagePackage, myStage)
+//	...
+//	return p.Run(ctx)
+//
+// Repositories with several addons in sub folders are built with FindWorkspace and
+// Workspace.Run, which runs the pipeline for each of them.
+package packager
+
+// Options are the inputs of a build, matching the flags of the build command.
+type Options struct {
+	TopDir      string
+	ReleaseDir  string
+	PkgmetaFile string
+	GameVersion string
+
+	WatchMode    bool
+	LevelVerbose bool
+	LevelDebug   bool
+
+	CurseId string
+	WagoId  string
+	WowiId  string
+
+	SkipChangelog    bool
+	SkipCopy         bool
+	SkipExternals    bool
+	SkipUpload       bool
+	SkipLocalization bool
+	SkipZip          bool
+
+	ForceExternals   bool
+	Frozen           bool
+	OnlyLocalization bool
+	// DryRunUpload writes the requests of the uploads to the upload plan of the release
+	// directory instead of sending them
+	DryRunUpload bool
+
+	CreateNoLib     bool
+	KeepPackageDir  bool
+	NameTemplate    string
+	SplitToc        bool
+	UnixLineEndings bool
+}
+
+// Build runs the default pipeline with the given options.
+func Build(opts *Options) (*BuildContext, error) {
+	ctx, err := NewBuildContext(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx, DefaultPipeline().Run(ctx)
+}
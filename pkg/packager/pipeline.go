@@ -0,0 +1,159 @@
+package packager
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Stage is a single step of a build.
+type Stage interface {
+	// Name identifies the stage within a pipeline.
+	Name() string
+	Run(ctx *BuildContext) error
+}
+
+// The names of the built-in stages.
+const (
+	StageResolve     = "resolve"
+	StageCopy        = "copy"
+	StageChangelog   = "changelog"
+	StageInject      = "inject"
+	StageExternals   = "externals"
+	StageMoveFolders = "move-folders"
+	StagePackage     = "package"
+	StagePublish     = "publish"
+)
+
+// Pipeline runs its stages in order.
+type Pipeline struct {
+	stages  []Stage
+	skipped map[string]bool
+}
+
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{
+		stages:  stages,
+		skipped: make(map[string]bool),
+	}
+}
+
+// DefaultPipeline returns the stages of the build command.
+//
+// The changelog is generated before the injector runs so it gets its tokens replaced, and
+// externals are fetched afterwards so they are packaged as-is. Folders are moved last: the
+// injector only replaces the tokens of the package directory, which holds the folders until
+// then, and externals can be moved along with them.
+func DefaultPipeline() *Pipeline {
+	return NewPipeline(
+		&ResolveStage{},
+		&CopyStage{},
+		&ChangelogStage{},
+		&InjectStage{},
+		&ExternalsStage{},
+		&MoveFoldersStage{},
+		&PackageStage{},
+		NewPublishStage(),
+	)
+}
+
+// UploadPipeline returns the stages that upload the zips of an earlier build without building
+// them again. The project is resolved and the changelog generated like they are for a build.
+func UploadPipeline(prebuilt *PrebuiltPackageStage) *Pipeline {
+	return NewPipeline(
+		&ResolveStage{},
+		&ChangelogStage{},
+		prebuilt,
+		NewPublishStage(),
+	)
+}
+
+// Stages returns the stages of the pipeline in the order they run, including skipped ones.
+func (p *Pipeline) Stages() []Stage {
+	return slices.Clone(p.stages)
+}
+
+func (p *Pipeline) index(name string) (int, error) {
+	i := slices.IndexFunc(p.stages, func(s Stage) bool { return s.Name() == name })
+	if i == -1 {
+		return -1, fmt.Errorf("no stage named %s in the pipeline", name)
+	}
+	return i, nil
+}
+
+// Stage returns the stage with the given name.
+func (p *Pipeline) Stage(name string) (Stage, bool) {
+	i, err := p.index(name)
+	if err != nil {
+		return nil, false
+	}
+	return p.stages[i], true
+}
+
+// Replace swaps the stage with the given name for another one.
+func (p *Pipeline) Replace(name string, stage Stage) error {
+	i, err := p.index(name)
+	if err != nil {
+		return err
+	}
+	p.stages[i] = stage
+	return nil
+}
+
+// InsertBefore adds a stage right before the stage with the given name.
+func (p *Pipeline) InsertBefore(name string, stage Stage) error {
+	i, err := p.index(name)
+	if err != nil {
+		return err
+	}
+	p.stages = slices.Insert(p.stages, i, stage)
+	return nil
+}
+
+// InsertAfter adds a stage right after the stage with the given name.
+func (p *Pipeline) InsertAfter(name string, stage Stage) error {
+	i, err := p.index(name)
+	if err != nil {
+		return err
+	}
+	p.stages = slices.Insert(p.stages, i+1, stage)
+	return nil
+}
+
+// Append adds a stage at the end of the pipeline.
+func (p *Pipeline) Append(stage Stage) {
+	p.stages = append(p.stages, stage)
+}
+
+// Skip keeps the stage with the given name from running.
+func (p *Pipeline) Skip(name string) {
+	p.skipped[name] = true
+}
+
+// Run runs every stage that isn't skipped, stopping at the first error.
+func (p *Pipeline) Run(ctx *BuildContext) error {
+	defer ctx.cleanup()
+
+	return p.runStages(ctx, p.stages)
+}
+
+func (p *Pipeline) runStages(ctx *BuildContext, stages []Stage) error {
+	for _, stage := range stages {
+		if p.skipped[stage.Name()] {
+			continue
+		}
+		if err := stage.Run(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunStage runs a single stage of the pipeline, e.g. to repeat it on an existing context.
+func (p *Pipeline) RunStage(name string, ctx *BuildContext) error {
+	i, err := p.index(name)
+	if err != nil {
+		return err
+	}
+	return p.stages[i].Run(ctx)
+}
@@ -0,0 +1,132 @@
+package packager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type funcStage struct {
+	name string
+	run  func(ctx *BuildContext) error
+}
+
+func (s *funcStage) Name() string                { return s.name }
+func (s *funcStage) Run(ctx *BuildContext) error { return s.run(ctx) }
+
+func recordingStages(ran *[]string, names ...string) []Stage {
+	var stages []Stage
+	for _, name := range names {
+		stages = append(stages, &funcStage{name: name, run: func(ctx *BuildContext) error {
+			*ran = append(*ran, name)
+			return nil
+		}})
+	}
+	return stages
+}
+
+func stageNames(p *Pipeline) []string {
+	var names []string
+	for _, s := range p.Stages() {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+func TestDefaultPipeline(t *testing.T) {
+	assert.Equal(t, []string{
+		StageResolve,
+		StageCopy,
+		StageChangelog,
+		StageInject,
+		StageExternals,
+		StageMoveFolders,
+		StagePackage,
+		StagePublish,
+	}, stageNames(DefaultPipeline()))
+}
+
+func TestPipeline_Run(t *testing.T) {
+	ran := &[]string{}
+	p := NewPipeline(recordingStages(ran, "a", "b", "c")...)
+
+	extra := recordingStages(ran, "before-b", "after-c", "replaced-a", "last")
+	require.NoError(t, p.InsertBefore("b", extra[0]))
+	require.NoError(t, p.InsertAfter("c", extra[1]))
+	require.NoError(t, p.Replace("a", extra[2]))
+	p.Append(extra[3])
+	p.Skip("c")
+
+	assert.Error(t, p.Replace("missing", extra[0]))
+	assert.Error(t, p.InsertBefore("missing", extra[0]))
+	assert.Error(t, p.InsertAfter("missing", extra[0]))
+
+	ctx := &BuildContext{}
+	cleanedUp := false
+	ctx.AddCleanup(func() { cleanedUp = true })
+
+	require.NoError(t, p.Run(ctx))
+	assert.Equal(t, []string{"replaced-a", "before-b", "b", "after-c", "last"}, *ran)
+	assert.True(t, cleanedUp)
+
+	*ran = nil
+	require.NoError(t, p.RunStage("c", ctx))
+	assert.Equal(t, []string{"c"}, *ran)
+	assert.Error(t, p.RunStage("missing", ctx))
+}
+
+func TestPipeline_RunStopsAtFirstError(t *testing.T) {
+	ran := &[]string{}
+	stages := recordingStages(ran, "a", "c")
+	failing := &funcStage{name: "b", run: func(ctx *BuildContext) error { return errors.New("failed") }}
+	p := NewPipeline(stages[0], failing, stages[1])
+
+	ctx := &BuildContext{}
+	cleanedUp := false
+	ctx.AddCleanup(func() { cleanedUp = true })
+
+	assert.EqualError(t, p.Run(ctx), "failed")
+	assert.Equal(t, []string{"a"}, *ran)
+	assert.True(t, cleanedUp)
+}
+
+func TestPipeline_CustomStage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	topDir := newTestAddon(t, map[string]string{
+		"Project.toc": "## Interface: 110100\n## Title: Project\n## Version: @project-version@\n\nProject.lua\n",
+		"Project.lua": "--@debug@\nprint(\"debug\")\n--@end-debug@\n",
+		".pkgmeta":    "package-as: Project\n",
+	})
+	releaseDir := filepath.Join(t.TempDir(), ".release")
+
+	ctx, err := NewBuildContext(&Options{
+		TopDir:        topDir,
+		ReleaseDir:    releaseDir,
+		SkipChangelog: true,
+		SkipUpload:    true,
+	})
+	require.NoError(t, err)
+
+	var zipPath string
+	p := DefaultPipeline()
+	require.NoError(t, p.InsertAfter(StagePackage, &funcStage{name: "inspect", run: func(ctx *BuildContext) error {
+		zipPath = ctx.ZipPath
+		return nil
+	}}))
+	p.Skip(StageExternals)
+	require.NoError(t, p.Run(ctx))
+
+	assert.Equal(t, "Project", ctx.ProjectName)
+	assert.Equal(t, filepath.Join(releaseDir, "Project"), ctx.PackageDir)
+	assert.FileExists(t, zipPath)
+
+	contents, err := os.ReadFile(filepath.Join(ctx.PackageDir, "Project.lua"))
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), "--[===[@debug@")
+}
@@ -0,0 +1,152 @@
+package packager
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+	"github.com/McTalian/wow-build-tools/internal/upload"
+)
+
+// PublishStage uploads the zips with the publishers of its registry, CurseForge, WoWInterface,
+// Wago and GitHub by default. Publishers skip themselves when the project has no id or
+// credentials for their site, and the `publish` field of the pkgmeta file turns them off.
+// With DryRunUpload the requests are written to the upload plan instead of being sent.
+type PublishStage struct {
+	Registry *upload.Registry
+}
+
+func NewPublishStage() *PublishStage {
+	return &PublishStage{
+		Registry: upload.DefaultRegistry(),
+	}
+}
+
+func (s *PublishStage) Name() string { return StagePublish }
+
+// artifact describes the release of the build for the publishers.
+func (s *PublishStage) artifact(ctx *BuildContext) *upload.ReleaseArtifact {
+	opts := ctx.Options
+	fileLabel := ctx.FileLabel
+	if fileLabel == "" {
+		fileLabel = ctx.NameTemplate.GetLabel(&ctx.TokenMap, ctx.Flags)
+	}
+
+	return &upload.ReleaseArtifact{
+		ProjectName:         ctx.ProjectName,
+		ProjectVersion:      ctx.TokenMap[tokens.ProjectVersion],
+		ZipPath:             ctx.ZipPath,
+		NoLibZipPath:        ctx.NoLibZipPath,
+		FileLabel:           fileLabel,
+		Changelog:           ctx.Changelog,
+		ReleaseType:         ctx.ReleaseType,
+		GameVersions:        ctx.GameVersions,
+		Relations:           upload.RelationsFromPkgMeta(ctx.PkgMeta),
+		WowiArchivePrevious: ctx.PkgMeta.WowiArchivePrevious,
+		TocFiles:            ctx.TocFiles,
+		ProjectIds: map[string]string{
+			upload.PublisherCurse: opts.CurseId,
+			upload.PublisherWowi:  opts.WowiId,
+			upload.PublisherWago:  opts.WagoId,
+		},
+		Repo: ctx.Repo,
+	}
+}
+
+// planPath is where the upload plan of a dry run is written. The addons of a workspace that
+// aren't combined share the release directory, so their plans are named after them.
+func planPath(ctx *BuildContext) string {
+	name := upload.UploadPlanFileName
+	if ctx.Workspace != nil && !ctx.Workspace.Combine {
+		name = fmt.Sprintf("upload-plan-%s.json", ctx.ProjectName)
+	}
+	return filepath.Join(ctx.Options.ReleaseDir, name)
+}
+
+// publishers returns the publishers that are turned on and enabled for the release.
+func (s *PublishStage) publishers(ctx *BuildContext, a *upload.ReleaseArtifact) []upload.Publisher {
+	l := logger.DefaultLogger
+
+	for name := range ctx.PkgMeta.Publish {
+		if _, ok := s.Registry.Get(name); !ok {
+			l.Warn("Unknown publisher %s in the publish field of the pkgmeta file", name)
+		}
+	}
+
+	var publishers []upload.Publisher
+	for _, p := range s.Registry.Publishers() {
+		if enabled, ok := ctx.PkgMeta.Publish[p.Name()]; ok && !enabled {
+			l.Verbose("Skipping %s upload (turned off in the pkgmeta file)", p.Name())
+			continue
+		}
+		if p.Name() == upload.PublisherCurse && ctx.Options.OnlyLocalization {
+			l.Info("Skipping CurseForge upload (--onlyLocalization)")
+			continue
+		}
+		if !p.Enabled(a) {
+			l.Verbose("Skipping %s upload", p.Name())
+			continue
+		}
+		publishers = append(publishers, p)
+	}
+	return publishers
+}
+
+func (s *PublishStage) Run(ctx *BuildContext) error {
+	opts := ctx.Options
+	if opts.SkipZip || opts.SkipUpload || opts.WatchMode {
+		return nil
+	}
+
+	l := logger.DefaultLogger
+	a := s.artifact(ctx)
+	if opts.DryRunUpload {
+		a.Plan = upload.NewUploadPlan()
+	}
+	publishers := s.publishers(ctx, a)
+
+	// Nothing is uploaded unless every site can be published to
+	for _, p := range publishers {
+		if err := p.Validate(a); err != nil {
+			l.Error("%s Upload Error: %v", p.Name(), err)
+			return err
+		}
+	}
+
+	var uploadWGroup sync.WaitGroup
+	uploadErrChan := make(chan error, len(publishers))
+
+	for _, p := range publishers {
+		uploadWGroup.Add(1)
+		go func() {
+			defer uploadWGroup.Done()
+			if err := p.Publish(a); err != nil {
+				l.Error("%s Upload Error: %v", p.Name(), err)
+				uploadErrChan <- err
+			}
+		}()
+	}
+
+	uploadWGroup.Wait()
+	close(uploadErrChan)
+
+	// Collect errors
+	for err := range uploadErrChan {
+		if err != nil {
+			l.Error("Upload Error: %v", err)
+			return err
+		}
+	}
+
+	if a.Plan != nil {
+		path := planPath(ctx)
+		if err := a.Plan.Write(path); err != nil {
+			return err
+		}
+		l.Info("📝 Wrote the upload plan to %s", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package packager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/McTalian/wow-build-tools/internal/configdir"
+	"github.com/McTalian/wow-build-tools/internal/external"
+	"github.com/McTalian/wow-build-tools/internal/github"
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/pkg"
+	"github.com/McTalian/wow-build-tools/internal/repo"
+	"github.com/McTalian/wow-build-tools/internal/toc"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+)
+
+// ResolveStage reads the TOC files, the repository and the pkgmeta file, and works out the
+// project name, tokens and release type of the build.
+type ResolveStage struct{}
+
+func (s *ResolveStage) Name() string { return StageResolve }
+
+func (s *ResolveStage) Run(ctx *BuildContext) error {
+	l := logger.DefaultLogger
+
+	if _, err := configdir.CreateExternalsCache(); err != nil {
+		l.Error("Cache Error: %v", err)
+		return err
+	}
+
+	tocFilePaths, err := toc.FindTocFiles(ctx.TopDir)
+	if err != nil {
+		l.Error("TOC Error: %v", err)
+		return err
+	}
+
+	l.Verbose("TOC Files: %v", tocFilePaths)
+
+	ctx.ProjectName = toc.DetermineProjectName(tocFilePaths)
+
+	l.Info("🔨 Building %s...", ctx.ProjectName)
+
+	for _, tocFilePath := range tocFilePaths {
+		t, err := toc.NewToc(tocFilePath)
+		if err != nil {
+			l.Error("TOC Error: %v", err)
+			return err
+		}
+		ctx.TocFiles = append(ctx.TocFiles, t)
+		ctx.GameVersions.AddToc(t)
+
+		l.Verbose("%s, %s", t.Filepath, t.Flavor.ToString())
+	}
+
+	if err := s.resolveRepo(ctx); err != nil {
+		return err
+	}
+
+	parseArgs := pkg.ParseArgs{
+		PkgmetaFile: ctx.Options.PkgmetaFile,
+		PkgDir:      ctx.TopDir,
+	}
+	ctx.PkgMeta, err = pkg.Parse(&parseArgs)
+	if _, ok := err.(*pkg.PkgMetaFileNotFound); ok && ctx.Workspace != nil {
+		l.Verbose("No pkgmeta file found in %s, using the defaults", ctx.TopDir)
+		ctx.PkgMeta, err = pkg.NewPkgMeta(), nil
+	}
+	if err != nil {
+		l.Error("Pkgmeta Error: %v", err)
+		return err
+	}
+
+	if ctx.PkgMeta.PackageAs != "" && ctx.ProjectName != ctx.PkgMeta.PackageAs {
+		return fmt.Errorf("Project name (%s) from TOC filename(s) does not match `package-as` name in pkgmeta file (%s)", ctx.ProjectName, ctx.PkgMeta.PackageAs)
+	}
+
+	l.Verbose("%s", ctx.PkgMeta.String())
+
+	if ctx.PkgMeta.PackageAs != "" {
+		ctx.ProjectName = ctx.PkgMeta.PackageAs
+	}
+
+	ctx.TokenMap[tokens.PackageName] = ctx.ProjectName
+	err = github.Output(string(tokens.PackageName), ctx.TokenMap[tokens.PackageName])
+	if err != nil {
+		l.Error("Output Error: %v", err)
+		return err
+	}
+
+	preGetInjectionValues := time.Now()
+	if err = ctx.Repo.GetInjectionValues(&ctx.TokenMap); err != nil {
+		l.Error("GetInjectionValues Error: %v", err)
+		return err
+	}
+	l.Timing("Getting Injection Values took %s", time.Since(preGetInjectionValues))
+	l.Verbose("%s", ctx.TokenMap.String())
+
+	s.resolveReleaseType(ctx)
+
+	return nil
+}
+
+func (s *ResolveStage) resolveRepo(ctx *BuildContext) error {
+	l := logger.DefaultLogger
+
+	r, err := repo.NewRepo(ctx.TopDir)
+	if err != nil {
+		l.Error("Repo Error: %v", err)
+		return err
+	}
+
+	l.Debug("%s", r.String())
+
+	preVr := time.Now()
+	switch r.GetVcsType() {
+	case external.Git:
+		l.Verbose("Git repository detected")
+		ctx.Repo, err = repo.NewGitRepo(r)
+		if err != nil {
+			l.Error("GitRepo Error: %v", err)
+			return err
+		}
+	case external.Svn:
+		l.Verbose("SVN repository detected")
+		ctx.Repo, err = repo.NewSvnRepo(r)
+		if err != nil {
+			l.Error("SvnRepo Error: %v", err)
+			return err
+		}
+	case external.Hg:
+		l.Verbose("Mercurial repository detected")
+		ctx.Repo, err = repo.NewHgRepo(r)
+		if err != nil {
+			l.Error("HgRepo Error: %v", err)
+			return err
+		}
+	default:
+		l.Error("Unknown repository type")
+		return fmt.Errorf("unknown repository type in %s", ctx.TopDir)
+	}
+	l.Timing("Creating VcsRepo took %s", time.Since(preVr))
+
+	return nil
+}
+
+// resolveReleaseType sets the release type, flags and build type tokens from the current tag
+// and the flavors being built.
+func (s *ResolveStage) resolveReleaseType(ctx *BuildContext) {
+	flags := ctx.Flags
+	bTTM := tokens.BuildTypeTokenMap{
+		tokens.Alpha: false,
+		tokens.Beta:  false,
+		tokens.Debug: false,
+	}
+	tag := ctx.Repo.GetCurrentTag()
+	if tag != "" {
+		if strings.Contains(tag, "alpha") {
+			flags[tokens.AlphaFlag] = "-alpha"
+			bTTM[tokens.Alpha] = true
+			ctx.ReleaseType = "alpha"
+		} else if strings.Contains(tag, "beta") {
+			flags[tokens.BetaFlag] = "-beta"
+			bTTM[tokens.Beta] = true
+			ctx.ReleaseType = "beta"
+		} else {
+			ctx.ReleaseType = "release"
+		}
+	} else {
+		flags[tokens.AlphaFlag] = "-alpha"
+		bTTM[tokens.Alpha] = true
+		ctx.ReleaseType = "alpha"
+	}
+
+	flavors := ctx.GameVersions.Flavors()
+	switch {
+	case len(flavors) == 1:
+		if flavors[0] == toc.ClassicEra {
+			flags[tokens.ClassicFlag] = "-classic"
+		}
+		bTTM = flavorBuildTypeTokens(bTTM, flavors[0])
+	case len(flavors) > 1:
+		// Flavor blocks are resolved per file by the inject stage, files shared by several flavors keep them as-is
+	default:
+		for _, token := range flavorTokens() {
+			bTTM[token] = false
+		}
+	}
+
+	ctx.BuildTypeTokens = bTTM
+}
@@ -0,0 +1,184 @@
+package packager
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/McTalian/wow-build-tools/internal/logger"
+	"github.com/McTalian/wow-build-tools/internal/pkg"
+	"github.com/McTalian/wow-build-tools/internal/toc"
+	"github.com/McTalian/wow-build-tools/internal/tokens"
+)
+
+// Workspace is a repository with several addons in its sub folders, e.g. a core addon and its
+// plugins, that are built by a single run.
+//
+// Each addon is built with its own TOC and pkgmeta files, so it's uploaded to its own
+// projects. With `combine-packages`, the addons are zipped and uploaded together instead,
+// using the projects of the first addon.
+type Workspace struct {
+	Options *Options
+	// PkgMeta is the pkgmeta file of the top directory, nil when there is none
+	PkgMeta *pkg.PkgMeta
+	// Dirs are the folders of the addons, in the order they are built
+	Dirs    []string
+	Combine bool
+}
+
+// FindWorkspace returns the workspace in the top directory, or nil when it's a single addon.
+//
+// The addons are the folders in the `packages` list of the pkgmeta file, or every folder with
+// a TOC file when the top directory doesn't have one itself.
+func FindWorkspace(opts *Options) (*Workspace, error) {
+	w := &Workspace{Options: opts}
+
+	pkgMeta, err := pkg.Parse(&pkg.ParseArgs{PkgmetaFile: opts.PkgmetaFile, PkgDir: opts.TopDir})
+	if err == nil {
+		w.PkgMeta = pkgMeta
+		w.Combine = pkgMeta.CombinePackages
+	} else if _, ok := err.(*pkg.PkgMetaFileNotFound); !ok {
+		return nil, fmt.Errorf("failed to parse the pkgmeta file: %w", err)
+	}
+
+	if w.PkgMeta != nil && len(w.PkgMeta.Packages) > 0 {
+		for _, p := range w.PkgMeta.Packages {
+			dir := filepath.Join(opts.TopDir, p)
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return nil, fmt.Errorf("package %s is not a folder in %s", p, opts.TopDir)
+			}
+			if slices.Contains(w.Dirs, dir) {
+				return nil, fmt.Errorf("package %s is listed more than once", p)
+			}
+			w.Dirs = append(w.Dirs, dir)
+		}
+		return w, nil
+	}
+
+	if _, err := toc.FindTocFiles(opts.TopDir); err == nil {
+		return nil, nil
+	}
+	w.Dirs, err = toc.FindAddonDirs(opts.TopDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(w.Dirs) == 0 {
+		// Let the build report the missing TOC file
+		return nil, nil
+	}
+	return w, nil
+}
+
+// Names returns the folder names of the addons.
+func (w *Workspace) Names() []string {
+	var names []string
+	for _, dir := range w.Dirs {
+		names = append(names, filepath.Base(dir))
+	}
+	return names
+}
+
+// packageOptions are the options of the build of the addon in dir. The pkgmeta file and
+// project ids given for the workspace don't apply to its addons.
+func (w *Workspace) packageOptions(dir string) *Options {
+	opts := *w.Options
+	opts.TopDir = dir
+	opts.PkgmetaFile = ""
+	opts.CurseId, opts.WowiId, opts.WagoId = "", "", ""
+	return &opts
+}
+
+// Run builds every addon of the workspace with the stages of the pipeline and returns their
+// contexts. When the addons are combined, the stages from the package stage onwards run once,
+// on a context that packages all of them.
+func (w *Workspace) Run(p *Pipeline) ([]*BuildContext, error) {
+	l := logger.DefaultLogger
+	l.Info("🧩 Building workspace with %s", strings.Join(w.Names(), ", "))
+
+	if !w.Combine && (w.Options.CurseId != "" || w.Options.WowiId != "" || w.Options.WagoId != "") {
+		l.Warn("Ignoring the project IDs given for the workspace, each addon uses the IDs in its TOC files")
+	}
+
+	var ctxs []*BuildContext
+	for _, dir := range w.Dirs {
+		ctx, err := NewBuildContext(w.packageOptions(dir))
+		if err != nil {
+			return ctxs, err
+		}
+		ctx.Workspace = w
+		ctxs = append(ctxs, ctx)
+	}
+
+	if !w.Combine {
+		for _, ctx := range ctxs {
+			if err := p.Run(ctx); err != nil {
+				return ctxs, err
+			}
+		}
+		return ctxs, nil
+	}
+
+	defer func() {
+		for _, ctx := range ctxs {
+			ctx.cleanup()
+		}
+	}()
+
+	split := len(p.stages)
+	if i, err := p.index(StagePackage); err == nil {
+		split = i
+	}
+
+	for _, ctx := range ctxs {
+		if err := p.runStages(ctx, p.stages[:split]); err != nil {
+			return ctxs, err
+		}
+	}
+
+	combined, err := w.combine(ctxs)
+	if err != nil {
+		return ctxs, err
+	}
+	if err := p.runStages(combined, p.stages[split:]); err != nil {
+		return ctxs, err
+	}
+
+	return append([]*BuildContext{combined}, ctxs...), nil
+}
+
+// combine creates the context that packages the addons together. It's named after the
+// `package-as` of the workspace, or else the first addon, whose project ids, changelog and
+// game versions are used for the uploads.
+func (w *Workspace) combine(ctxs []*BuildContext) (*BuildContext, error) {
+	seen := make(map[string]string)
+	for _, ctx := range ctxs {
+		if other, ok := seen[ctx.ProjectName]; ok {
+			return nil, fmt.Errorf("%s and %s are both packaged as %s", other, ctx.TopDir, ctx.ProjectName)
+		}
+		seen[ctx.ProjectName] = ctx.TopDir
+	}
+
+	primary := ctxs[0]
+	combined := *primary
+	combined.Options = w.Options
+	combined.TopDir = w.Options.TopDir
+	combined.Workspace = w
+	combined.Packages = ctxs
+	combined.cleanups = nil
+
+	if w.PkgMeta != nil && w.PkgMeta.PackageAs != "" {
+		combined.ProjectName = w.PkgMeta.PackageAs
+	}
+	combined.TokenMap = maps.Clone(primary.TokenMap)
+	combined.TokenMap[tokens.PackageName] = combined.ProjectName
+
+	combined.AddonDirs = nil
+	for _, ctx := range ctxs {
+		combined.AddonDirs = append(combined.AddonDirs, ctx.AddonDirs...)
+	}
+
+	return &combined, nil
+}